@@ -2,6 +2,7 @@ package client
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kataras/iris/v12/cache/client/rule"
@@ -26,6 +27,19 @@ type Handler struct {
 	// entries the memory cache stored responses.
 	entries map[string]*entry.Entry
 	mu      sync.RWMutex
+
+	hits   uint64 // atomic.
+	misses uint64 // atomic.
+}
+
+// Stats holds the counters of a cache `Handler`, see its `Stats` method.
+type Stats struct {
+	// Entries is the number of the stored responses, including the expired ones.
+	Entries int
+	// Hits is the total number of the requests served by a stored response.
+	Hits uint64
+	// Misses is the total number of the cacheable requests served by the handler.
+	Misses uint64
 }
 
 // NewHandler returns a new cached handler for the "bodyHandler"
@@ -63,6 +77,19 @@ func (h *Handler) AddRule(r rule.Rule) *Handler {
 	return h
 }
 
+// Stats returns a snapshot of the cache's counters, e.g. to be exposed as metrics.
+func (h *Handler) Stats() Stats {
+	h.mu.RLock()
+	entries := len(h.entries)
+	h.mu.RUnlock()
+
+	return Stats{
+		Entries: entries,
+		Hits:    atomic.LoadUint64(&h.hits),
+		Misses:  atomic.LoadUint64(&h.misses),
+	}
+}
+
 var emptyHandler = func(ctx context.Context) {
 	ctx.StopWithText(500, "cache: empty body handler")
 }
@@ -122,6 +149,7 @@ func (h *Handler) ServeHTTP(ctx context.Context) {
 	}
 
 	if !valid {
+		atomic.AddUint64(&h.misses, 1)
 		// if it's expired, then execute the original handler
 		// with our custom response recorder response writer
 		// because the net/http doesn't give us
@@ -161,6 +189,7 @@ func (h *Handler) ServeHTTP(ctx context.Context) {
 		return
 	}
 
+	atomic.AddUint64(&h.hits, 1)
 	// if it's valid then just write the cached results
 	entry.CopyHeaders(ctx.ResponseWriter().Header(), response.Headers())
 	ctx.SetLastModified(e.LastModified)
@@ -199,6 +199,21 @@ func WithSocketSharding(app *Application) {
 	app.config.SocketSharding = true
 }
 
+// WithProxyProtocol enables the PROXY protocol on all registered Hosts
+// for the load balancers of the "trustedCIDRs", at least one is required.
+// See the `Configuration.EnableProxyProtocol` and `Configuration.ProxyProtocolTrustedCIDRs` fields.
+func WithProxyProtocol(trustedCIDRs ...string) Configurator {
+	return func(app *Application) {
+		app.config.EnableProxyProtocol = true
+		app.config.ProxyProtocolTrustedCIDRs = trustedCIDRs
+	}
+}
+
+// WithH2C sets the `Configuration.EnableH2C` field to true.
+func WithH2C(app *Application) {
+	app.config.EnableH2C = true
+}
+
 // WithoutServerError will cause to ignore the matched "errors"
 // from the main application's `Run/Listen` function.
 //
@@ -825,6 +840,32 @@ type Configuration struct {
 	//
 	// Defaults to false.
 	SocketSharding bool `json:"socketSharding" yaml:"SocketSharding" toml:"SocketSharding" env:"SOCKET_SHARDING"`
+	// EnableProxyProtocol enables the PROXY protocol (v1 and v2) on all registered Hosts,
+	// so `Context.RemoteAddr()` reports the client's address sent by a TCP load balancer
+	// instead of the balancer's one.
+	// See the `WithProxyProtocol` Configurator and the `netutil.ProxyProtocol` listener too.
+	//
+	// Defaults to false.
+	EnableProxyProtocol bool `json:"enableProxyProtocol,omitempty" yaml:"EnableProxyProtocol" toml:"EnableProxyProtocol"`
+	// ProxyProtocolTrustedCIDRs are the networks of the load balancers
+	// which are allowed to send a PROXY protocol header, e.g. "10.0.0.0/8".
+	// Works when `EnableProxyProtocol` is true, at least one is required.
+	//
+	// Defaults to empty, the hosts fail to start.
+	ProxyProtocolTrustedCIDRs []string `json:"proxyProtocolTrustedCIDRs,omitempty" yaml:"ProxyProtocolTrustedCIDRs" toml:"ProxyProtocolTrustedCIDRs"`
+	// ProxyProtocolStrict closes the connections of the trusted load balancers
+	// which do not send a PROXY protocol header.
+	// Works when `EnableProxyProtocol` is true.
+	//
+	// Defaults to false.
+	ProxyProtocolStrict bool `json:"proxyProtocolStrict,omitempty" yaml:"ProxyProtocolStrict" toml:"ProxyProtocolStrict"`
+	// EnableH2C enables cleartext HTTP/2 (h2c) on all registered Hosts,
+	// both "prior knowledge" and "Upgrade: h2c" connections are accepted,
+	// so gRPC and HTTP/2 clients can talk to the server without TLS (e.g. inside a cluster).
+	// See the `WithH2C` Configurator too.
+	//
+	// Defaults to false.
+	EnableH2C bool `json:"enableH2C,omitempty" yaml:"EnableH2C" toml:"EnableH2C"`
 	// Tunneling can be optionally set to enable ngrok http(s) tunneling for this Iris app instance.
 	// See the `WithTunneling` Configurator too.
 	Tunneling TunnelingConfiguration `json:"tunneling,omitempty" yaml:"Tunneling" toml:"Tunneling"`
@@ -890,6 +931,7 @@ type Configuration struct {
 	EnablePathEscape bool `json:"enablePathEscape,omitempty" yaml:"EnablePathEscape" toml:"EnablePathEscape"`
 	// ForceLowercaseRouting if enabled, converts all registered routes paths to lowercase
 	// and it does lowercase the request path too for matching.
+	// The routes of a Party with a different case policy follow that one instead, see `Party.SetCasePolicy`.
 	//
 	// Defaults to false.
 	ForceLowercaseRouting bool `json:"forceLowercaseRouting,omitempty" yaml:"ForceLowercaseRouting" toml:"ForceLowercaseRouting"`
@@ -1210,6 +1252,22 @@ func WithConfiguration(c Configuration) Configurator {
 			main.SocketSharding = v
 		}
 
+		if v := c.EnableProxyProtocol; v {
+			main.EnableProxyProtocol = v
+		}
+
+		if v := c.ProxyProtocolTrustedCIDRs; len(v) > 0 {
+			main.ProxyProtocolTrustedCIDRs = v
+		}
+
+		if v := c.ProxyProtocolStrict; v {
+			main.ProxyProtocolStrict = v
+		}
+
+		if v := c.EnableH2C; v {
+			main.EnableH2C = v
+		}
+
 		if c.Tunneling.isEnabled() {
 			main.Tunneling = c.Tunneling
 		}
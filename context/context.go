@@ -2097,9 +2097,16 @@ func (ctx *context) IsHTTP2() bool {
 	return ctx.request.ProtoMajor == 2
 }
 
-// IsGRPC reports whether the request came from a gRPC client.
+// IsGRPC reports whether the request came from a gRPC client,
+// including the "application/grpc+proto" and "application/grpc+json" content types
+// but not the "application/grpc-web" ones.
 func (ctx *context) IsGRPC() bool {
-	return ctx.IsHTTP2() && ctx.GetContentTypeRequested() == ContentGRPCHeaderValue
+	if !ctx.IsHTTP2() {
+		return false
+	}
+
+	contentType := ctx.GetContentTypeRequested()
+	return contentType == ContentGRPCHeaderValue || strings.HasPrefix(contentType, ContentGRPCHeaderValue+"+")
 }
 
 type (
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/kataras/iris/v12/core/memstore"
 	"github.com/kataras/iris/v12/macro"
 )
 
@@ -73,6 +74,14 @@ type RouteReadOnly interface {
 	// get the route by `Application#GetRouteByPath(staticSite.RequestPath)`.
 	StaticSites() []StaticSite
 
+	// Meta returns the route's custom metadata, set through `Route.SetMeta`.
+	// It should be treated as read-only at serve-time.
+	Meta() *memstore.Store
+	// Tags returns the route's custom tags, set through `Route.Tag`.
+	Tags() []string
+	// HasTag reports whether this route is tagged with the given "tag".
+	HasTag(tag string) bool
+
 	// Sitemap properties: https://www.sitemaps.org/protocol.html
 
 	// GetLastMod returns the date of last modification of the file served by this route.
@@ -0,0 +1,353 @@
+package host
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CertManager keeps one or more TLS certificates, selects the one
+// which matches the client's SNI (server name) and, optionally, reloads them
+// when their files are changed, without a server restart (see `ReloadInterval`).
+//
+// Use it through the `TLSCertManager` Configurator or its `GetCertificate`
+// method on a custom `tls.Config`.
+//
+//     m := host.NewCertManager()
+//     m.ReloadInterval = time.Minute
+//     m.Add("example.com.crt", "example.com.key")
+//     m.Add("example.org.crt", "example.org.key")
+//     defer m.Close()
+//     app.Run(iris.TLS(":443", "", "", host.TLSCertManager(m)))
+type CertManager struct {
+	// ReloadInterval is the interval to check the certificate files for changes.
+	// Zero disables the reloading, `Reload` can still be called manually.
+	//
+	// Defaults to zero.
+	ReloadInterval time.Duration
+	// OCSP, if not nil, is called to fetch the OCSP response of a certificate on load
+	// and on every `OCSPInterval`, the response is stapled to the TLS handshakes.
+	OCSP func(cert *tls.Certificate) ([]byte, error)
+	// OCSPInterval is the interval to refresh the OCSP responses.
+	//
+	// Defaults to 12 hours.
+	OCSPInterval time.Duration
+	// OnError is called when a certificate failed to be reloaded (the previous one is kept)
+	// or its OCSP response failed to be fetched.
+	OnError func(error)
+
+	mu       sync.RWMutex
+	certs    []*managedCert
+	byName   map[string]*managedCert
+	watching bool
+	stop     chan struct{}
+}
+
+type managedCert struct {
+	certFile, keyFile string // empty when loaded from contents.
+	modTime           time.Time
+	cert              *tls.Certificate
+}
+
+// NewCertManager returns a new, empty, certificate manager.
+func NewCertManager() *CertManager {
+	return &CertManager{
+		OCSPInterval: 12 * time.Hour,
+		byName:       make(map[string]*managedCert),
+		stop:         make(chan struct{}),
+	}
+}
+
+// Add loads a certificate and its private key, from files or raw contents,
+// and registers it for the names (SANs and Common Name) of its leaf certificate.
+// The first added certificate is the default one, used when the client sends no or an unknown server name.
+func (m *CertManager) Add(certFileOrContents, keyFileOrContents string) error {
+	mc := new(managedCert)
+	if fileExists(certFileOrContents) && fileExists(keyFileOrContents) {
+		mc.certFile, mc.keyFile = certFileOrContents, keyFileOrContents
+		mc.modTime = lastModTime(mc.certFile, mc.keyFile)
+	}
+
+	cert, err := loadCertificate(certFileOrContents, keyFileOrContents)
+	if err != nil {
+		return err
+	}
+
+	return m.add(mc, cert)
+}
+
+// AddCertificate registers an already loaded certificate, it is never reloaded.
+func (m *CertManager) AddCertificate(cert tls.Certificate) error {
+	return m.add(new(managedCert), &cert)
+}
+
+func (m *CertManager) add(mc *managedCert, cert *tls.Certificate) error {
+	if err := m.prepare(cert); err != nil {
+		return err
+	}
+
+	mc.cert = cert
+
+	m.mu.Lock()
+	m.certs = append(m.certs, mc)
+	m.index()
+	m.mu.Unlock()
+
+	return nil
+}
+
+// prepare parses the leaf certificate and staples its OCSP response, if enabled.
+func (m *CertManager) prepare(cert *tls.Certificate) error {
+	if len(cert.Certificate) == 0 {
+		return errors.New("cert manager: empty certificate")
+	}
+
+	if cert.Leaf == nil {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return fmt.Errorf("cert manager: %w", err)
+		}
+		cert.Leaf = leaf
+	}
+
+	if m.OCSP != nil {
+		staple, err := m.OCSP(cert)
+		if err != nil {
+			m.reportErr(fmt.Errorf("cert manager: ocsp: %s: %w", cert.Leaf.Subject.CommonName, err))
+		} else {
+			cert.OCSPStaple = staple
+		}
+	}
+
+	return nil
+}
+
+// index rebuilds the names of all certificates, so the names which were
+// removed from a reloaded certificate are not served by it anymore.
+// The caller should hold the lock.
+func (m *CertManager) index() {
+	byName := make(map[string]*managedCert, len(m.byName))
+	for _, mc := range m.certs {
+		leaf := mc.cert.Leaf
+		names := append([]string{leaf.Subject.CommonName}, leaf.DNSNames...)
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+
+			byName[strings.ToLower(name)] = mc
+		}
+	}
+
+	m.byName = byName
+}
+
+// GetCertificate returns the certificate which matches the client's server name,
+// exact names take precedence over wildcard ones (e.g. "*.example.com").
+// It can be used as the `tls.Config.GetCertificate` field.
+func (m *CertManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.certs) == 0 {
+		return nil, errors.New("cert manager: no certificates")
+	}
+
+	if name := strings.ToLower(strings.TrimSuffix(hello.ServerName, ".")); name != "" {
+		if mc, ok := m.byName[name]; ok {
+			return mc.cert, nil
+		}
+
+		if idx := strings.IndexByte(name, '.'); idx > 0 {
+			if mc, ok := m.byName["*"+name[idx:]]; ok {
+				return mc.cert, nil
+			}
+		}
+	}
+
+	return m.certs[0].cert, nil
+}
+
+// Reload reloads the certificates whose files changed since their last load.
+// On failure, the previous certificate is kept and the error is returned.
+func (m *CertManager) Reload() error {
+	m.mu.RLock()
+	certs := append([]*managedCert(nil), m.certs...)
+	m.mu.RUnlock()
+
+	var errs []string
+	for _, mc := range certs {
+		if mc.certFile == "" {
+			continue
+		}
+
+		m.mu.RLock()
+		prevModTime := mc.modTime
+		m.mu.RUnlock()
+
+		modTime := lastModTime(mc.certFile, mc.keyFile)
+		if !modTime.After(prevModTime) {
+			continue
+		}
+
+		cert, err := tls.LoadX509KeyPair(mc.certFile, mc.keyFile)
+		if err == nil {
+			err = m.prepare(&cert)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", mc.certFile, err))
+			continue
+		}
+
+		m.mu.Lock()
+		mc.cert = &cert
+		mc.modTime = modTime
+		m.index()
+		m.mu.Unlock()
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("cert manager: reload: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// RefreshOCSP fetches and staples the OCSP responses of all certificates.
+// It does nothing if the `OCSP` field is nil.
+func (m *CertManager) RefreshOCSP() {
+	if m.OCSP == nil {
+		return
+	}
+
+	m.mu.RLock()
+	certs := append([]*managedCert(nil), m.certs...)
+	m.mu.RUnlock()
+
+	for _, mc := range certs {
+		m.mu.RLock()
+		current := mc.cert
+		m.mu.RUnlock()
+
+		staple, err := m.OCSP(current)
+		if err != nil {
+			m.reportErr(fmt.Errorf("cert manager: ocsp: %s: %w", current.Leaf.Subject.CommonName, err))
+			continue
+		}
+
+		cert := *current // copy, the current one may be in use by a handshake.
+		cert.OCSPStaple = staple
+
+		m.mu.Lock()
+		// a concurrent Reload replaced the certificate, and stapled its own response, keep that one.
+		if mc.cert == current {
+			mc.cert = &cert
+		}
+		m.mu.Unlock()
+	}
+}
+
+// Watch starts checking the certificate files for changes, every `ReloadInterval`,
+// and refreshing the OCSP responses, every `OCSPInterval`, until `Close`.
+// It does nothing if both of them are disabled.
+// It is called automatically by the `TLSCertManager` Configurator.
+func (m *CertManager) Watch() {
+	if m.ReloadInterval <= 0 && (m.OCSP == nil || m.OCSPInterval <= 0) {
+		return
+	}
+
+	m.mu.Lock()
+	if m.watching {
+		m.mu.Unlock()
+		return
+	}
+	m.watching = true
+	stop := m.stop
+	m.mu.Unlock()
+
+	go m.watch(stop)
+}
+
+func (m *CertManager) watch(stop <-chan struct{}) {
+	var reload, ocsp <-chan time.Time
+
+	if m.ReloadInterval > 0 {
+		ticker := time.NewTicker(m.ReloadInterval)
+		defer ticker.Stop()
+		reload = ticker.C
+	}
+
+	if m.OCSP != nil && m.OCSPInterval > 0 {
+		ticker := time.NewTicker(m.OCSPInterval)
+		defer ticker.Stop()
+		ocsp = ticker.C
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-reload:
+			if err := m.Reload(); err != nil {
+				m.reportErr(err)
+			}
+		case <-ocsp:
+			m.RefreshOCSP()
+		}
+	}
+}
+
+// Close stops the watching of the certificates.
+func (m *CertManager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.watching {
+		m.watching = false
+		close(m.stop)
+		m.stop = make(chan struct{})
+	}
+
+	return nil
+}
+
+func (m *CertManager) len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.certs)
+}
+
+func (m *CertManager) reportErr(err error) {
+	if m.OnError != nil {
+		m.OnError(err)
+	}
+}
+
+// TLSCertManager returns a `Configurator` which makes the Supervisor's
+// `ListenAndServeTLS` to serve the certificates of the "m" CertManager
+// and watches them for changes.
+// The certificate and key passed to the `ListenAndServeTLS` (if any) are added to the manager.
+//
+// The manager may be shared between Supervisors, so it is not closed on their shutdown,
+// call its `Close` method when it is no longer used.
+func TLSCertManager(m *CertManager) Configurator {
+	return func(su *Supervisor) {
+		su.certManager = m
+	}
+}
+
+func lastModTime(files ...string) (modTime time.Time) {
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil && info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+
+	return
+}
@@ -0,0 +1,217 @@
+package host
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func generateCert(t *testing.T, serial int64, names ...string) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return
+}
+
+func TestCertManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certmanager")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := filepath.Join(dir, "a.crt"), filepath.Join(dir, "a.key")
+	certPEM, keyPEM := generateCert(t, 1, "a.example.com")
+	ioutil.WriteFile(certFile, certPEM, 0600)
+	ioutil.WriteFile(keyFile, keyPEM, 0600)
+
+	m := NewCertManager()
+	m.OCSP = func(cert *tls.Certificate) ([]byte, error) {
+		return []byte("staple-" + cert.Leaf.SerialNumber.String()), nil
+	}
+
+	if err = m.Add(certFile, keyFile); err != nil {
+		t.Fatal(err)
+	}
+
+	wildcardCert, wildcardKey := generateCert(t, 2, "*.b.example.com")
+	if err = m.Add(string(wildcardCert), string(wildcardKey)); err != nil {
+		t.Fatal(err)
+	}
+
+	serialOf := func(serverName string) int64 {
+		cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert.Leaf.SerialNumber.Int64()
+	}
+
+	tests := []struct {
+		serverName string
+		serial     int64
+	}{
+		{"a.example.com", 1},
+		{"A.Example.com", 1},
+		{"www.b.example.com", 2},
+		{"unknown.com", 1}, // default.
+		{"", 1},
+	}
+
+	for _, tt := range tests {
+		if got := serialOf(tt.serverName); got != tt.serial {
+			t.Fatalf("[%s] expected certificate: %d but got: %d", tt.serverName, tt.serial, got)
+		}
+	}
+
+	cert, _ := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "a.example.com"})
+	if expected, got := "staple-1", string(cert.OCSPStaple); expected != got {
+		t.Fatalf("expected OCSP staple: %q but got: %q", expected, got)
+	}
+
+	// hot reload.
+	certPEM, keyPEM = generateCert(t, 3, "a.example.com")
+	ioutil.WriteFile(certFile, certPEM, 0600)
+	ioutil.WriteFile(keyFile, keyPEM, 0600)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, future, future)
+
+	if err = m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := int64(3), serialOf("a.example.com"); expected != got {
+		t.Fatalf("expected reloaded certificate: %d but got: %d", expected, got)
+	}
+
+	// invalid files keep the previous certificate.
+	ioutil.WriteFile(certFile, []byte("invalid"), 0600)
+	future = future.Add(time.Minute)
+	os.Chtimes(certFile, future, future)
+
+	if err = m.Reload(); err == nil {
+		t.Fatalf("expected a reload error")
+	}
+
+	if expected, got := int64(3), serialOf("a.example.com"); expected != got {
+		t.Fatalf("expected previous certificate: %d but got: %d", expected, got)
+	}
+
+	// names removed from a reloaded certificate fall back to the default one.
+	otherCertFile, otherKeyFile := filepath.Join(dir, "d.crt"), filepath.Join(dir, "d.key")
+	certPEM, keyPEM = generateCert(t, 4, "d.example.com", "old.example.com")
+	ioutil.WriteFile(otherCertFile, certPEM, 0600)
+	ioutil.WriteFile(otherKeyFile, keyPEM, 0600)
+	if err = m.Add(otherCertFile, otherKeyFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := int64(4), serialOf("old.example.com"); expected != got {
+		t.Fatalf("expected certificate: %d but got: %d", expected, got)
+	}
+
+	certPEM, keyPEM = generateCert(t, 5, "d.example.com")
+	ioutil.WriteFile(otherCertFile, certPEM, 0600)
+	ioutil.WriteFile(otherKeyFile, keyPEM, 0600)
+	os.Chtimes(otherCertFile, future, future)
+
+	m.Reload() // a.crt is still invalid.
+
+	if expected, got := int64(5), serialOf("d.example.com"); expected != got {
+		t.Fatalf("expected reloaded certificate: %d but got: %d", expected, got)
+	}
+
+	if expected, got := int64(3), serialOf("old.example.com"); expected != got {
+		t.Fatalf("expected default certificate for a removed name: %d but got: %d", expected, got)
+	}
+}
+
+func TestCertManagerReloadOptIn(t *testing.T) {
+	m := NewCertManager()
+	if m.ReloadInterval != 0 {
+		t.Fatalf("expected reloading to be disabled by default but got interval: %s", m.ReloadInterval)
+	}
+
+	m.Watch()
+	m.mu.RLock()
+	watching := m.watching
+	m.mu.RUnlock()
+	if watching {
+		t.Fatalf("expected no watcher when reloading and OCSP are disabled")
+	}
+	m.Close()
+}
+
+func TestCertManagerShared(t *testing.T) {
+	certPEM, keyPEM := generateCert(t, 1, "localhost")
+
+	m := NewCertManager()
+	m.ReloadInterval = time.Hour
+	if err := m.Add(string(certPEM), string(keyPEM)); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	var supervisors []*Supervisor
+	for i := 0; i < 2; i++ {
+		su := New(&http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}).Configure(TLSCertManager(m))
+		su.NoRedirect()
+		supervisors = append(supervisors, su)
+		go su.ListenAndServeTLS("", "")
+	}
+
+	watching := func() bool {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		return m.watching
+	}
+
+	for i := 0; i < 50 && !watching(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the rest of the Supervisors keep the reloading of the shared manager.
+	supervisors[0].Shutdown(context.Background())
+	// the shutdown tasks run on their own goroutine.
+	for i := 0; i < 10; i++ {
+		if !watching() {
+			t.Fatalf("expected the shared manager to not be closed by a Supervisor's shutdown")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	supervisors[1].Shutdown(context.Background())
+}
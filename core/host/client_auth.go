@@ -0,0 +1,75 @@
+package host
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// ClientAuthOptions holds the options for the mutual TLS (client certificates) authentication.
+// See `ClientAuth` Configurator.
+type ClientAuthOptions struct {
+	// CAs are the certificate authorities (PEM files or raw contents)
+	// which the client certificates are verified against.
+	CAs []string
+	// Type declares the policy for the client certificates.
+	//
+	// Defaults to tls.RequireAndVerifyClientCert.
+	Type tls.ClientAuthType
+	// VerifyPeerCertificate, if not nil, is called after the normal certificate verification,
+	// e.g. to check a revocation list or to allow only specific SPIFFE IDs.
+	VerifyPeerCertificate func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error
+}
+
+// ClientAuth returns a `Configurator` which enables mutual TLS on the Supervisor's
+// `ListenAndServeTLS` and `ListenAndServeAutoTLS`: the clients should present
+// a certificate signed by one of the "CAs".
+//
+// Usage:
+//
+//     app.Run(iris.TLS(":443", "server.crt", "server.key", host.ClientAuth(host.ClientAuthOptions{
+//         CAs: []string{"ca.crt"},
+//     })))
+//
+// The verified client's identity can be retrieved through the `middleware/mtls` package.
+func ClientAuth(opts ClientAuthOptions) Configurator {
+	return func(su *Supervisor) {
+		su.clientAuth = &opts
+	}
+}
+
+func (opts *ClientAuthOptions) apply(cfg *tls.Config) error {
+	if len(opts.CAs) == 0 {
+		return errors.New("client auth: empty CAs")
+	}
+
+	pool := x509.NewCertPool()
+	for _, ca := range opts.CAs {
+		contents := []byte(ca)
+		if fileExists(ca) {
+			b, err := ioutil.ReadFile(ca)
+			if err != nil {
+				return fmt.Errorf("client auth: %w", err)
+			}
+			contents = b
+		}
+
+		if !pool.AppendCertsFromPEM(contents) {
+			return fmt.Errorf("client auth: no valid certificates in %q", ca)
+		}
+	}
+
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = opts.Type
+	if cfg.ClientAuth == tls.NoClientCert {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	if opts.VerifyPeerCertificate != nil {
+		cfg.VerifyPeerCertificate = opts.VerifyPeerCertificate
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package host
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// HTTP3Server is the interface which a QUIC (HTTP/3) server implementation should complete,
+// see `NewHTTP3Server`.
+type HTTP3Server interface {
+	// Serve serves HTTP/3 requests over the "conn" UDP connection
+	// until the server is closed.
+	Serve(conn net.PacketConn) error
+	// Close immediately closes the server and its connections.
+	Close() error
+}
+
+// NewHTTP3Server creates the HTTP/3 server of the `HTTP3` Configurator.
+// The core does not depend on a QUIC implementation, set it to the quic-go adapter
+// of the "core/host/quic" module (a separate module, so the dependency is opt-in)
+// or to an adapter of the one of your choice, which serves the "handler" using the "tlsConfig", e.g.
+//
+//     import "github.com/kataras/iris/core/host/quic"
+//
+//     host.NewHTTP3Server = quic.New
+//     app.Run(iris.HTTP3(":443", "server.crt", "server.key"))
+//
+// Defaults to nil, the `HTTP3` Configurator fails with `ErrHTTP3Unavailable`.
+var NewHTTP3Server func(handler http.Handler, tlsConfig *tls.Config) HTTP3Server
+
+// ErrHTTP3Unavailable is returned by the `ListenAndServeTLS` and `ListenAndServeAutoTLS`
+// of a Supervisor configured with `HTTP3` when the `NewHTTP3Server` is nil.
+var ErrHTTP3Unavailable = errors.New("http3: no QUIC server implementation, see host.NewHTTP3Server")
+
+// HTTP3AltSvcMaxAge is the "ma" (max age in seconds) parameter of the "Alt-Svc" response header
+// which advertises the HTTP/3 server to the clients of the TCP one.
+var HTTP3AltSvcMaxAge = 86400
+
+// HTTP3 returns a `Configurator` which makes the Supervisor's `ListenAndServeTLS` and
+// `ListenAndServeAutoTLS` to serve the same handler over QUIC (UDP) on the same address,
+// advertises it through the "Alt-Svc" header on the TCP responses
+// and closes it on the Supervisor's shutdown.
+//
+// See the `NewHTTP3Server` package-level variable.
+func HTTP3() Configurator {
+	return func(su *Supervisor) {
+		su.http3 = true
+	}
+}
+
+// serveHTTP3 listens on the UDP port of the "ln" TCP listener and serves the HTTP/3 server there.
+// It should be called after the Server's TLSConfig and Handler are set and before the TCP server starts.
+func (su *Supervisor) serveHTTP3(ln net.Listener) error {
+	if NewHTTP3Server == nil {
+		return ErrHTTP3Unavailable
+	}
+
+	addr := ln.Addr().String()
+	if tcpAddr, ok := ln.Addr().(*net.TCPAddr); ok {
+		addr = (&net.UDPAddr{IP: tcpAddr.IP, Port: tcpAddr.Port, Zone: tcpAddr.Zone}).String()
+	}
+
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("http3: %w", err)
+	}
+
+	tlsConfig := su.Server.TLSConfig.Clone()
+	tlsConfig.NextProtos = []string{"h3"}
+
+	srv := NewHTTP3Server(su.Server.Handler, tlsConfig)
+	// the shutdown callbacks run on each Shutdown call,
+	// the server and its connection should be closed once.
+	var closeOnce sync.Once
+	su.RegisterOnShutdown(func() {
+		closeOnce.Do(func() {
+			srv.Close()
+			conn.Close()
+		})
+	})
+
+	go func() {
+		if err := srv.Serve(conn); err != nil {
+			su.notifyErr(err)
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(conn.LocalAddr().String())
+	altSvc := `h3=":` + port + `"; ma=` + strconv.Itoa(HTTP3AltSvcMaxAge)
+
+	next := su.Server.Handler
+	su.Server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Alt-Svc", altSvc)
+		next.ServeHTTP(w, r)
+	})
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package host
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testHTTP3Server serves a GET request of the received datagram's path
+// and replies with the response's status code.
+type testHTTP3Server struct {
+	handler   http.Handler
+	tlsConfig *tls.Config
+	closed    chan struct{}
+}
+
+func (s *testHTTP3Server) Serve(conn net.PacketConn) error {
+	b := make([]byte, 1024)
+	for {
+		n, addr, err := conn.ReadFrom(b)
+		if err != nil {
+			return nil // closed.
+		}
+
+		rec := httptest.NewRecorder()
+		s.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, string(b[:n]), nil))
+		conn.WriteTo([]byte(http.StatusText(rec.Code)), addr)
+	}
+}
+
+func (s *testHTTP3Server) Close() error {
+	close(s.closed)
+	return nil
+}
+
+func TestHTTP3(t *testing.T) {
+	certPEM, keyPEM := generateCert(t, 1, "localhost")
+
+	var h3 *testHTTP3Server
+	NewHTTP3Server = func(handler http.Handler, tlsConfig *tls.Config) HTTP3Server {
+		h3 = &testHTTP3Server{handler: handler, tlsConfig: tlsConfig, closed: make(chan struct{})}
+		return h3
+	}
+	defer func() { NewHTTP3Server = nil }()
+
+	// Find a free port.
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}),
+	}
+	su := New(srv).Configure(HTTP3())
+	su.NoRedirect()
+
+	go su.ListenAndServeTLS(string(certPEM), string(keyPEM))
+	defer su.Shutdown(context.Background())
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get("https://" + addr); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	_, port, _ := net.SplitHostPort(addr)
+	if expected, got := `h3=":`+port+`"; ma=86400`, resp.Header.Get("Alt-Svc"); expected != got {
+		t.Fatalf("expected Alt-Svc: %q but got: %q", expected, got)
+	}
+
+	if expected, got := []string{"h3"}, h3.tlsConfig.NextProtos; len(got) != 1 || got[0] != expected[0] {
+		t.Fatalf("expected next protos: %v but got: %v", expected, got)
+	}
+
+	// The same handler is served over UDP.
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	for path, expected := range map[string]string{"/": "OK", "/other": "Not Found"} {
+		conn.Write([]byte(path))
+
+		b := make([]byte, 64)
+		n, err := conn.Read(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := string(b[:n]); got != expected {
+			t.Fatalf("[%s] expected: %q but got: %q", path, expected, got)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	su.Shutdown(ctx)
+
+	select {
+	case <-h3.closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the HTTP/3 server to be closed on shutdown")
+	}
+}
+
+func TestHTTP3Unavailable(t *testing.T) {
+	certPEM, keyPEM := generateCert(t, 1, "localhost")
+
+	su := New(&http.Server{Addr: "127.0.0.1:0"}).Configure(HTTP3())
+	su.NoRedirect()
+
+	err := su.ListenAndServeTLS(string(certPEM), string(keyPEM))
+	if err == nil || !strings.Contains(err.Error(), ErrHTTP3Unavailable.Error()) {
+		t.Fatalf("expected error: %v but got: %v", ErrHTTP3Unavailable, err)
+	}
+}
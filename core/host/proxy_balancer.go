@@ -0,0 +1,461 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"hash/crc32"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BalancerAlgorithm is the type of the algorithm
+// that a `LoadBalancer` uses to select the next upstream.
+// Available values are: RoundRobin, LeastConnections and ConsistentHash.
+type BalancerAlgorithm uint8
+
+const (
+	// RoundRobin selects the upstreams in turn, the default algorithm.
+	RoundRobin BalancerAlgorithm = iota
+	// LeastConnections selects the upstream with the fewest in-flight requests.
+	LeastConnections
+	// ConsistentHash selects the upstream based on the `ProxyOptions.HashKey`
+	// (defaults to the client's IP), the same key is served by the same upstream
+	// for as long as it is healthy.
+	ConsistentHash
+)
+
+// ErrNoUpstream is passed to the `ProxyOptions.ErrorHandler` when
+// there is no healthy upstream to serve the request.
+var ErrNoUpstream = errors.New("proxy: no healthy upstream available")
+
+// HealthCheckOptions holds the active health checks settings of a `LoadBalancer`.
+type HealthCheckOptions struct {
+	// Path is the upstream's request path that the health checker sends GET requests to.
+	// Defaults to "/".
+	Path string
+	// Interval between health checks. Zero disables the active health checks.
+	Interval time.Duration
+	// Timeout of each health check request.
+	// Defaults to the Interval.
+	Timeout time.Duration
+	// Healthy reports whether the health check response marks the upstream as healthy.
+	// Defaults to a response status code less than 400.
+	Healthy func(resp *http.Response) bool
+}
+
+// ProxyOptions holds the settings of a `LoadBalancer`.
+// See `NewLoadBalancer` and `Party.Proxy` too.
+type ProxyOptions struct {
+	// Balancer is the algorithm to select the next upstream.
+	// Defaults to RoundRobin.
+	Balancer BalancerAlgorithm
+	// HashKey returns the key of the request that ConsistentHash uses.
+	// Defaults to the client's IP address.
+	HashKey func(r *http.Request) string
+	// HealthCheck configures the active health checks.
+	// Unhealthy upstreams are skipped until a health check marks them healthy again.
+	HealthCheck HealthCheckOptions
+	// Retries is the number of times a failed request is retried against a different upstream.
+	// Only requests with idempotent methods and without a body are retried.
+	// Defaults to zero.
+	Retries int
+	// PreserveHost keeps the original request's Host header instead of the upstream's one.
+	PreserveHost bool
+	// RequestHeaders are set to the proxied request, an empty value removes the header.
+	RequestHeaders http.Header
+	// ResponseHeaders are set to the upstream's response, an empty value removes the header.
+	ResponseHeaders http.Header
+	// ModifyRequest can optionally modify the request before sent to the upstream,
+	// it runs after the RequestHeaders are set.
+	ModifyRequest func(r *http.Request)
+	// ModifyResponse can optionally modify the upstream's response,
+	// it runs after the ResponseHeaders are set.
+	ModifyResponse func(resp *http.Response) error
+	// Transport is the http.RoundTripper used to send requests to the upstreams and their health checks.
+	// Defaults to `http.DefaultTransport`, it skips TLS verification for loopback upstreams.
+	Transport http.RoundTripper
+	// ErrorHandler handles the errors when no upstream could serve the request.
+	// Defaults to a 503 Service Unavailable on `ErrNoUpstream` and 502 Bad Gateway otherwise.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Upstream is a target of a `LoadBalancer`.
+type Upstream struct {
+	URL *url.URL
+
+	proxy  *httputil.ReverseProxy
+	down   uint32 // accessed atomically, non-zero means unhealthy.
+	active int64  // accessed atomically, the in-flight requests.
+}
+
+// IsHealthy reports whether the upstream is marked as healthy.
+func (u *Upstream) IsHealthy() bool {
+	return atomic.LoadUint32(&u.down) == 0
+}
+
+// ActiveRequests returns the number of the in-flight requests to this upstream.
+func (u *Upstream) ActiveRequests() int64 {
+	return atomic.LoadInt64(&u.active)
+}
+
+// serve proxies the request and keeps the in-flight requests count,
+// even if the proxy panics with the http.ErrAbortHandler.
+func (u *Upstream) serve(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt64(&u.active, 1)
+	defer atomic.AddInt64(&u.active, -1)
+
+	u.proxy.ServeHTTP(w, r)
+}
+
+func (u *Upstream) setHealthy(healthy bool) {
+	if healthy {
+		atomic.StoreUint32(&u.down, 0)
+	} else {
+		atomic.StoreUint32(&u.down, 1)
+	}
+}
+
+type ringEntry struct {
+	hash  uint32
+	index int
+}
+
+// LoadBalancer is an http.Handler which forwards requests to one of its upstreams.
+// See `NewLoadBalancer`.
+type LoadBalancer struct {
+	upstreams []*Upstream
+	opts      ProxyOptions
+
+	counter uint64      // accessed atomically, used by RoundRobin.
+	ring    []ringEntry // used by ConsistentHash.
+
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+var _ http.Handler = (*LoadBalancer)(nil)
+
+// consistentHashReplicas is the number of the virtual nodes per upstream on the ConsistentHash ring.
+const consistentHashReplicas = 100
+
+// NewLoadBalancer returns a new reverse proxy handler which
+// balances the requests across the "targets" based on the "opts".
+// WebSocket (and any other Upgrade) requests are passed through.
+//
+// If `HealthCheck.Interval` is set then it starts a goroutine to check the upstreams periodically,
+// call its `Close` method to stop it.
+//
+// Usage:
+// api, _ := url.Parse("http://localhost:9091")
+// api2, _ := url.Parse("http://localhost:9092")
+// lb := NewLoadBalancer([]*url.URL{api, api2}, ProxyOptions{Balancer: LeastConnections})
+// http.ListenAndServe(":8080", lb)
+func NewLoadBalancer(targets []*url.URL, opts ProxyOptions) *LoadBalancer {
+	lb := &LoadBalancer{
+		opts:    opts,
+		closeCh: make(chan struct{}),
+	}
+
+	for _, target := range targets {
+		lb.upstreams = append(lb.upstreams, lb.newUpstream(target))
+	}
+
+	if opts.Balancer == ConsistentHash {
+		for i, u := range lb.upstreams {
+			for r := 0; r < consistentHashReplicas; r++ {
+				hash := crc32.ChecksumIEEE([]byte(strconv.Itoa(r) + u.URL.String()))
+				lb.ring = append(lb.ring, ringEntry{hash: hash, index: i})
+			}
+		}
+
+		sort.Slice(lb.ring, func(i, j int) bool {
+			return lb.ring[i].hash < lb.ring[j].hash
+		})
+	}
+
+	if opts.HealthCheck.Interval > 0 {
+		go lb.healthCheckLoop()
+	}
+
+	return lb
+}
+
+type proxyErrorKey struct{}
+
+func (lb *LoadBalancer) newUpstream(target *url.URL) *Upstream {
+	p := ProxyHandler(target)
+	if lb.opts.Transport != nil {
+		p.Transport = lb.opts.Transport
+	}
+
+	director := p.Director
+	p.Director = func(r *http.Request) {
+		host := r.Host
+		director(r)
+
+		r.Header.Set("X-Forwarded-Host", host)
+		if r.TLS != nil {
+			r.Header.Set("X-Forwarded-Proto", "https")
+		} else {
+			r.Header.Set("X-Forwarded-Proto", "http")
+		}
+
+		if lb.opts.PreserveHost {
+			r.Host = host
+		}
+
+		setHeaders(r.Header, lb.opts.RequestHeaders)
+
+		if lb.opts.ModifyRequest != nil {
+			lb.opts.ModifyRequest(r)
+		}
+	}
+
+	p.ModifyResponse = func(resp *http.Response) error {
+		setHeaders(resp.Header, lb.opts.ResponseHeaders)
+
+		if lb.opts.ModifyResponse != nil {
+			return lb.opts.ModifyResponse(resp)
+		}
+
+		return nil
+	}
+
+	p.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		// Keep the error, the LoadBalancer decides whether to retry or to fire the error handler.
+		if errPtr, ok := r.Context().Value(proxyErrorKey{}).(*error); ok {
+			*errPtr = err
+		}
+	}
+
+	return &Upstream{URL: target, proxy: p}
+}
+
+func setHeaders(dest http.Header, headers http.Header) {
+	for key, values := range headers {
+		if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+			dest.Del(key)
+			continue
+		}
+
+		dest[http.CanonicalHeaderKey(key)] = values
+	}
+}
+
+// Upstreams returns the upstreams of this LoadBalancer, useful for monitoring.
+func (lb *LoadBalancer) Upstreams() []*Upstream {
+	return lb.upstreams
+}
+
+// Close stops the active health checks, if any.
+func (lb *LoadBalancer) Close() error {
+	lb.closeOnce.Do(func() {
+		close(lb.closeCh)
+	})
+
+	return nil
+}
+
+func (lb *LoadBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var tried []bool
+	if lb.opts.Retries > 0 {
+		tried = make([]bool, len(lb.upstreams))
+	}
+
+	lastErr := ErrNoUpstream
+	for attempt := 0; ; attempt++ {
+		u, index := lb.next(r, tried)
+		if u == nil {
+			// No (other) healthy upstream, fire the last error.
+			lb.handleError(w, r, lastErr)
+			return
+		}
+
+		var err error
+		req := r.WithContext(context.WithValue(r.Context(), proxyErrorKey{}, &err))
+
+		u.serve(w, req)
+
+		if err == nil {
+			return
+		}
+
+		if lb.opts.HealthCheck.Interval > 0 && !errors.Is(err, context.Canceled) {
+			// Passive check, the next active health check may restore it.
+			u.setHealthy(false)
+		}
+
+		if attempt >= lb.opts.Retries || !canRetry(r) {
+			lb.handleError(w, r, err)
+			return
+		}
+
+		lastErr = err
+		tried[index] = true
+	}
+}
+
+func canRetry(r *http.Request) bool {
+	if r.Body != nil && r.Body != http.NoBody {
+		return false
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func (lb *LoadBalancer) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if lb.opts.ErrorHandler != nil {
+		lb.opts.ErrorHandler(w, r, err)
+		return
+	}
+
+	if err == ErrNoUpstream {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+func (lb *LoadBalancer) available(index int, tried []bool) bool {
+	return lb.upstreams[index].IsHealthy() && (tried == nil || !tried[index])
+}
+
+func (lb *LoadBalancer) next(r *http.Request, tried []bool) (*Upstream, int) {
+	n := len(lb.upstreams)
+	if n == 0 {
+		return nil, -1
+	}
+
+	switch lb.opts.Balancer {
+	case LeastConnections:
+		index := -1
+		for i, u := range lb.upstreams {
+			if !lb.available(i, tried) {
+				continue
+			}
+
+			if index == -1 || u.ActiveRequests() < lb.upstreams[index].ActiveRequests() {
+				index = i
+			}
+		}
+
+		if index == -1 {
+			return nil, -1
+		}
+
+		return lb.upstreams[index], index
+	case ConsistentHash:
+		key := ""
+		if lb.opts.HashKey != nil {
+			key = lb.opts.HashKey(r)
+		} else if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			key = host
+		} else {
+			key = r.RemoteAddr
+		}
+
+		hash := crc32.ChecksumIEEE([]byte(key))
+		start := sort.Search(len(lb.ring), func(i int) bool {
+			return lb.ring[i].hash >= hash
+		})
+
+		for i := 0; i < len(lb.ring); i++ {
+			entry := lb.ring[(start+i)%len(lb.ring)]
+			if lb.available(entry.index, tried) {
+				return lb.upstreams[entry.index], entry.index
+			}
+		}
+
+		return nil, -1
+	default:
+		start := int(atomic.AddUint64(&lb.counter, 1) - 1)
+		for i := 0; i < n; i++ {
+			index := (start + i) % n
+			if lb.available(index, tried) {
+				return lb.upstreams[index], index
+			}
+		}
+
+		return nil, -1
+	}
+}
+
+func (lb *LoadBalancer) healthCheckLoop() {
+	ticker := time.NewTicker(lb.opts.HealthCheck.Interval)
+	defer ticker.Stop()
+
+	for {
+		lb.checkUpstreams()
+
+		select {
+		case <-lb.closeCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (lb *LoadBalancer) checkUpstreams() {
+	var wg sync.WaitGroup
+	for _, u := range lb.upstreams {
+		wg.Add(1)
+		go func(u *Upstream) {
+			u.setHealthy(lb.check(u))
+			wg.Done()
+		}(u)
+	}
+
+	wg.Wait()
+}
+
+func (lb *LoadBalancer) check(u *Upstream) bool {
+	opts := lb.opts.HealthCheck
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = opts.Interval
+	}
+
+	path := opts.Path
+	if path == "" {
+		path = "/"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, singleJoiningSlash(u.URL.String(), path), nil)
+	if err != nil {
+		return false
+	}
+
+	transport := u.proxy.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	if opts.Healthy != nil {
+		return opts.Healthy(resp)
+	}
+
+	return resp.StatusCode < http.StatusBadRequest
+}
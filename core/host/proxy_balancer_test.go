@@ -0,0 +1,210 @@
+package host_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12/core/host"
+)
+
+func newBackend(t *testing.T, name string) (*httptest.Server, *url.URL) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(name + " " + r.URL.Path))
+	}))
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return srv, u
+}
+
+func get(t *testing.T, lb http.Handler, path, remoteAddr string) (int, string) {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	if remoteAddr != "" {
+		r.RemoteAddr = remoteAddr
+	}
+
+	w := httptest.NewRecorder()
+	lb.ServeHTTP(w, r)
+	body, err := ioutil.ReadAll(w.Result().Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w.Code, string(body)
+}
+
+func TestLoadBalancerRoundRobin(t *testing.T) {
+	srv1, u1 := newBackend(t, "one")
+	defer srv1.Close()
+	srv2, u2 := newBackend(t, "two")
+	defer srv2.Close()
+
+	lb := host.NewLoadBalancer([]*url.URL{u1, u2}, host.ProxyOptions{})
+	defer lb.Close()
+
+	for i, expected := range []string{"one /a", "two /a", "one /a", "two /a"} {
+		if _, got := get(t, lb, "/a", ""); got != expected {
+			t.Fatalf("[%d] expected body: %q but got: %q", i, expected, got)
+		}
+	}
+}
+
+func TestLoadBalancerConsistentHash(t *testing.T) {
+	srv1, u1 := newBackend(t, "one")
+	defer srv1.Close()
+	srv2, u2 := newBackend(t, "two")
+	defer srv2.Close()
+	srv3, u3 := newBackend(t, "three")
+	defer srv3.Close()
+
+	lb := host.NewLoadBalancer([]*url.URL{u1, u2, u3}, host.ProxyOptions{Balancer: host.ConsistentHash})
+	defer lb.Close()
+
+	for _, remoteAddr := range []string{"10.0.0.1:1234", "10.0.0.2:1234", "192.168.1.5:1234"} {
+		_, expected := get(t, lb, "/", remoteAddr)
+		for i := 0; i < 5; i++ {
+			if _, got := get(t, lb, "/", remoteAddr); got != expected {
+				t.Fatalf("[%s] expected the same upstream: %q but got: %q", remoteAddr, expected, got)
+			}
+		}
+	}
+}
+
+func TestLoadBalancerRetries(t *testing.T) {
+	down, uDown := newBackend(t, "down")
+	down.Close()
+	srv, u := newBackend(t, "up")
+	defer srv.Close()
+
+	lb := host.NewLoadBalancer([]*url.URL{uDown, u}, host.ProxyOptions{Retries: 1})
+	defer lb.Close()
+
+	for i := 0; i < 4; i++ {
+		if code, got := get(t, lb, "/", ""); code != http.StatusOK || got != "up /" {
+			t.Fatalf("[%d] expected status code: %d and body: %q but got: %d and %q", i, http.StatusOK, "up /", code, got)
+		}
+	}
+
+	lb = host.NewLoadBalancer([]*url.URL{uDown}, host.ProxyOptions{Retries: 1})
+	if code, _ := get(t, lb, "/", ""); code != http.StatusBadGateway {
+		t.Fatalf("expected status code: %d but got: %d", http.StatusBadGateway, code)
+	}
+}
+
+func TestLoadBalancerHealthCheck(t *testing.T) {
+	var healthy uint32 = 1
+	sick := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" && atomic.LoadUint32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte("sick"))
+	}))
+	defer sick.Close()
+	uSick, _ := url.Parse(sick.URL)
+
+	srv, u := newBackend(t, "fine")
+	defer srv.Close()
+
+	lb := host.NewLoadBalancer([]*url.URL{uSick, u}, host.ProxyOptions{
+		HealthCheck: host.HealthCheckOptions{Path: "/health", Interval: 20 * time.Millisecond},
+	})
+	defer lb.Close()
+
+	atomic.StoreUint32(&healthy, 0)
+	time.Sleep(100 * time.Millisecond)
+
+	if lb.Upstreams()[0].IsHealthy() {
+		t.Fatalf("expected first upstream to be marked as unhealthy")
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, got := get(t, lb, "/", ""); got != "fine /" {
+			t.Fatalf("[%d] expected body: %q but got: %q", i, "fine /", got)
+		}
+	}
+
+	atomic.StoreUint32(&healthy, 1)
+	time.Sleep(100 * time.Millisecond)
+
+	if !lb.Upstreams()[0].IsHealthy() {
+		t.Fatalf("expected first upstream to be marked as healthy again")
+	}
+}
+
+func TestLoadBalancerHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "backend")
+		w.Write([]byte(r.Header.Get("X-Custom") + r.Header.Get("X-Remove")))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+
+	lb := host.NewLoadBalancer([]*url.URL{u}, host.ProxyOptions{
+		RequestHeaders:  http.Header{"X-Custom": []string{"value"}, "X-Remove": []string{""}},
+		ResponseHeaders: http.Header{"Server": []string{""}, "X-Proxied": []string{"true"}},
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Remove", "should be removed")
+	w := httptest.NewRecorder()
+	lb.ServeHTTP(w, r)
+
+	if expected, got := "value", w.Body.String(); expected != got {
+		t.Fatalf("expected body: %q but got: %q", expected, got)
+	}
+
+	if got := w.Header().Get("Server"); got != "" {
+		t.Fatalf("expected Server response header to be removed but got: %q", got)
+	}
+
+	if expected, got := "true", w.Header().Get("X-Proxied"); expected != got {
+		t.Fatalf("expected X-Proxied response header: %q but got: %q", expected, got)
+	}
+}
+
+func TestLoadBalancerAbortedResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte("short"))
+		w.(http.Flusher).Flush()
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lb := host.NewLoadBalancer([]*url.URL{u}, host.ProxyOptions{})
+	defer lb.Close()
+
+	func() {
+		defer func() {
+			if v := recover(); v != http.ErrAbortHandler {
+				t.Fatalf("expected the proxy to panic with: %v but got: %v", http.ErrAbortHandler, v)
+			}
+		}()
+
+		// the proxy panics only when it runs under a server.
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), http.ServerContextKey, &http.Server{}))
+		lb.ServeHTTP(httptest.NewRecorder(), r)
+	}()
+
+	if got := lb.Upstreams()[0].ActiveRequests(); got != 0 {
+		t.Fatalf("expected no active requests after an aborted response but got: %d", got)
+	}
+}
@@ -0,0 +1,29 @@
+// Package quic provides the quic-go adapter of the `host.HTTP3Server`.
+// It lives on its own module, so the QUIC dependency is only required by
+// the applications which serve HTTP/3.
+//
+// Usage:
+//
+//     host.NewHTTP3Server = quic.New
+//     app.Run(iris.HTTP3(":443", "server.crt", "server.key"))
+package quic
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/kataras/iris/v12/core/host"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+var _ host.HTTP3Server = (*http3.Server)(nil)
+
+// New returns a quic-go HTTP/3 server which serves the "handler" using the "tlsConfig",
+// it completes the `host.NewHTTP3Server` signature.
+func New(handler http.Handler, tlsConfig *tls.Config) host.HTTP3Server {
+	return &http3.Server{
+		Handler:   handler,
+		TLSConfig: http3.ConfigureTLSConfig(tlsConfig),
+	}
+}
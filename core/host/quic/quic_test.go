@@ -0,0 +1,119 @@
+package quic_test
+
+import (
+	stdContext "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/core/host/quic"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/host"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+func generateCert(t *testing.T) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return
+}
+
+func TestHTTP3(t *testing.T) {
+	host.NewHTTP3Server = quic.New
+	defer func() { host.NewHTTP3Server = nil }()
+
+	certPEM, keyPEM := generateCert(t)
+
+	// Find a free port.
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	app := iris.New()
+	app.Get("/", func(ctx iris.Context) {
+		ctx.Writef("%s", ctx.Request().Proto)
+	})
+
+	go app.Run(iris.HTTP3(addr, string(certPEM), string(keyPEM), func(su *host.Supervisor) {
+		su.NoRedirect()
+	}), iris.WithoutStartupLog, iris.WithoutInterruptHandler, iris.WithoutServerError(iris.ErrServerClosed))
+	defer func() {
+		ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 5*time.Second)
+		defer cancel()
+		app.Shutdown(ctx)
+	}()
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+
+	// wait for the TCP server, it advertises the HTTP/3 one.
+	tcpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = tcpClient.Get("https://" + addr); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.Header.Get("Alt-Svc") == "" {
+		t.Fatalf("expected the Alt-Svc header")
+	}
+
+	transport := &http3.Transport{TLSClientConfig: tlsConfig}
+	defer transport.Close()
+
+	h3Client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+	resp, err = h3Client.Get("https://" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if expected, got := "HTTP/3.0", string(body); expected != got {
+		t.Fatalf("expected body: %q but got: %q", expected, got)
+	}
+}
@@ -0,0 +1,430 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kataras/iris/v12/core/netutil"
+)
+
+// The environment variables which are set by the parent process on a graceful restart.
+const (
+	// EnvRestartListeners holds the listeners passed to the child process,
+	// a comma separated list of "address=fd" pairs, e.g. ":8080=3,:http=4".
+	EnvRestartListeners = "IRIS_RESTART_LISTENERS"
+	// EnvRestartReadyFD holds the file descriptor which the child process
+	// writes to when all of its registered Supervisors serve.
+	EnvRestartReadyFD = "IRIS_RESTART_READY_FD"
+)
+
+// RestartOptions holds the options for the graceful restart feature.
+// See `GracefulRestart` package-level function.
+type RestartOptions struct {
+	// Signals that trigger a restart.
+	// Defaults to SIGHUP and SIGUSR2 (not available on windows).
+	Signals []os.Signal
+	// ReadyTimeout is the maximum duration to wait for the child process
+	// to serve. On timeout the child is killed
+	// and the current process keeps serving.
+	//
+	// Defaults to 30 seconds.
+	ReadyTimeout time.Duration
+	// ShutdownTimeout is the maximum duration to wait
+	// for the active connections of the current process to be drained.
+	//
+	// Defaults to 10 seconds.
+	ShutdownTimeout time.Duration
+	// Env holds extra environment variables ("key=value") for the child process.
+	Env []string
+	// OnError is called when a restart failed, e.g. the child could not start in time.
+	OnError func(error)
+}
+
+// GracefulRestart returns a `Configurator` which registers the Supervisor
+// to the zero-downtime restart feature.
+//
+// On a SIGHUP or SIGUSR2 signal (or a manual `Restarter.Restart()` call)
+// the executable is started again and the listening sockets of all registered Supervisors
+// are passed to it (as inherited file descriptors). Once all the registered Supervisors of the child serve,
+// the Supervisors of the current process are gracefully shut down,
+// the `RegisterOnShutdown` tasks are fired and the `Serve/ListenAndServe...` methods return.
+// The `RegisterOnServe` tasks are fired by the child as usual.
+//
+// Usage:
+//
+//     app.Listen(":8080", iris.WithConfigurator(func(app *iris.Application) {
+//         app.ConfigureHost(host.GracefulRestart())
+//     }))
+//
+// Note that only the listeners created by the Supervisor are handed off,
+// not the ones passed manually through `Serve(net.Listener)`.
+// The child closes the inherited listeners which it does not use (e.g. its address was changed),
+// so all of its Supervisors should be registered before the first one serves.
+func GracefulRestart(options ...RestartOptions) Configurator {
+	return func(su *Supervisor) {
+		Restarter.Register(su, options...)
+	}
+}
+
+// Restarter is the package-level manager of the graceful restart feature.
+// See `GracefulRestart` for more.
+var Restarter = new(restarter)
+
+type restarter struct {
+	mu          sync.Mutex
+	once        sync.Once
+	opts        RestartOptions
+	supervisors []*Supervisor
+	restarting  bool
+	handoff     bool // true when the listeners are served by the child process.
+
+	// child side.
+	inheritOnce sync.Once
+	inherited   map[string]net.Listener
+	serving     map[*Supervisor]struct{}
+	readyFile   *os.File
+	child       bool
+}
+
+// Register registers a Supervisor to the restart feature,
+// the first call starts listening to the restart signals.
+func (r *restarter) Register(su *Supervisor, options ...RestartOptions) {
+	r.mu.Lock()
+	if len(options) > 0 {
+		r.opts = options[0]
+	}
+
+	for _, s := range r.supervisors {
+		if s == su {
+			r.mu.Unlock()
+			return
+		}
+	}
+
+	r.supervisors = append(r.supervisors, su)
+	r.mu.Unlock()
+
+	r.once.Do(func() { go r.notifyAndRestart() })
+}
+
+func (r *restarter) notifyAndRestart() {
+	r.mu.Lock()
+	signals := r.opts.Signals
+	r.mu.Unlock()
+
+	if len(signals) == 0 {
+		signals = restartSignals
+	}
+
+	if len(signals) == 0 {
+		return
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	for range ch {
+		if err := r.Restart(); err != nil {
+			r.mu.Lock()
+			onError := r.opts.OnError
+			r.mu.Unlock()
+			if onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// IsChild reports whether this process was started by a graceful restart.
+func (r *restarter) IsChild() bool {
+	r.inheritOnce.Do(r.loadInherited)
+	return r.child
+}
+
+// Restart starts the executable again, passes the listeners to it,
+// waits for the child to be ready and shutdowns the registered Supervisors gracefully.
+func (r *restarter) Restart() error {
+	r.mu.Lock()
+	if r.restarting {
+		r.mu.Unlock()
+		return errors.New("restart: already in progress")
+	}
+	r.restarting = true
+	opts := r.opts
+	supervisors := append([]*Supervisor(nil), r.supervisors...)
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		r.restarting = false
+		r.mu.Unlock()
+	}()
+
+	if opts.ReadyTimeout <= 0 {
+		opts.ReadyTimeout = 30 * time.Second
+	}
+
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = 10 * time.Second
+	}
+
+	var (
+		files []*os.File
+		pairs []string
+	)
+
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for _, su := range supervisors {
+		su.mu.Lock()
+		for key, ln := range su.listeners {
+			f, err := listenerFile(ln)
+			if err != nil {
+				su.mu.Unlock()
+				return fmt.Errorf("restart: %s: %w", key, err)
+			}
+
+			files = append(files, f)
+			// ExtraFiles entry i becomes file descriptor 3+i.
+			pairs = append(pairs, key+"="+strconv.Itoa(2+len(files)))
+		}
+		su.mu.Unlock()
+	}
+
+	if len(files) == 0 {
+		return errors.New("restart: no listeners to pass")
+	}
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("restart: %w", err)
+	}
+	defer readyReader.Close()
+	files = append(files, readyWriter)
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("restart: %w", err)
+	}
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(cleanRestartEnv(os.Environ()), opts.Env...)
+	cmd.Env = append(cmd.Env,
+		EnvRestartListeners+"="+strings.Join(pairs, ","),
+		EnvRestartReadyFD+"="+strconv.Itoa(2+len(files)))
+
+	if err = cmd.Start(); err != nil {
+		return fmt.Errorf("restart: %w", err)
+	}
+	// close our copy, so a child's exit results to EOF.
+	readyWriter.Close()
+
+	ready := make(chan error, 1)
+	go func() {
+		b := make([]byte, 1)
+		if _, err := readyReader.Read(b); err != nil {
+			ready <- errors.New("restart: child exited before ready")
+			return
+		}
+		ready <- nil
+	}()
+
+	select {
+	case err = <-ready:
+	case <-time.After(opts.ReadyTimeout):
+		err = fmt.Errorf("restart: child was not ready after %s", opts.ReadyTimeout)
+	}
+
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+
+	go cmd.Process.Release()
+
+	r.mu.Lock()
+	r.handoff = true
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.ShutdownTimeout)
+	defer cancel()
+
+	for _, su := range supervisors {
+		// ErrServerClosed is not reported, the same as interrupt.
+		su.shutdownOnInterrupt(ctx)
+		su.RestoreFlow()
+	}
+
+	return nil
+}
+
+func (r *restarter) registered(su *Supervisor) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range r.supervisors {
+		if s == su {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *restarter) handedOff() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.handoff
+}
+
+// listen returns an inherited listener for "addr" (if any)
+// or creates a new tcp one. The listener is tracked for a future restart
+// if the Supervisor is registered, see `GracefulRestart`.
+func (r *restarter) listen(su *Supervisor, addr string) (net.Listener, error) {
+	ln, ok := r.inherit(addr)
+	if !ok {
+		var err error
+		ln, err = netutil.TCP(addr, su.SocketSharding)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !r.registered(su) {
+		return ln, nil
+	}
+
+	su.mu.Lock()
+	if su.listeners == nil {
+		su.listeners = make(map[string]net.Listener)
+	}
+	su.listeners[addr] = ln
+	su.mu.Unlock()
+
+	return ln, nil
+}
+
+// inherit returns the inherited listener of "addr", if any.
+// Each listener can be claimed once.
+func (r *restarter) inherit(addr string) (net.Listener, bool) {
+	r.inheritOnce.Do(r.loadInherited)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ln, ok := r.inherited[addr]
+	if ok {
+		delete(r.inherited, addr)
+	}
+
+	return ln, ok
+}
+
+// ready notifies the parent process when all of the registered Supervisors serve,
+// the inherited listeners which are not claimed by them are closed.
+func (r *restarter) ready(su *Supervisor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.readyFile == nil {
+		return
+	}
+
+	if r.serving == nil {
+		r.serving = make(map[*Supervisor]struct{})
+	}
+	r.serving[su] = struct{}{}
+
+	for _, s := range r.supervisors {
+		if _, ok := r.serving[s]; !ok {
+			return
+		}
+	}
+
+	for addr, ln := range r.inherited {
+		ln.Close()
+		delete(r.inherited, addr)
+	}
+
+	r.readyFile.Write([]byte{1})
+	r.readyFile.Close()
+	r.readyFile = nil
+}
+
+func (r *restarter) loadInherited() {
+	pairs := os.Getenv(EnvRestartListeners)
+	readyFD := os.Getenv(EnvRestartReadyFD)
+	// do not pass them to any other sub process.
+	os.Unsetenv(EnvRestartListeners)
+	os.Unsetenv(EnvRestartReadyFD)
+
+	if pairs == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.child = true
+	r.inherited = make(map[string]net.Listener)
+	for _, pair := range strings.Split(pairs, ",") {
+		idx := strings.LastIndexByte(pair, '=')
+		if idx == -1 {
+			continue
+		}
+
+		fd, err := strconv.Atoi(pair[idx+1:])
+		if err != nil {
+			continue
+		}
+
+		addr := pair[:idx]
+		f := os.NewFile(uintptr(fd), addr)
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			continue
+		}
+
+		r.inherited[addr] = ln
+	}
+
+	if fd, err := strconv.Atoi(readyFD); err == nil {
+		r.readyFile = os.NewFile(uintptr(fd), "ready")
+	}
+}
+
+func listenerFile(ln net.Listener) (*os.File, error) {
+	if f, ok := ln.(interface{ File() (*os.File, error) }); ok {
+		return f.File()
+	}
+
+	return nil, fmt.Errorf("listener of type %T cannot be passed", ln)
+}
+
+func cleanRestartEnv(environ []string) []string {
+	env := environ[:0:0]
+	for _, kv := range environ {
+		if strings.HasPrefix(kv, EnvRestartListeners+"=") || strings.HasPrefix(kv, EnvRestartReadyFD+"=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+
+	return env
+}
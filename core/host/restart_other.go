@@ -0,0 +1,8 @@
+// +build windows js
+
+package host
+
+import "os"
+
+// graceful restart through signals is not supported.
+var restartSignals []os.Signal
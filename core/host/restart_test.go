@@ -0,0 +1,278 @@
+// +build !windows,!js
+
+package host
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// envRestartTestChild holds the address of the restarted test binary's server.
+const envRestartTestChild = "IRIS_TEST_RESTART_CHILD"
+
+func TestMain(m *testing.M) {
+	if addr := os.Getenv(envRestartTestChild); addr != "" {
+		os.Exit(runRestartChild(addr))
+	}
+
+	os.Exit(m.Run())
+}
+
+// runRestartChild serves the inherited listener of "addr" until a request to /exit.
+func runRestartChild(addr string) int {
+	if !Restarter.IsChild() {
+		return 1
+	}
+
+	exit := make(chan struct{})
+	su := New(&http.Server{Addr: addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/exit" {
+			close(exit)
+		}
+		w.Write([]byte("child"))
+	})}).Configure(GracefulRestart())
+
+	go su.ListenAndServe()
+
+	select {
+	case <-exit:
+	case <-time.After(10 * time.Second):
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	su.Shutdown(ctx)
+	return 0
+}
+
+func TestRestart(t *testing.T) {
+	defer func() { Restarter = new(restarter) }()
+
+	addr := "127.0.0.1:0"
+	su := New(&http.Server{Addr: addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("parent"))
+	})}).Configure(GracefulRestart(RestartOptions{
+		Env:             []string{envRestartTestChild + "=" + addr},
+		ReadyTimeout:    10 * time.Second,
+		ShutdownTimeout: 2 * time.Second,
+	}))
+
+	done := make(chan error, 1)
+	go func() { done <- su.ListenAndServe() }()
+
+	var listenAddr string
+	for i := 0; i < 50 && listenAddr == ""; i++ {
+		su.mu.Lock()
+		if ln, ok := su.listeners[addr]; ok {
+			listenAddr = ln.Addr().String()
+		}
+		su.mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+	}
+	if listenAddr == "" {
+		t.Fatalf("expected the listener to be tracked")
+	}
+
+	get := func(path string) string {
+		t.Helper()
+
+		resp, err := http.Get("http://" + listenAddr + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if expected, got := "parent", get("/"); expected != got {
+		t.Fatalf("expected body: %q but got: %q", expected, got)
+	}
+
+	if err := Restarter.Restart(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the parent's server to be shut down")
+	}
+
+	if expected, got := "child", get("/exit"); expected != got {
+		t.Fatalf("expected body: %q but got: %q", expected, got)
+	}
+}
+
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	return ln.Addr().String()
+}
+
+func TestRestartChangedAddress(t *testing.T) {
+	defer func() { Restarter = new(restarter) }()
+
+	addr, childAddr := freeAddr(t), freeAddr(t)
+	su := New(&http.Server{Addr: addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("parent"))
+	})}).Configure(GracefulRestart(RestartOptions{
+		// the child serves on a different address.
+		Env:             []string{envRestartTestChild + "=" + childAddr},
+		ReadyTimeout:    10 * time.Second,
+		ShutdownTimeout: 2 * time.Second,
+	}))
+
+	done := make(chan error, 1)
+	go func() { done <- su.ListenAndServe() }()
+
+	for i := 0; i < 50; i++ {
+		su.mu.Lock()
+		_, ok := su.listeners[addr]
+		su.mu.Unlock()
+		if ok {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if err := Restarter.Restart(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the parent's server to be shut down")
+	}
+
+	// the child closed the inherited listener which it does not use.
+	if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+		conn.Close()
+		t.Fatalf("expected the previous address to not be served")
+	}
+
+	resp, err := http.Get("http://" + childAddr + "/exit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if expected, got := "child", string(body); expected != got {
+		t.Fatalf("expected body: %q but got: %q", expected, got)
+	}
+}
+
+func TestRestartNotRegistered(t *testing.T) {
+	r := new(restarter)
+	su := New(&http.Server{})
+
+	ln, err := r.listen(su, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	if len(su.listeners) != 0 {
+		t.Fatalf("expected the listener to not be tracked without a graceful restart")
+	}
+}
+
+func TestRestartInheritListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	addr := ln.Addr().String()
+
+	// act like the parent process.
+	f, err := listenerFile(ln)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer readyReader.Close()
+	defer readyWriter.Close()
+
+	// the child owns (and closes) its file descriptors.
+	listenerFD, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	readyFD, err := syscall.Dup(int(readyWriter.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv(EnvRestartListeners, addr+"="+strconv.Itoa(listenerFD))
+	os.Setenv(EnvRestartReadyFD, strconv.Itoa(readyFD))
+
+	// act like the child process.
+	r := new(restarter)
+	if !r.IsChild() {
+		t.Fatalf("expected to be a child process")
+	}
+
+	if os.Getenv(EnvRestartListeners) != "" || os.Getenv(EnvRestartReadyFD) != "" {
+		t.Fatalf("expected the restart environment variables to be removed")
+	}
+
+	su := New(&http.Server{Addr: addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("child"))
+	})})
+
+	inherited, err := r.listen(su, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := addr, inherited.Addr().String(); expected != got {
+		t.Fatalf("expected inherited listener address: %s but got: %s", expected, got)
+	}
+
+	if _, ok := r.inherit(addr); ok {
+		t.Fatalf("expected the inherited listener to be claimed once")
+	}
+
+	go su.Server.Serve(inherited)
+	defer su.Server.Close()
+	r.ready(su)
+
+	readyReader.SetReadDeadline(time.Now().Add(3 * time.Second))
+	if _, err = readyReader.Read(make([]byte, 1)); err != nil {
+		t.Fatalf("expected ready notification but got: %v", err)
+	}
+
+	// the parent's listener is closed, the child keeps serving.
+	ln.Close()
+
+	resp, err := http.Get("http://" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if expected, got := "child", string(body); expected != got {
+		t.Fatalf("expected body: %q but got: %q", expected, got)
+	}
+}
@@ -0,0 +1,10 @@
+// +build !windows,!js
+
+package host
+
+import (
+	"os"
+	"syscall"
+)
+
+var restartSignals = []os.Signal{syscall.SIGHUP, syscall.SIGUSR2}
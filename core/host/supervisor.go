@@ -48,9 +48,22 @@ type Supervisor struct {
 	// Defaults to empty.
 	IgnoredErrors []string
 	onErr         []func(error)
+	// see `RegisterOnPreShutdown`.
+	onPreShutdown []func(context.Context)
 
 	// See `iris.Configuration.SocketSharding`.
 	SocketSharding bool
+
+	// the listeners created by this Supervisor, passed to the child process on a graceful restart.
+	listeners map[string]net.Listener
+	// see `TLSCertManager`.
+	certManager *CertManager
+	// see `ClientAuth`.
+	clientAuth *ClientAuthOptions
+	// see `WrapListener`.
+	listenerWrappers []func(net.Listener) (net.Listener, error)
+	// see `HTTP3`.
+	http3 bool
 }
 
 // New returns a new host supervisor
@@ -128,11 +141,15 @@ func (su *Supervisor) newListener() (net.Listener, error) {
 	//
 	// User still be able to call .Serve instead.
 	// l, err := netutil.TCPKeepAlive(su.Server.Addr, su.SocketReuse)
-	l, err := netutil.TCP(su.Server.Addr, su.SocketSharding)
+	l, err := Restarter.listen(su, su.Server.Addr)
 	if err != nil {
 		return nil, err
 	}
 
+	if l, err = su.wrapListener(l); err != nil {
+		return nil, err
+	}
+
 	// here we can check for sure, without the need of the supervisor's `manuallyTLS` field.
 	if netutil.IsTLS(su.Server) {
 		// means tls
@@ -143,6 +160,75 @@ func (su *Supervisor) newListener() (net.Listener, error) {
 	return l, nil
 }
 
+// WrapListener registers a function which wraps the listeners of this Supervisor
+// before they are served (and before the TLS layer, if any), e.g. the `netutil.ProxyProtocol`.
+// Wrappers are executed by registration order.
+//
+// Should be called before `Serve/ListenAndServe...`, e.g. through a `Configurator`.
+func (su *Supervisor) WrapListener(wrapper func(net.Listener) (net.Listener, error)) {
+	su.mu.Lock()
+	su.listenerWrappers = append(su.listenerWrappers, wrapper)
+	su.mu.Unlock()
+}
+
+func (su *Supervisor) wrapListener(l net.Listener) (net.Listener, error) {
+	su.mu.Lock()
+	wrappers := su.listenerWrappers
+	su.mu.Unlock()
+
+	var err error
+	for _, wrapper := range wrappers {
+		if l, err = wrapper(l); err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+// ProxyProtocol returns a `Configurator` which enables the PROXY protocol (v1 and v2)
+// on the Supervisor's listeners, the `http.Request.RemoteAddr` is the client's address
+// sent by a trusted load balancer.
+//
+// See `netutil.ProxyProtocol` for more.
+func ProxyProtocol(opts netutil.ProxyProtocolOptions) Configurator {
+	return func(su *Supervisor) {
+		su.WrapListener(func(l net.Listener) (net.Listener, error) {
+			return netutil.ProxyProtocol(l, opts)
+		})
+	}
+}
+
+// ConnLimits returns a `Configurator` which limits the connections of the Supervisor's listeners
+// through the "limiter" (global and per-IP maximum connections, accept rate)
+// and sets the server's `ReadHeaderTimeout` and `IdleTimeout`, if not already set,
+// to the limiter's options.
+//
+// Usage:
+//
+//     limiter := netutil.NewConnLimiter(netutil.ConnLimitOptions{
+//         MaxConns:      10000,
+//         MaxConnsPerIP: 50,
+//         AcceptRate:    500,
+//     })
+//     app.Run(iris.Addr(":8080", host.ConnLimits(limiter)))
+//     // limiter.Stats() reports the connection counters.
+func ConnLimits(limiter *netutil.ConnLimiter) Configurator {
+	return func(su *Supervisor) {
+		if su.Server.ReadHeaderTimeout == 0 {
+			su.Server.ReadHeaderTimeout = limiter.Options.ReadHeaderTimeout
+		}
+
+		if su.Server.IdleTimeout == 0 {
+			su.Server.IdleTimeout = limiter.Options.IdleTimeout
+		}
+
+		su.WrapListener(func(l net.Listener) (net.Listener, error) {
+			return limiter.Listener(l), nil
+		})
+	}
+}
+
 // RegisterOnError registers a function to call when errors occurred by the underline http server.
 func (su *Supervisor) RegisterOnError(cb func(error)) {
 	su.mu.Lock()
@@ -208,6 +294,9 @@ func (su *Supervisor) supervise(blockFunc func() error) error {
 	su.notifyServe(host)
 	atomic.StoreUint32(&su.closedByInterruptHandler, 0)
 	atomic.StoreUint32(&su.closedManually, 0)
+	// notify the parent process, if this is a graceful restart.
+	Restarter.ready(su)
+	systemd.ready()
 
 	err := blockFunc()
 	su.notifyErr(err)
@@ -233,6 +322,54 @@ func (su *Supervisor) supervise(blockFunc func() error) error {
 // Serve always returns a non-nil error. After Shutdown or Close, the
 // returned error is http.ErrServerClosed.
 func (su *Supervisor) Serve(l net.Listener) error {
+	l, err := su.wrapListener(l)
+	if err != nil {
+		return err
+	}
+
+	return su.serve(l)
+}
+
+// ServeTLS accepts incoming HTTPS connections on the listener "l",
+// like `Serve` but the TLS layer is added after the listener wrappers
+// (e.g. the PROXY protocol and the connection limits run on the raw connections).
+// The "certFile" and "keyFile" can be empty if the Server's TLSConfig
+// is filled with certificates.
+// HTTP/2 is enabled if the TLSConfig's NextProtos does not include "h2".
+func (su *Supervisor) ServeTLS(l net.Listener, certFile, keyFile string) error {
+	if su.Server.TLSConfig == nil {
+		su.Server.TLSConfig = new(tls.Config)
+	}
+
+	if su.clientAuth != nil {
+		if err := su.clientAuth.apply(su.Server.TLSConfig); err != nil {
+			return err
+		}
+	}
+
+	if !hasNextProto(su.Server.TLSConfig, "h2") {
+		su.Server.TLSConfig.NextProtos = append([]string{"h2"}, su.Server.TLSConfig.NextProtos...)
+	}
+
+	l, err := su.wrapListener(l)
+	if err != nil {
+		return err
+	}
+
+	return su.supervise(func() error { return su.Server.ServeTLS(l, certFile, keyFile) })
+}
+
+func hasNextProto(cfg *tls.Config, proto string) bool {
+	for _, p := range cfg.NextProtos {
+		if p == proto {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (su *Supervisor) serve(l net.Listener) error {
 	return su.supervise(func() error { return su.Server.Serve(l) })
 }
 
@@ -245,7 +382,7 @@ func (su *Supervisor) ListenAndServe() error {
 	if err != nil {
 		return err
 	}
-	return su.Serve(l)
+	return su.serve(l)
 }
 
 func loadCertificate(c, k string) (*tls.Certificate, error) {
@@ -281,18 +418,29 @@ func (su *Supervisor) ListenAndServeTLS(certFileOrContents string, keyFileOrCont
 	// and let the redirection service registered alone.
 	// e.g. https://github.com/kataras/iris/issues/1481#issuecomment-605621255
 	if su.Server.TLSConfig == nil {
-		if certFileOrContents == "" && keyFileOrContents == "" {
-			return errors.New("empty certFileOrContents or keyFileOrContents and Server.TLSConfig")
+		// a shared manager (see `TLSCertManager`) is closed by its owner.
+		m, owned := su.certManager, su.certManager == nil
+		if owned {
+			m = NewCertManager()
 		}
 
-		cert, err := loadCertificate(certFileOrContents, keyFileOrContents)
-		if err != nil {
-			return err
+		if certFileOrContents != "" || keyFileOrContents != "" {
+			if err := m.Add(certFileOrContents, keyFileOrContents); err != nil {
+				return err
+			}
 		}
 
-		getCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
-			return cert, nil
+		if m.len() == 0 {
+			return errors.New("empty certFileOrContents or keyFileOrContents and Server.TLSConfig")
 		}
+
+		// reload the certificate files on change, if enabled.
+		m.Watch()
+		if owned {
+			su.RegisterOnShutdown(func() { m.Close() })
+		}
+
+		getCertificate = m.GetCertificate
 	}
 
 	target, _ := url.Parse("https://" + netutil.ResolveVHost(su.Server.Addr)) // e.g. https://localhost:443
@@ -371,7 +519,7 @@ func (su *Supervisor) runTLS(getCertificate func(*tls.ClientHelloInfo) (*tls.Cer
 			http1RedirectServer.Shutdown(ctx)
 		})
 
-		ln, err := netutil.TCP(":http", su.SocketSharding)
+		ln, err := Restarter.listen(su, ":http")
 		if err != nil {
 			return err
 		}
@@ -410,11 +558,28 @@ func (su *Supervisor) runTLS(getCertificate func(*tls.ClientHelloInfo) (*tls.Cer
 		}
 	}
 
-	ln, err := netutil.TCP(su.Server.Addr, su.SocketSharding)
+	if su.clientAuth != nil {
+		if err := su.clientAuth.apply(su.Server.TLSConfig); err != nil {
+			return err
+		}
+	}
+
+	ln, err := Restarter.listen(su, su.Server.Addr)
 	if err != nil {
 		return err
 	}
 
+	if su.http3 {
+		if err = su.serveHTTP3(ln); err != nil {
+			ln.Close()
+			return err
+		}
+	}
+
+	if ln, err = su.wrapListener(ln); err != nil {
+		return err
+	}
+
 	return su.supervise(func() error { return su.Server.ServeTLS(ln, "", "") })
 }
 
@@ -429,6 +594,18 @@ func (su *Supervisor) RegisterOnShutdown(cb func()) {
 	su.Server.RegisterOnShutdown(cb)
 }
 
+// RegisterOnPreShutdown registers a function to call on Shutdown,
+// before the listeners are closed, e.g. to report the server as not ready
+// and let the load balancers stop sending traffic to it.
+//
+// Callbacks run in order and Shutdown waits for them,
+// the "ctx" is the one passed on Shutdown.
+func (su *Supervisor) RegisterOnPreShutdown(cb func(ctx context.Context)) {
+	su.mu.Lock()
+	su.onPreShutdown = append(su.onPreShutdown, cb)
+	su.mu.Unlock()
+}
+
 // Shutdown gracefully shuts down the server without interrupting any
 // active connections. Shutdown works by first closing all open
 // listeners, then closing all idle connections, and then waiting
@@ -442,6 +619,15 @@ func (su *Supervisor) RegisterOnShutdown(cb func()) {
 // for them to close, if desired.
 func (su *Supervisor) Shutdown(ctx context.Context) error {
 	atomic.StoreUint32(&su.closedManually, 1) // future-use
+	systemd.stopping()
+
+	su.mu.Lock()
+	onPreShutdown := su.onPreShutdown
+	su.mu.Unlock()
+	for _, f := range onPreShutdown {
+		f(ctx)
+	}
+
 	return su.Server.Shutdown(ctx)
 }
 
@@ -453,7 +639,7 @@ func (su *Supervisor) shutdownOnInterrupt(ctx context.Context) {
 // fileExists tries to report whether a local physical file of "filename" exists.
 func fileExists(filename string) bool {
 	info, err := os.Stat(filename)
-	if os.IsNotExist(err) {
+	if err != nil { // e.g. not exist or a raw content which is too long to be a filename.
 		return false
 	}
 
@@ -0,0 +1,67 @@
+package host
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kataras/iris/v12/core/netutil"
+)
+
+// systemd sends the lifecycle notifications of the Supervisors
+// to the service manager, if the process runs as a "Type=notify" systemd service.
+var systemd = &sdNotifier{stop: make(chan struct{})}
+
+type sdNotifier struct {
+	readyOnce    sync.Once
+	stoppingOnce sync.Once
+	stop         chan struct{}
+}
+
+// ready sends the READY notification, once, when the first Supervisor is served
+// and starts sending the WATCHDOG notifications, if enabled.
+func (n *sdNotifier) ready() {
+	n.readyOnce.Do(func() {
+		state := netutil.SdNotifyReady
+		if Restarter.IsChild() {
+			// the service's main process is this one now.
+			state += "\nMAINPID=" + strconv.Itoa(os.Getpid())
+		}
+
+		if ok, _ := netutil.SdNotify(state); !ok {
+			return
+		}
+
+		if interval := netutil.SdWatchdogInterval(); interval > 0 {
+			go n.watchdog(interval / 2)
+		}
+	})
+}
+
+// stopping sends the STOPPING notification, once, on the first Supervisor's Shutdown.
+// It does nothing when the listeners were passed to a child process.
+func (n *sdNotifier) stopping() {
+	if Restarter.handedOff() {
+		return
+	}
+
+	n.stoppingOnce.Do(func() {
+		close(n.stop)
+		netutil.SdNotify(netutil.SdNotifyStopping)
+	})
+}
+
+func (n *sdNotifier) watchdog(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-ticker.C:
+			netutil.SdNotify(netutil.SdNotifyWatchdog)
+		}
+	}
+}
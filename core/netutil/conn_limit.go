@@ -0,0 +1,315 @@
+package netutil
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnLimitOptions holds the options for the `ConnLimiter`.
+type ConnLimitOptions struct {
+	// MaxConns is the maximum number of simultaneous connections,
+	// when reached the listener stops accepting new ones until a connection is closed.
+	//
+	// Defaults to zero, unlimited.
+	MaxConns int `json:"maxConns,omitempty" yaml:"MaxConns" toml:"MaxConns"`
+	// MaxConnsPerIP is the maximum number of simultaneous connections of a single remote IP,
+	// the connections above that limit are closed immediately.
+	//
+	// Defaults to zero, unlimited.
+	MaxConnsPerIP int `json:"maxConnsPerIP,omitempty" yaml:"MaxConnsPerIP" toml:"MaxConnsPerIP"`
+	// AcceptRate is the maximum number of accepted connections per second,
+	// the listener waits before accepting more.
+	//
+	// Defaults to zero, unlimited.
+	AcceptRate float64 `json:"acceptRate,omitempty" yaml:"AcceptRate" toml:"AcceptRate"`
+	// AcceptBurst is the number of connections that can be accepted at once above the "AcceptRate".
+	//
+	// Defaults to 1.
+	AcceptBurst int `json:"acceptBurst,omitempty" yaml:"AcceptBurst" toml:"AcceptBurst"`
+
+	// ReadHeaderTimeout and IdleTimeout are set to the
+	// `http.Server` by the `host.ConnLimits` Configurator when its fields are zero.
+	//
+	// Default to 10 seconds and 2 minutes respectively.
+	ReadHeaderTimeout time.Duration `json:"readHeaderTimeout,omitempty" yaml:"ReadHeaderTimeout" toml:"ReadHeaderTimeout"`
+	IdleTimeout       time.Duration `json:"idleTimeout,omitempty" yaml:"IdleTimeout" toml:"IdleTimeout"`
+}
+
+// ConnStats holds the counters of a `ConnLimiter`.
+type ConnStats struct {
+	// Active is the number of the currently open connections.
+	Active int64 `json:"active"`
+	// Accepted is the total number of accepted (and not rejected) connections.
+	Accepted uint64 `json:"accepted"`
+	// RejectedPerIP is the total number of connections closed because of the `MaxConnsPerIP` limit.
+	RejectedPerIP uint64 `json:"rejectedPerIP"`
+	// Throttled is the total number of connections delayed because of the `AcceptRate` limit.
+	Throttled uint64 `json:"throttled"`
+	// Waited is the total number of times the listener waited for a free slot (see `MaxConns`).
+	Waited uint64 `json:"waited"`
+}
+
+// ConnLimiter limits the connections of one or more listeners.
+// It can be shared between listeners, so the limits and the counters are global.
+//
+// See `NewConnLimiter`, its `Listener` method and the `host.ConnLimits` Configurator.
+type ConnLimiter struct {
+	Options ConnLimitOptions
+
+	sem chan struct{} // nil if MaxConns is zero.
+
+	mu          sync.Mutex
+	perIP       map[string]int
+	nextAllowed time.Time
+
+	active        int64
+	accepted      uint64
+	rejectedPerIP uint64
+	throttled     uint64
+	waited        uint64
+}
+
+// NewConnLimiter returns a new connection limiter based on the given options.
+func NewConnLimiter(opts ConnLimitOptions) *ConnLimiter {
+	if opts.AcceptBurst <= 0 {
+		opts.AcceptBurst = 1
+	}
+
+	if opts.ReadHeaderTimeout <= 0 {
+		opts.ReadHeaderTimeout = 10 * time.Second
+	}
+
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = 2 * time.Minute
+	}
+
+	l := &ConnLimiter{
+		Options: opts,
+		perIP:   make(map[string]int),
+	}
+
+	if opts.MaxConns > 0 {
+		l.sem = make(chan struct{}, opts.MaxConns)
+	}
+
+	return l
+}
+
+// Stats returns a snapshot of the limiter's counters.
+func (l *ConnLimiter) Stats() ConnStats {
+	return ConnStats{
+		Active:        atomic.LoadInt64(&l.active),
+		Accepted:      atomic.LoadUint64(&l.accepted),
+		RejectedPerIP: atomic.LoadUint64(&l.rejectedPerIP),
+		Throttled:     atomic.LoadUint64(&l.throttled),
+		Waited:        atomic.LoadUint64(&l.waited),
+	}
+}
+
+// ActiveByIP returns the number of the currently open connections of a remote "ip".
+func (l *ConnLimiter) ActiveByIP(ip string) int {
+	l.mu.Lock()
+	n := l.perIP[ip]
+	l.mu.Unlock()
+	return n
+}
+
+// Listener returns a listener which accepts connections of "ln" based on the limiter's options.
+//
+// Note that the per-IP limit is based on the connection's remote address
+// and it is checked on the connection's first Read, not on Accept:
+// when it wraps a `ProxyProtocol` listener, the header is read there, out of the accept loop,
+// and the limit applies to the client's address instead of the load balancer's one.
+func (l *ConnLimiter) Listener(ln net.Listener) net.Listener {
+	return &limitListener{Listener: ln, limiter: l, done: make(chan struct{})}
+}
+
+type limitListener struct {
+	net.Listener
+	limiter   *ConnLimiter
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// Accept waits for and returns the next connection to the listener.
+func (ln *limitListener) Accept() (net.Conn, error) {
+	l := ln.limiter
+
+	if l.sem != nil {
+		select {
+		case l.sem <- struct{}{}:
+		default:
+			atomic.AddUint64(&l.waited, 1)
+			select {
+			case l.sem <- struct{}{}:
+			case <-ln.done:
+				return nil, errListenerClosed
+			}
+		}
+	}
+
+	if !ln.throttle() {
+		l.release()
+		return nil, errListenerClosed
+	}
+
+	c, err := ln.Listener.Accept()
+	if err != nil {
+		l.release()
+		return nil, err
+	}
+
+	atomic.AddInt64(&l.active, 1)
+	return &limitConn{Conn: c, limiter: l}, nil
+}
+
+// Close closes the listener, any blocked Accept operations will be unblocked.
+func (ln *limitListener) Close() error {
+	ln.closeOnce.Do(func() { close(ln.done) })
+	return ln.Listener.Close()
+}
+
+// throttle waits, if necessary, to respect the accept rate.
+// Reports false if the listener was closed while waiting.
+func (ln *limitListener) throttle() bool {
+	l := ln.limiter
+	if l.Options.AcceptRate <= 0 {
+		return true
+	}
+
+	interval := time.Duration(float64(time.Second) / l.Options.AcceptRate)
+
+	l.mu.Lock()
+	now := time.Now()
+	if min := now.Add(-time.Duration(l.Options.AcceptBurst-1) * interval); l.nextAllowed.Before(min) {
+		l.nextAllowed = min
+	}
+	wait := l.nextAllowed.Sub(now)
+	l.nextAllowed = l.nextAllowed.Add(interval)
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return true
+	}
+
+	atomic.AddUint64(&l.throttled, 1)
+	t := time.NewTimer(wait)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ln.done:
+		return false
+	}
+}
+
+func (l *ConnLimiter) acquireIP(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if max := l.Options.MaxConnsPerIP; max > 0 && l.perIP[ip] >= max {
+		return false
+	}
+
+	l.perIP[ip]++
+	return true
+}
+
+func (l *ConnLimiter) releaseIP(ip string) {
+	l.mu.Lock()
+	if n := l.perIP[ip]; n <= 1 {
+		delete(l.perIP, ip)
+	} else {
+		l.perIP[ip] = n - 1
+	}
+	l.mu.Unlock()
+}
+
+func (l *ConnLimiter) release() {
+	if l.sem != nil {
+		<-l.sem
+	}
+}
+
+// errConnLimitPerIP is returned by the connection's first Read when its IP is over the `MaxConnsPerIP`.
+var errConnLimitPerIP = errors.New("conn limit: too many connections from the same IP")
+
+type limitConn struct {
+	net.Conn
+	limiter *ConnLimiter
+
+	acquireOnce sync.Once
+	ip          string
+	acquired    bool
+	err         error
+
+	closeOnce sync.Once
+}
+
+func (c *limitConn) Read(b []byte) (int, error) {
+	c.acquireOnce.Do(c.acquireIP)
+	if c.err != nil {
+		c.Close()
+		return 0, c.err
+	}
+
+	return c.Conn.Read(b)
+}
+
+// acquireIP counts the connection to its remote IP, the remote address of a
+// `ProxyProtocol` connection is resolved here, on the connection's goroutine.
+func (c *limitConn) acquireIP() {
+	ip := remoteIP(c.Conn)
+	if !c.limiter.acquireIP(ip) {
+		atomic.AddUint64(&c.limiter.rejectedPerIP, 1)
+		c.err = errConnLimitPerIP
+		return
+	}
+
+	atomic.AddUint64(&c.limiter.accepted, 1)
+	c.ip = ip
+	c.acquired = true
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() {
+		// waits for a running acquireIP, and a connection closed before its first Read is never counted.
+		c.acquireOnce.Do(func() {})
+		if c.acquired {
+			c.limiter.releaseIP(c.ip)
+		}
+
+		atomic.AddInt64(&c.limiter.active, -1)
+		c.limiter.release()
+	})
+	return err
+}
+
+func remoteIP(c net.Conn) string {
+	addr := c.RemoteAddr()
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		return tcpAddr.IP.String()
+	}
+
+	if host, _, err := net.SplitHostPort(addr.String()); err == nil {
+		return host
+	}
+
+	return addr.String()
+}
+
+// closedListenerError is a net.Error which wraps the net.ErrClosed,
+// so errors.Is(err, net.ErrClosed) reports true for a closed limitListener.
+type closedListenerError struct{}
+
+func (closedListenerError) Error() string   { return net.ErrClosed.Error() }
+func (closedListenerError) Unwrap() error   { return net.ErrClosed }
+func (closedListenerError) Timeout() bool   { return false }
+func (closedListenerError) Temporary() bool { return false }
+
+var errListenerClosed net.Error = closedListenerError{}
@@ -0,0 +1,187 @@
+package netutil
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConnLimiter(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limiter := NewConnLimiter(ConnLimitOptions{MaxConns: 2, MaxConnsPerIP: 1})
+	l := limiter.Listener(ln)
+	defer l.Close()
+
+	// The per-IP limit is checked on the first Read of the connection.
+	accepted := make(chan net.Conn, 4)
+	rejected := make(chan error, 4)
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(c net.Conn) {
+				if _, err := c.Read(make([]byte, 1)); err != nil {
+					rejected <- err
+					return
+				}
+				accepted <- c
+			}(c)
+		}
+	}()
+
+	dial := func() net.Conn {
+		c, err := net.Dial("tcp4", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Write([]byte("a"))
+		return c
+	}
+
+	c1 := dial()
+	defer c1.Close()
+
+	var s1 net.Conn
+	select {
+	case s1 = <-accepted:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the first connection to be accepted")
+	}
+
+	// Same IP, over the per-IP limit: closed by the server.
+	c2 := dial()
+	defer c2.Close()
+
+	select {
+	case err = <-rejected:
+		if err != errConnLimitPerIP {
+			t.Fatalf("expected error: %v but got: %v", errConnLimitPerIP, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the second connection to be rejected")
+	}
+
+	c2.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err = c2.Read(make([]byte, 1)); err == nil {
+		t.Fatal("expected the second connection to be closed")
+	}
+
+	if expected, got := 1, limiter.ActiveByIP("127.0.0.1"); expected != got {
+		t.Fatalf("expected %d active connections of the IP but got %d", expected, got)
+	}
+
+	stats := limiter.Stats()
+	if stats.Active != 1 || stats.Accepted != 1 || stats.RejectedPerIP != 1 {
+		t.Fatalf("unexpected stats: %#+v", stats)
+	}
+
+	s1.Close()
+	if stats = limiter.Stats(); stats.Active != 0 {
+		t.Fatalf("expected zero active connections but got %d", stats.Active)
+	}
+
+	c3 := dial()
+	defer c3.Close()
+
+	select {
+	case s3 := <-accepted:
+		s3.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a connection to be accepted after the previous one was closed")
+	}
+}
+
+func TestConnLimiterAcceptRate(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limiter := NewConnLimiter(ConnLimitOptions{AcceptRate: 10, AcceptBurst: 2})
+	l := limiter.Listener(ln)
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		c, err := net.Dial("tcp4", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		c, err := l.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected the third connection to be throttled but all accepted in %s", elapsed)
+	}
+
+	if expected, got := uint64(1), limiter.Stats().Throttled; expected != got {
+		t.Fatalf("expected %d throttled connections but got %d", expected, got)
+	}
+
+	// Close unblocks a throttled Accept.
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		l.Close()
+	}()
+
+	if _, err = l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected error: %v but got: %v", net.ErrClosed, err)
+	}
+}
+
+func TestConnLimiterProxyProtocol(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err = ProxyProtocol(ln, ProxyProtocolOptions{TrustedCIDRs: []string{"127.0.0.0/8"}, HeaderTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewConnLimiter(ConnLimitOptions{MaxConnsPerIP: 1}).Listener(ln)
+	defer l.Close()
+
+	// A slow client which does not send its header does not block the accept loop.
+	slow, err := net.Dial("tcp4", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slow.Close()
+
+	fast, err := net.Dial("tcp4", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fast.Close()
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		c, err := l.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the connections to be accepted without waiting for their headers but took %s", elapsed)
+	}
+}
@@ -0,0 +1,295 @@
+package netutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProxyProtocolOptions holds the options for the `ProxyProtocol` listener.
+type ProxyProtocolOptions struct {
+	// TrustedCIDRs are the networks (e.g. "10.0.0.0/8") of the load balancers
+	// which are allowed to send a PROXY protocol header.
+	// Connections from other sources are served as they are.
+	// At least one is required, as a trusted source can set any client address,
+	// use "0.0.0.0/0" and "::/0" to trust all sources.
+	TrustedCIDRs []string `json:"trustedCIDRs,omitempty" yaml:"TrustedCIDRs" toml:"TrustedCIDRs"`
+	// Strict closes the connections of the trusted sources which do not send a PROXY protocol header.
+	//
+	// Defaults to false, the header is optional.
+	Strict bool `json:"strict,omitempty" yaml:"Strict" toml:"Strict"`
+	// HeaderTimeout is the maximum duration to wait for the PROXY protocol header.
+	//
+	// Defaults to 5 seconds.
+	HeaderTimeout time.Duration `json:"headerTimeout,omitempty" yaml:"HeaderTimeout" toml:"HeaderTimeout"`
+}
+
+// ProxyProtocol returns a listener which parses the PROXY protocol (v1 text and v2 binary) header
+// of the connections accepted by "l" and reports the original source and destination addresses
+// through their `RemoteAddr` and `LocalAddr` methods, so the `http.Request.RemoteAddr`
+// is the client's address instead of the load balancer's one.
+//
+// The header is read on the first use of the connection, not on `Accept`,
+// so a slow client cannot block the accept loop.
+//
+// It returns an error if the "TrustedCIDRs" are empty or one of them is invalid.
+func ProxyProtocol(l net.Listener, opts ProxyProtocolOptions) (net.Listener, error) {
+	if len(opts.TrustedCIDRs) == 0 {
+		return nil, ErrProxyProtocolNoTrustedCIDRs
+	}
+
+	trusted := make([]*net.IPNet, 0, len(opts.TrustedCIDRs))
+	for _, cidr := range opts.TrustedCIDRs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("proxy protocol: %w", err)
+		}
+
+		trusted = append(trusted, ipNet)
+	}
+
+	if opts.HeaderTimeout <= 0 {
+		opts.HeaderTimeout = 5 * time.Second
+	}
+
+	return &proxyProtocolListener{
+		Listener:      l,
+		trusted:       trusted,
+		headerTimeout: opts.HeaderTimeout,
+		strict:        opts.Strict,
+	}, nil
+}
+
+type proxyProtocolListener struct {
+	net.Listener
+	trusted       []*net.IPNet
+	headerTimeout time.Duration
+	strict        bool
+}
+
+// Accept waits for and returns the next connection to the listener.
+func (l *proxyProtocolListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	if !l.isTrusted(c.RemoteAddr()) {
+		return c, nil
+	}
+
+	return &proxyProtocolConn{Conn: c, headerTimeout: l.headerTimeout, strict: l.strict}, nil
+}
+
+func (l *proxyProtocolListener) isTrusted(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+
+	for _, ipNet := range l.trusted {
+		if ipNet.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	// ErrProxyProtocolNoTrustedCIDRs is returned by `ProxyProtocol` when no trusted source is set.
+	ErrProxyProtocolNoTrustedCIDRs = errors.New("proxy protocol: at least one trusted CIDR is required")
+	// ErrProxyProtocolHeader is returned by the connection's `Read`
+	// when the PROXY protocol header is malformed, or missing on `ProxyProtocolOptions.Strict` mode.
+	ErrProxyProtocolHeader = errors.New("proxy protocol: invalid header")
+)
+
+var (
+	proxyProtocolV1Prefix  = []byte("PROXY ")
+	proxyProtocolSignature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+type proxyProtocolConn struct {
+	net.Conn
+	headerTimeout time.Duration
+	strict        bool
+
+	once    sync.Once
+	br      *bufio.Reader
+	err     error
+	srcAddr net.Addr
+	dstAddr net.Addr
+}
+
+func (c *proxyProtocolConn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	return c.br.Read(b)
+}
+
+func (c *proxyProtocolConn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.srcAddr != nil {
+		return c.srcAddr
+	}
+
+	return c.Conn.RemoteAddr()
+}
+
+func (c *proxyProtocolConn) LocalAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.dstAddr != nil {
+		return c.dstAddr
+	}
+
+	return c.Conn.LocalAddr()
+}
+
+func (c *proxyProtocolConn) readHeader() {
+	c.br = bufio.NewReader(c.Conn)
+
+	c.Conn.SetReadDeadline(time.Now().Add(c.headerTimeout))
+	defer c.Conn.SetReadDeadline(time.Time{})
+
+	// A header's minimum length is the v2 signature's length (and a v1 one is longer).
+	b, err := c.br.Peek(len(proxyProtocolSignature))
+	if err != nil {
+		// Not enough data, e.g. a client without a header that waits for the server first.
+		if n := c.br.Buffered(); n > 0 && !c.strict {
+			b, _ = c.br.Peek(n)
+			if !bytes.HasPrefix(proxyProtocolV1Prefix, b) && !bytes.HasPrefix(proxyProtocolSignature, b) {
+				return
+			}
+		}
+
+		c.err = err
+		return
+	}
+
+	switch {
+	case bytes.HasPrefix(b, proxyProtocolV1Prefix):
+		c.err = c.readHeaderV1()
+	case bytes.Equal(b, proxyProtocolSignature):
+		c.err = c.readHeaderV2()
+	case c.strict:
+		c.err = ErrProxyProtocolHeader
+	}
+
+	if c.err != nil {
+		c.Conn.Close()
+	}
+}
+
+// readHeaderV1 parses a "PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n" line.
+func (c *proxyProtocolConn) readHeaderV1() error {
+	const maxLength = 107
+
+	var line []byte
+	for {
+		b, err := c.br.ReadSlice('\n')
+		line = append(line, b...)
+		if len(line) > maxLength {
+			return ErrProxyProtocolHeader
+		}
+
+		if err == nil {
+			break
+		}
+
+		if err != bufio.ErrBufferFull {
+			return err
+		}
+	}
+
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return ErrProxyProtocolHeader
+	}
+
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) < 2 {
+		return ErrProxyProtocolHeader
+	}
+
+	switch fields[1] {
+	case "UNKNOWN":
+		return nil // keep the connection's addresses.
+	case "TCP4", "TCP6":
+		if len(fields) != 6 {
+			return ErrProxyProtocolHeader
+		}
+	default:
+		return ErrProxyProtocolHeader
+	}
+
+	srcIP, dstIP := net.ParseIP(fields[2]), net.ParseIP(fields[3])
+	srcPort, err1 := strconv.ParseUint(fields[4], 10, 16)
+	dstPort, err2 := strconv.ParseUint(fields[5], 10, 16)
+	if srcIP == nil || dstIP == nil || err1 != nil || err2 != nil {
+		return ErrProxyProtocolHeader
+	}
+
+	c.srcAddr = &net.TCPAddr{IP: srcIP, Port: int(srcPort)}
+	c.dstAddr = &net.TCPAddr{IP: dstIP, Port: int(dstPort)}
+	return nil
+}
+
+// readHeaderV2 parses the binary header: signature (12), version and command (1),
+// address family and protocol (1), length of the addresses and TLVs (2).
+func (c *proxyProtocolConn) readHeaderV2() error {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(c.br, header); err != nil {
+		return err
+	}
+
+	if header[12]>>4 != 2 {
+		return ErrProxyProtocolHeader
+	}
+
+	command := header[12] & 0x0F
+	family := header[13]
+	length := int(binary.BigEndian.Uint16(header[14:16]))
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return err
+	}
+
+	if command == 0x00 { // LOCAL, e.g. health checks of the balancer.
+		return nil
+	}
+
+	if command != 0x01 { // not PROXY.
+		return ErrProxyProtocolHeader
+	}
+
+	switch family >> 4 {
+	case 0x1: // AF_INET
+		if length < 12 {
+			return ErrProxyProtocolHeader
+		}
+
+		c.srcAddr = &net.TCPAddr{IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:10]))}
+		c.dstAddr = &net.TCPAddr{IP: net.IP(payload[4:8]), Port: int(binary.BigEndian.Uint16(payload[10:12]))}
+	case 0x2: // AF_INET6
+		if length < 36 {
+			return ErrProxyProtocolHeader
+		}
+
+		c.srcAddr = &net.TCPAddr{IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:34]))}
+		c.dstAddr = &net.TCPAddr{IP: net.IP(payload[16:32]), Port: int(binary.BigEndian.Uint16(payload[34:36]))}
+	}
+	// AF_UNSPEC and AF_UNIX: keep the connection's addresses.
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package netutil
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestProxyProtocol(t *testing.T) {
+	v2 := func(src, dst net.IP, srcPort, dstPort uint16) []byte {
+		b := append([]byte{}, proxyProtocolSignature...)
+		b = append(b, 0x21, 0x11, 0, 12) // PROXY, TCP over IPv4, 12 bytes.
+		b = append(b, src.To4()...)
+		b = append(b, dst.To4()...)
+		ports := make([]byte, 4)
+		binary.BigEndian.PutUint16(ports[0:2], srcPort)
+		binary.BigEndian.PutUint16(ports[2:4], dstPort)
+		return append(b, ports...)
+	}
+
+	local := []string{"127.0.0.0/8"}
+
+	tests := []struct {
+		name       string
+		trusted    []string
+		strict     bool
+		header     []byte
+		remoteAddr string // empty for the connection's address.
+		fail       bool
+	}{
+		{"v1", local, false, []byte("PROXY TCP4 203.0.113.7 10.0.0.1 56324 443\r\n"), "203.0.113.7:56324", false},
+		{"v1 ipv6", local, false, []byte("PROXY TCP6 2001:db8::1 2001:db8::2 1000 443\r\n"), "[2001:db8::1]:1000", false},
+		{"v1 unknown", local, false, []byte("PROXY UNKNOWN\r\n"), "", false},
+		{"v2", local, true, v2(net.ParseIP("198.51.100.9"), net.ParseIP("10.0.0.1"), 4000, 443), "198.51.100.9:4000", false},
+		{"no header", local, false, nil, "", false},
+		{"no header strict", local, true, nil, "", true},
+		{"untrusted", []string{"10.0.0.0/8"}, false, []byte("PROXY TCP4 203.0.113.7 10.0.0.1 56324 443\r\n"), "", true},
+		{"malformed", local, false, []byte("PROXY TCP4 invalid\r\n"), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ln, err = ProxyProtocol(ln, ProxyProtocolOptions{TrustedCIDRs: tt.trusted, Strict: tt.strict})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(r.RemoteAddr))
+			})}
+			go srv.Serve(ln)
+			defer srv.Close()
+
+			conn, err := net.Dial("tcp", ln.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			conn.Write(append(tt.header, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"...))
+
+			resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			if tt.fail {
+				if err == nil && resp.StatusCode == http.StatusOK {
+					t.Fatalf("expected a failure but got: %s", resp.Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			body := make([]byte, 128)
+			n, _ := resp.Body.Read(body)
+			got := string(body[:n])
+
+			expected := tt.remoteAddr
+			if expected == "" {
+				expected = conn.LocalAddr().String()
+			}
+
+			if expected != got {
+				t.Fatalf("expected remote address: %q but got: %q", expected, got)
+			}
+		})
+	}
+
+	if _, err := ProxyProtocol(nil, ProxyProtocolOptions{TrustedCIDRs: []string{"invalid"}}); err == nil {
+		t.Fatalf("expected an error on invalid trusted CIDRs")
+	}
+
+	if _, err := ProxyProtocol(nil, ProxyProtocolOptions{}); err != ErrProxyProtocolNoTrustedCIDRs {
+		t.Fatalf("expected error: %v but got: %v", ErrProxyProtocolNoTrustedCIDRs, err)
+	}
+}
@@ -0,0 +1,145 @@
+package netutil
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// listenFdsStart is the first file descriptor passed by the service manager (SD_LISTEN_FDS_START).
+var listenFdsStart = 3
+
+// InheritedListener is a listener passed by the service manager, see `FromEnv`.
+type InheritedListener struct {
+	net.Listener
+	// Name is the name of the socket, set by the "FileDescriptorName" option
+	// of the systemd socket unit, defaults to the unit's name.
+	Name string
+}
+
+var (
+	inheritedOnce      sync.Once
+	inheritedListeners []InheritedListener
+	inheritedErr       error
+)
+
+// FromEnv returns the listeners passed by the service manager (systemd socket activation)
+// through the LISTEN_PID, LISTEN_FDS and LISTEN_FDNAMES environment variables.
+// The environment variables are removed, so they are not passed to any sub process,
+// next calls return the same listeners.
+//
+// Returns an empty slice if the process was not socket activated.
+// Non-stream sockets are ignored.
+func FromEnv() ([]InheritedListener, error) {
+	inheritedOnce.Do(func() {
+		inheritedListeners, inheritedErr = listenersFromEnv()
+	})
+
+	return inheritedListeners, inheritedErr
+}
+
+// FromEnvByName returns the inherited listeners of a "name" (see "FileDescriptorName").
+// If "name" is empty then it returns all inherited listeners.
+func FromEnvByName(name string) ([]net.Listener, error) {
+	inherited, err := FromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	var listeners []net.Listener
+	for _, l := range inherited {
+		if name == "" || l.Name == name {
+			listeners = append(listeners, l.Listener)
+		}
+	}
+
+	return listeners, nil
+}
+
+func listenersFromEnv() ([]InheritedListener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, nil // not for us.
+	}
+
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, err
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	listeners := make([]InheritedListener, 0, n)
+	for i := 0; i < n; i++ {
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		f := os.NewFile(uintptr(listenFdsStart+i), name)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			continue // not a stream socket.
+		}
+
+		listeners = append(listeners, InheritedListener{Listener: l, Name: name})
+	}
+
+	return listeners, nil
+}
+
+// Notification states for the `SdNotify` function.
+const (
+	SdNotifyReady    = "READY=1"
+	SdNotifyStopping = "STOPPING=1"
+	SdNotifyWatchdog = "WATCHDOG=1"
+)
+
+// SdNotify sends a "state" notification (e.g. "READY=1") to the service manager
+// through the NOTIFY_SOCKET environment variable.
+// It reports false if the process is not running under a notify-aware service manager.
+func SdNotify(state string) (bool, error) {
+	addr := &net.UnixAddr{Name: os.Getenv("NOTIFY_SOCKET"), Net: "unixgram"}
+	if addr.Name == "" {
+		return false, nil
+	}
+
+	conn, err := net.DialUnix(addr.Net, nil, addr)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if _, err = conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SdWatchdogInterval returns the watchdog interval (WATCHDOG_USEC) of the service manager,
+// the "WATCHDOG=1" notification should be sent more often than that.
+// Returns zero if the watchdog is not enabled for this process.
+func SdWatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	if v := os.Getenv("WATCHDOG_PID"); v != "" {
+		if pid, err := strconv.Atoi(v); err != nil || pid != os.Getpid() {
+			return 0
+		}
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
@@ -0,0 +1,123 @@
+// +build !windows,!wasm
+
+package netutil
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestFromEnv(t *testing.T) {
+	var fds []int
+	for i := 0; i < 2; i++ {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+
+		f, err := ln.(*net.TCPListener).File()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		fds = append(fds, int(f.Fd()))
+	}
+
+	// the service manager passes consecutive file descriptors.
+	start := 100
+	for i, fd := range fds {
+		if err := unix.Dup2(fd, start+i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	listenFdsStart = start
+	inheritedOnce = sync.Once{}
+
+	os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	os.Setenv("LISTEN_FDS", "2")
+	os.Setenv("LISTEN_FDNAMES", "http:admin")
+
+	inherited, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := 2, len(inherited); expected != got {
+		t.Fatalf("expected %d inherited listeners but got: %d", expected, got)
+	}
+
+	for _, l := range inherited {
+		defer l.Close()
+	}
+
+	if inherited[0].Name != "http" || inherited[1].Name != "admin" {
+		t.Fatalf("unexpected listener names: %q and %q", inherited[0].Name, inherited[1].Name)
+	}
+
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Fatalf("expected LISTEN_FDS to be removed")
+	}
+
+	admin, err := FromEnvByName("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(admin) != 1 || admin[0] != inherited[1].Listener {
+		t.Fatalf("expected the admin listener")
+	}
+}
+
+func TestSdNotify(t *testing.T) {
+	os.Unsetenv("NOTIFY_SOCKET")
+	if ok, err := SdNotify(SdNotifyReady); ok || err != nil {
+		t.Fatalf("expected no notification without NOTIFY_SOCKET but got: %v, %v", ok, err)
+	}
+
+	dir, err := ioutil.TempDir("", "sdnotify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	os.Setenv("NOTIFY_SOCKET", socket)
+	defer os.Unsetenv("NOTIFY_SOCKET")
+
+	if ok, err := SdNotify(SdNotifyReady); !ok || err != nil {
+		t.Fatalf("expected notification to be sent but got: %v, %v", ok, err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	b := make([]byte, 64)
+	n, err := conn.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := SdNotifyReady, string(b[:n]); expected != got {
+		t.Fatalf("expected state: %q but got: %q", expected, got)
+	}
+
+	os.Setenv("WATCHDOG_USEC", "2000000")
+	defer os.Unsetenv("WATCHDOG_USEC")
+	if expected, got := 2*time.Second, SdWatchdogInterval(); expected != got {
+		t.Fatalf("expected watchdog interval: %s but got: %s", expected, got)
+	}
+}
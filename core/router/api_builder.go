@@ -3,6 +3,7 @@ package router
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/kataras/iris/v12/context"
 	"github.com/kataras/iris/v12/core/errgroup"
+	"github.com/kataras/iris/v12/core/host"
 	"github.com/kataras/iris/v12/hero"
 	"github.com/kataras/iris/v12/macro"
 	macroHandler "github.com/kataras/iris/v12/macro/handler"
@@ -192,6 +194,10 @@ type APIBuilder struct {
 	handlerExecutionRules ExecutionRules
 	// the per-party (and its children) route registration rule, see `SetRegisterRule`.
 	routeRegisterRule RouteRegisterRule
+	// the per-party (and its children) path policies,
+	// see `SetTrailingSlashPolicy` and `SetCasePolicy`.
+	trailingSlashPolicy PathPolicy
+	casePolicy          PathPolicy
 }
 
 var _ Party = (*APIBuilder)(nil)
@@ -312,6 +318,56 @@ func (api *APIBuilder) SetRegisterRule(rule RouteRegisterRule) Party {
 	return api
 }
 
+// PathPolicy is a type of uint8.
+// Defines how a request path which differs from the registered one
+// by a trailing slash or by its letter case is handled.
+// Available values are: PathPolicyDefault, PathPolicyRedirect, PathPolicyStrict and PathPolicyTolerant.
+//
+// See `Party#SetTrailingSlashPolicy` and `Party#SetCasePolicy`.
+type PathPolicy uint8
+
+const (
+	// PathPolicyDefault follows the application's `Configuration`,
+	// the default policy.
+	PathPolicyDefault PathPolicy = iota
+	// PathPolicyRedirect redirects the client to the registered path,
+	// permanently or temporary (on POST and PUT requests).
+	PathPolicyRedirect
+	// PathPolicyStrict does not match a different path, the client receives a not found.
+	PathPolicyStrict
+	// PathPolicyTolerant executes the route's handlers without a redirection.
+	PathPolicyTolerant
+)
+
+// SetTrailingSlashPolicy sets a `PathPolicy` for requests with a trailing slash
+// for this Party and its children.
+// Available values are:
+// * PathPolicyDefault (the default one, follows the `Configuration.DisablePathCorrection`
+// and `Configuration.DisablePathCorrectionRedirection` fields)
+// * PathPolicyRedirect (e.g. "/users/" redirects to "/users")
+// * PathPolicyStrict (e.g. "/users/" fires 404)
+// * PathPolicyTolerant (e.g. "/users/" executes the "/users" route).
+func (api *APIBuilder) SetTrailingSlashPolicy(policy PathPolicy) Party {
+	api.trailingSlashPolicy = policy
+	return api
+}
+
+// SetCasePolicy sets a `PathPolicy` for the letter case of request paths
+// for this Party and its children.
+// Available values are:
+// * PathPolicyDefault (the default one, follows the `Configuration.ForceLowercaseRouting` field)
+// * PathPolicyRedirect (e.g. "/API/Users" redirects to "/api/users")
+// * PathPolicyStrict (e.g. "/API/Users" fires 404 on a registered "/api/users" route,
+// even if the ForceLowercaseRouting is enabled)
+// * PathPolicyTolerant (e.g. "/API/Users" executes the "/api/users" route).
+//
+// The redirect and tolerant policies register the routes of this Party with lowercase paths
+// and, like the `ForceLowercaseRouting` does, the path parameters values are lowercased.
+func (api *APIBuilder) SetCasePolicy(policy PathPolicy) Party {
+	api.casePolicy = policy
+	return api
+}
+
 // Handle registers a route to the server's api.
 // if empty method is passed then handler(s) are being registered to all methods, same as .Any.
 //
@@ -460,6 +516,135 @@ func (api *APIBuilder) HandleDir(requestPath, directory string, opts ...DirOptio
 	return getRoute
 }
 
+// Proxy registers routes for all HTTP methods which forward this Party's requests
+// (the Party's path prefix is stripped) to the "targets".
+// The requests are balanced based on the optional "opts" (round-robin by default),
+// they support active health checks, retries on idempotent methods,
+// request and response headers rewriting and WebSocket passthrough.
+// See `host.ProxyOptions` for more.
+//
+// The active health checks, if enabled, run until the returned load balancer is closed,
+// e.g. on the application's shutdown:
+//
+//     backend1, _ := url.Parse("http://localhost:9091")
+//     backend2, _ := url.Parse("http://localhost:9092")
+//     lb, _ := app.Party("/api").Proxy([]*url.URL{backend1, backend2}, host.ProxyOptions{Balancer: host.LeastConnections})
+//     iris.RegisterOnInterrupt(func() { lb.Close() })
+//
+// Returns the load balancer and the registered routes.
+func (api *APIBuilder) Proxy(targets []*url.URL, opts ...host.ProxyOptions) (*host.LoadBalancer, []*Route) {
+	if len(targets) == 0 {
+		api.errors.Addf("proxy: missing targets for %s", api.relativePath)
+		return nil, nil
+	}
+
+	var options host.ProxyOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
+	lb := host.NewLoadBalancer(targets, options)
+	_, prefix := splitSubdomainAndPath(api.relativePath)
+
+	h := func(ctx context.Context) {
+		lb.ServeHTTP(ctx.ResponseWriter(), stripPathPrefix(ctx.Request(), prefix))
+	}
+
+	description := make([]string, 0, len(targets))
+	for _, target := range targets {
+		description = append(description, target.String())
+	}
+
+	routes := append(api.Any("/", h), api.Any("/{proxy:path}", h)...)
+	for _, r := range routes {
+		r.Describe("proxy: " + strings.Join(description, ", "))
+	}
+
+	return lb, routes
+}
+
+// Mount registers routes for all HTTP methods under the "relativePath" which
+// delegate the requests (the full path prefix is stripped) to the "h" http.Handler.
+//
+// If "h" is an Iris Application then it is built and its own middleware,
+// error handlers, view engines and i18n are preserved, this Party's middleware
+// (and the global one registered before `Mount`) is not executed, so it does not run twice,
+// its routes are registered (and listed through `GetRoutes`) on this Party too,
+// so reverse routing and sitemaps work as expected.
+// As it is built by `Mount`, its routes should be registered before that, any later routes are not served.
+// Its subdomain routes are served but not listed.
+//
+//     users := iris.New()
+//     users.Get("/{id:uint64}", getUser)
+//     app.Mount("/users", users)
+//     app.Mount("/debug", http.DefaultServeMux)
+//
+// Returns the registered routes.
+func (api *APIBuilder) Mount(relativePath string, h http.Handler) []*Route {
+	if h == nil {
+		api.errors.Addf("mount: missing handler for %s", relativePath)
+		return nil
+	}
+
+	p := api.Party(relativePath).(*APIBuilder)
+	_, prefix := splitSubdomainAndPath(p.relativePath)
+
+	delegate := func(ctx context.Context) {
+		h.ServeHTTP(ctx.ResponseWriter(), stripPathPrefix(ctx.Request(), prefix))
+	}
+
+	child, ok := h.(interface {
+		Build() error
+		GetRoutes() []*Route
+	})
+	if !ok {
+		return append(p.Any("/", delegate), p.Any("/{mount:path}", delegate)...)
+	}
+
+	// The child application executes its own middleware.
+	p.middleware = nil
+	p.doneHandlers = nil
+	p.beginGlobalHandlers = nil
+	p.doneGlobalHandlers = nil
+	p.handlerExecutionRules = ExecutionRules{}
+
+	// Register the catch-all routes first, the child routes can override them.
+	routes := append(p.Any("/", delegate), p.Any("/{mount:path}", delegate)...)
+
+	if err := child.Build(); err != nil {
+		api.errors.Add(err)
+		return routes
+	}
+
+	for _, r := range child.GetRoutes() {
+		if r.StatusCode > 0 || r.Subdomain != "" {
+			// error handlers are fired by the child itself.
+			continue
+		}
+
+		route := p.Handle(r.Method, r.tmpl.Src, delegate)
+		if route == nil {
+			continue
+		}
+
+		if r.Name != r.Method+r.Subdomain+r.tmpl.Src { // keep custom names for reverse routing.
+			route.Name = r.Name
+		}
+
+		route.Description = r.Description
+		route.Version = r.Version
+		route.MainHandlerName = r.MainHandlerName
+		route.SourceFileName, route.SourceLineNumber = r.SourceFileName, r.SourceLineNumber
+		route.LastMod, route.ChangeFreq, route.Priority = r.LastMod, r.ChangeFreq, r.Priority
+		route.Meta = append(route.Meta, r.Meta...)
+		route.Tag(r.Tags...)
+
+		routes = append(routes, route)
+	}
+
+	return routes
+}
+
 // CreateRoutes returns a list of Party-based Routes.
 // It does NOT registers the route. Use `Handle, Get...` methods instead.
 // This method can be used for third-parties Iris helpers packages and tools
@@ -554,6 +739,9 @@ func (api *APIBuilder) createRoutes(errorCode int, methods []string, relativePat
 		route.MainHandlerName = mainHandlerName
 		route.MainHandlerIndex = mainHandlerIndex
 
+		route.TrailingSlashPolicy = api.trailingSlashPolicy
+		route.CasePolicy = api.casePolicy
+
 		// The main handler source, could be the same as the register's if anonymous.
 		route.SourceFileName = mainHandlerFileName
 		route.SourceLineNumber = mainHandlerFileNumber
@@ -638,6 +826,8 @@ func (api *APIBuilder) Party(relativePath string, handlers ...context.Handler) P
 		allowMethods:          allowMethods,
 		handlerExecutionRules: api.handlerExecutionRules,
 		routeRegisterRule:     api.routeRegisterRule,
+		trailingSlashPolicy:   api.trailingSlashPolicy,
+		casePolicy:            api.casePolicy,
 		apiBuilderDI: &APIContainer{
 			// attach a new Container with correct dynamic path parameter start index for input arguments
 			// based on the fullpath.
@@ -0,0 +1,76 @@
+package router_test
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+)
+
+func TestPartyMount(t *testing.T) {
+	child := iris.New()
+	child.Use(func(ctx iris.Context) {
+		ctx.Header("X-Child", "true")
+		ctx.Next()
+	})
+	child.OnErrorCode(iris.StatusNotFound, func(ctx iris.Context) {
+		ctx.WriteString("child not found")
+	})
+	child.Get("/", func(ctx iris.Context) {
+		ctx.WriteString("child index " + ctx.Path())
+	})
+	child.Get("/{id:uint64}", func(ctx iris.Context) {
+		ctx.Writef("user %d", ctx.Params().GetUint64Default("id", 0))
+	}).Name = "user"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/vars", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "vars "+r.URL.Path)
+	})
+
+	app := iris.New()
+	parentMiddleware := 0
+	app.Use(func(ctx iris.Context) {
+		parentMiddleware++
+		ctx.Next()
+	})
+	app.Get("/", func(ctx iris.Context) {
+		ctx.WriteString("parent index")
+	})
+	app.Mount("/users", child)
+	app.Party("/admin/{tenant:string}").Mount("/debug", mux)
+	// not served, the child is already built.
+	child.Get("/late", func(ctx iris.Context) {
+		ctx.WriteString("late")
+	})
+
+	e := httptest.New(t, app)
+	e.GET("/").Expect().Status(httptest.StatusOK).Body().Equal("parent index")
+	resp := e.GET("/users").Expect().Status(httptest.StatusOK)
+	resp.Header("X-Child").Equal("true")
+	resp.Body().Equal("child index /")
+	resp = e.GET("/users/42").Expect().Status(httptest.StatusOK)
+	resp.Header("X-Child").Equal("true")
+	resp.Body().Equal("user 42")
+	e.GET("/users/notfound/path").Expect().Status(httptest.StatusNotFound).Body().Equal("child not found")
+	e.GET("/users/late").Expect().Status(httptest.StatusNotFound)
+	e.GET("/admin/acme/debug/vars").Expect().Status(httptest.StatusOK).Body().Equal("vars /vars")
+
+	// the parent's middleware runs on the mounted http.Handlers only, the Iris child runs its own.
+	if expected := 2; parentMiddleware != expected {
+		t.Fatalf("expected the parent's middleware to run %d times but ran %d", expected, parentMiddleware)
+	}
+
+	route := app.GetRoute("user")
+	if route == nil {
+		t.Fatalf("expected the child's named route to be registered on the parent")
+	}
+	if expected, got := "/users/{id:uint64}", route.Tmpl().Src; expected != got {
+		t.Fatalf("expected route path: %q but got: %q", expected, got)
+	}
+	if expected, got := "/users/42", app.GetRouteReadOnly("user").ResolvePath("42"); expected != got {
+		t.Fatalf("expected reverse path: %q but got: %q", expected, got)
+	}
+}
@@ -0,0 +1,117 @@
+package router_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	stdhttptest "net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/host"
+	"github.com/kataras/iris/v12/core/router"
+	"github.com/kataras/iris/v12/httptest"
+)
+
+func TestPartyProxy(t *testing.T) {
+	var backends []*url.URL
+	for _, name := range []string{"one", "two"} {
+		name := name
+		srv := stdhttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, name+" "+r.URL.Path)
+		}))
+		defer srv.Close()
+
+		u, _ := url.Parse(srv.URL)
+		backends = append(backends, u)
+	}
+
+	app := iris.New()
+	// the previous handlers see the original request path after the proxy.
+	var lastPath string
+	app.UseGlobal(func(ctx iris.Context) {
+		ctx.Next()
+		lastPath = ctx.Request().URL.Path
+	})
+	lb, _ := app.Party("/api").SetCasePolicy(router.PathPolicyTolerant).Proxy(backends, host.ProxyOptions{
+		HealthCheck: host.HealthCheckOptions{Interval: time.Hour},
+	})
+	defer lb.Close()
+	app.Party("/users/{id:uint64}").Proxy(backends[:1])
+	app.Get("/", func(ctx iris.Context) { ctx.WriteString("local") })
+
+	e := httptest.New(t, app)
+	e.GET("/").Expect().Status(httptest.StatusOK).Body().Equal("local")
+	e.GET("/api/users").Expect().Status(httptest.StatusOK).Body().Equal("one /users")
+	if expected := "/api/users"; lastPath != expected {
+		t.Fatalf("expected the request path to be kept as: %q but got: %q", expected, lastPath)
+	}
+	e.POST("/API/users").Expect().Status(httptest.StatusOK).Body().Equal("two /users")
+	e.GET("/api").Expect().Status(httptest.StatusOK).Body().Equal("one /")
+	e.GET("/users/42/friends").Expect().Status(httptest.StatusOK).Body().Equal("one /friends")
+}
+
+func TestPartyProxyUpgrade(t *testing.T) {
+	// A raw "echo" upgrade backend.
+	backend := stdhttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "echo" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		rw.Flush()
+
+		line, _ := rw.ReadString('\n')
+		rw.WriteString(line)
+		rw.Flush()
+	}))
+	defer backend.Close()
+
+	target, _ := url.Parse(backend.URL)
+	app := iris.New()
+	app.Party("/ws").Proxy([]*url.URL{target}, host.ProxyOptions{})
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := stdhttptest.NewServer(app)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	io.WriteString(conn, "GET /ws/echo HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := http.StatusSwitchingProtocols, resp.StatusCode; expected != got {
+		t.Fatalf("expected status code: %d but got: %d", expected, got)
+	}
+
+	io.WriteString(conn, "ping\n")
+	line, err := br.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := "ping\n", line; expected != got {
+		t.Fatalf("expected echo: %q but got: %q", expected, got)
+	}
+}
@@ -50,6 +50,10 @@ type routerHandler struct {
 
 	hosts      bool // true if at least one route contains a Subdomain.
 	errorHosts bool // true if error handlers are registered to at least one Subdomain.
+
+	trailingSlashPolicies bool // true if at least one route has a non-default trailing slash policy.
+	casePolicies          bool // true if at least one route has a case-insensitive policy.
+	caseSensitiveRoutes   bool // true if at least one route has a strict case policy while the ForceLowercaseRouting is enabled.
 }
 
 var _ RequestHandler = (*routerHandler)(nil)
@@ -122,6 +126,7 @@ type RoutesProvider interface { // api builder
 
 func (h *routerHandler) Build(provider RoutesProvider) error {
 	h.trees = h.trees[0:0] // reset, inneed when rebuilding.
+	h.trailingSlashPolicies, h.casePolicies, h.caseSensitiveRoutes = false, false, false
 	rp := errgroup.New("Routes Builder")
 	registeredRoutes := provider.GetRoutes()
 
@@ -169,9 +174,21 @@ func (h *routerHandler) Build(provider RoutesProvider) error {
 	})
 
 	for _, r := range registeredRoutes {
-		if h.config != nil && h.config.GetForceLowercaseRouting() {
+		if r.TrailingSlashPolicy != PathPolicyDefault {
+			h.trailingSlashPolicies = true
+		}
+
+		switch h.casePolicy(r.CasePolicy) {
+		case PathPolicyRedirect, PathPolicyTolerant:
 			// only in that state, keep everything else as end-developer registered.
 			r.Path = strings.ToLower(r.Path)
+			if r.CasePolicy != PathPolicyDefault {
+				h.casePolicies = true
+			}
+		default:
+			if h.forceLowercaseRouting() {
+				h.caseSensitiveRoutes = true
+			}
 		}
 
 		if r.Subdomain != "" {
@@ -361,32 +378,23 @@ func (h *routerHandler) HandleRequest(ctx context.Context) {
 	path := ctx.Path()
 	config := h.config // ctx.Application().GetConfigurationReadOnly()
 
-	if !config.GetDisablePathCorrection() {
-		if len(path) > 1 && strings.HasSuffix(path, "/") {
+	if len(path) > 1 && path[len(path)-1] == '/' {
+		policy := h.trailingSlashPolicy(ctx, method, path)
+		switch policy {
+		case PathPolicyRedirect:
 			// Remove trailing slash and client-permanent rule for redirection,
 			// if confgiuration allows that and path has an extra slash.
 
 			// update the new path and redirect.
 			u := ctx.Request().URL
 			// use Trim to ensure there is no open redirect due to two leading slashes
+			u.Path = "/" + strings.Trim(path, "/")
+			h.redirect(ctx, method, u.String())
+			return
+		case PathPolicyTolerant:
+			// continue with the modified path without the last "/".
 			path = "/" + strings.Trim(path, "/")
-			u.Path = path
-			if !config.GetDisablePathCorrectionRedirection() {
-				// do redirect, else continue with the modified path without the last "/".
-				url := u.String()
-
-				// Fixes https://github.com/kataras/iris/issues/921
-				// This is caused for security reasons, imagine a payment shop,
-				// you can't just permantly redirect a POST request, so just 307 (RFC 7231, 6.4.7).
-				if method == http.MethodPost || method == http.MethodPut {
-					ctx.Redirect(url, http.StatusTemporaryRedirect)
-					return
-				}
-
-				ctx.Redirect(url, http.StatusMovedPermanently)
-				return
-			}
-
+			ctx.Request().URL.Path = path
 		}
 	}
 
@@ -400,7 +408,11 @@ func (h *routerHandler) HandleRequest(ctx context.Context) {
 			continue
 		}
 
-		n := t.search(path, ctx.Params())
+		n, redirected := h.search(ctx, t, method, path)
+		if redirected {
+			return
+		}
+
 		if n != nil {
 			ctx.SetCurrentRoute(n.Route)
 			ctx.Do(n.Handlers)
@@ -439,6 +451,135 @@ func (h *routerHandler) HandleRequest(ctx context.Context) {
 	ctx.StatusCode(http.StatusNotFound)
 }
 
+// search returns the route's node of the "path" on the tree "t", according to the case policies of the routes.
+// It reports whether the client was redirected to the lowercase path instead.
+func (h *routerHandler) search(ctx context.Context, t *trie, method, path string) (n *trieNode, redirected bool) {
+	forceLowercase := h.forceLowercaseRouting()
+	upper := hasUpper(path)
+
+	// with the ForceLowercaseRouting a path with uppercase letters
+	// matches as it is the case-sensitive routes only.
+	if !forceLowercase || !upper || h.caseSensitiveRoutes {
+		n = t.search(path, ctx.Params())
+		if n != nil && forceLowercase && upper && h.casePolicy(routePolicy(n.Route, true)) != PathPolicyStrict {
+			n = nil
+			ctx.Params().Reset()
+		}
+	}
+
+	if n == nil && upper && (forceLowercase || h.casePolicies) {
+		lowerPath := strings.ToLower(path)
+		if n = t.search(lowerPath, ctx.Params()); n != nil {
+			switch h.casePolicy(routePolicy(n.Route, true)) {
+			case PathPolicyRedirect:
+				u := ctx.Request().URL
+				u.Path = lowerPath
+				h.redirect(ctx, method, u.String())
+				return nil, true
+			case PathPolicyTolerant:
+				ctx.Request().URL.Path = lowerPath
+			default:
+				n = nil
+				ctx.Params().Reset()
+			}
+		}
+	}
+
+	return
+}
+
+func (h *routerHandler) forceLowercaseRouting() bool {
+	return h.config != nil && h.config.GetForceLowercaseRouting()
+}
+
+// casePolicy resolves the default case policy of a route by the configuration:
+// the ForceLowercaseRouting tolerates any letter case, otherwise the case is strict.
+func (h *routerHandler) casePolicy(policy PathPolicy) PathPolicy {
+	if policy != PathPolicyDefault {
+		return policy
+	}
+
+	if h.forceLowercaseRouting() {
+		return PathPolicyTolerant
+	}
+
+	return PathPolicyStrict
+}
+
+// trailingSlashPolicy returns the policy of the route which matches the "path" without its trailing slash,
+// if that route does not set a policy then the policy is resolved by the configuration.
+func (h *routerHandler) trailingSlashPolicy(ctx context.Context, method, path string) PathPolicy {
+	if h.trailingSlashPolicies {
+		trimmedPath := strings.TrimRight(path, "/")
+		for i := range h.trees {
+			t := h.trees[i]
+			if method != t.method || (h.hosts && !h.canHandleSubdomain(ctx, t.subdomain)) {
+				continue
+			}
+
+			n := t.search(trimmedPath, ctx.Params())
+			if n == nil && (h.casePolicies || h.forceLowercaseRouting()) && hasUpper(trimmedPath) {
+				n = t.search(strings.ToLower(trimmedPath), ctx.Params())
+			}
+			ctx.Params().Reset() // they are filled again on the route's search.
+
+			if n != nil {
+				if policy := routePolicy(n.Route, false); policy != PathPolicyDefault {
+					return policy
+				}
+			}
+
+			break
+		}
+	}
+
+	config := h.config
+	if config.GetDisablePathCorrection() {
+		return PathPolicyStrict
+	}
+
+	if config.GetDisablePathCorrectionRedirection() {
+		return PathPolicyTolerant
+	}
+
+	return PathPolicyRedirect
+}
+
+// routePolicy returns the case (if "casePolicy" is true) or the trailing slash policy of a route.
+func routePolicy(route context.RouteReadOnly, casePolicy bool) PathPolicy {
+	if rd, ok := route.(routeReadOnlyWrapper); ok {
+		if casePolicy {
+			return rd.Route.CasePolicy
+		}
+
+		return rd.Route.TrailingSlashPolicy
+	}
+
+	return PathPolicyDefault
+}
+
+func (h *routerHandler) redirect(ctx context.Context, method, url string) {
+	// Fixes https://github.com/kataras/iris/issues/921
+	// This is caused for security reasons, imagine a payment shop,
+	// you can't just permantly redirect a POST request, so just 307 (RFC 7231, 6.4.7).
+	if method == http.MethodPost || method == http.MethodPut {
+		ctx.Redirect(url, http.StatusTemporaryRedirect)
+		return
+	}
+
+	ctx.Redirect(url, http.StatusMovedPermanently)
+}
+
+func hasUpper(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; 'A' <= c && c <= 'Z' {
+			return true
+		}
+	}
+
+	return false
+}
+
 func statusCodeSuccessful(statusCode int) bool {
 	return !context.StatusCodeNotSuccessful(statusCode)
 }
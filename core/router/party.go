@@ -1,8 +1,12 @@
 package router
 
 import (
+	"net/http"
+	"net/url"
+
 	"github.com/kataras/iris/v12/context"
 	"github.com/kataras/iris/v12/core/errgroup"
+	"github.com/kataras/iris/v12/core/host"
 	"github.com/kataras/iris/v12/macro"
 )
 
@@ -122,6 +126,27 @@ type Party interface {
 	// * RouteError
 	// * RouteOverlap.
 	SetRegisterRule(rule RouteRegisterRule) Party
+	// SetTrailingSlashPolicy sets a `PathPolicy` for requests with a trailing slash
+	// for this Party and its children.
+	// Available values are:
+	// * PathPolicyDefault (the default one, follows the `Configuration.DisablePathCorrection`
+	// and `Configuration.DisablePathCorrectionRedirection` fields)
+	// * PathPolicyRedirect (e.g. "/users/" redirects to "/users")
+	// * PathPolicyStrict (e.g. "/users/" fires 404)
+	// * PathPolicyTolerant (e.g. "/users/" executes the "/users" route).
+	SetTrailingSlashPolicy(policy PathPolicy) Party
+	// SetCasePolicy sets a `PathPolicy` for the letter case of request paths
+	// for this Party and its children.
+	// Available values are:
+	// * PathPolicyDefault (the default one, follows the `Configuration.ForceLowercaseRouting` field)
+	// * PathPolicyRedirect (e.g. "/API/Users" redirects to "/api/users")
+	// * PathPolicyStrict (e.g. "/API/Users" fires 404 on a registered "/api/users" route,
+	// even if the ForceLowercaseRouting is enabled)
+	// * PathPolicyTolerant (e.g. "/API/Users" executes the "/api/users" route).
+	//
+	// The redirect and tolerant policies register the routes of this Party with lowercase paths
+	// and, like the `ForceLowercaseRouting` does, the path parameters values are lowercased.
+	SetCasePolicy(policy PathPolicy) Party
 
 	// Handle registers a route to the server's router.
 	// if empty method is passed then handler(s) are being registered to all methods, same as .Any.
@@ -160,6 +185,39 @@ type Party interface {
 	//
 	// Examples can be found at: https://github.com/kataras/iris/tree/master/_examples/file-server
 	HandleDir(requestPath, directory string, opts ...DirOptions) *Route
+	// Proxy registers routes for all HTTP methods which forward this Party's requests
+	// (the Party's path prefix is stripped) to the "targets".
+	// The requests are balanced based on the optional "opts" (round-robin by default),
+	// they support active health checks, retries on idempotent methods,
+	// request and response headers rewriting and WebSocket passthrough.
+	// See `host.ProxyOptions` for more.
+	//
+	// The active health checks, if enabled, run until the returned load balancer is closed,
+	// e.g. on the application's shutdown:
+	//
+	//     backend1, _ := url.Parse("http://localhost:9091")
+	//     backend2, _ := url.Parse("http://localhost:9092")
+	//     lb, _ := app.Party("/api").Proxy([]*url.URL{backend1, backend2}, host.ProxyOptions{Balancer: host.LeastConnections})
+	//     iris.RegisterOnInterrupt(func() { lb.Close() })
+	//
+	// Returns the load balancer and the registered routes.
+	Proxy(targets []*url.URL, opts ...host.ProxyOptions) (*host.LoadBalancer, []*Route)
+	// Mount registers routes for all HTTP methods under the "relativePath" which
+	// delegate the requests (the full path prefix is stripped) to the "h" http.Handler.
+	//
+	// If "h" is an Iris Application then it is built and its own middleware,
+	// error handlers, view engines and i18n are preserved, this Party's middleware is not executed,
+	// its routes are registered (and listed through `GetRoutes`) on this Party too,
+	// so reverse routing and sitemaps work as expected.
+	// As it is built by `Mount`, its routes should be registered before that, any later routes are not served.
+	//
+	//     users := iris.New()
+	//     users.Get("/{id:uint64}", getUser)
+	//     app.Mount("/users", users)
+	//     app.Mount("/debug", http.DefaultServeMux)
+	//
+	// Returns the registered routes.
+	Mount(relativePath string, h http.Handler) []*Route
 
 	// None registers an "offline" route
 	// see context.ExecRoute(routeName) and
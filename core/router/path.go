@@ -271,6 +271,52 @@ func staticPath(src string) string {
 	return src[:bidx-1] // (/static/{...} -> /static)
 }
 
+// stripPathPrefix returns a shallow copy of the "r" request with the "prefix" trimmed from its URL path,
+// the original request, e.g. as seen by the next handlers and the access log, is not modified.
+func stripPathPrefix(r *http.Request, prefix string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	u := *r.URL
+	u.Path = trimPathPrefix(prefix, u.Path)
+	u.RawPath = ""
+	r2.URL = &u
+	return r2
+}
+
+// trimPathPrefix removes the "prefix" (which may contain dynamic path parameters)
+// from the request "path". A dynamic prefix removes the same number of path segments,
+// i.e "/users/{id}" trims the "/users/42" part of the "/users/42/friends".
+// It always returns a path which starts with a slash.
+func trimPathPrefix(prefix, path string) string {
+	if prefix == "" || prefix == "/" {
+		return path
+	}
+
+	if !strings.Contains(prefix, "{") {
+		// case-insensitive, the route may be matched through a case policy
+		// or the Configuration.ForceLowercaseRouting.
+		if len(path) >= len(prefix) && strings.EqualFold(path[:len(prefix)], prefix) {
+			path = path[len(prefix):]
+		}
+	} else {
+		for n := strings.Count(prefix, "/"); n > 0 && path != ""; n-- {
+			idx := strings.IndexByte(path[1:], '/')
+			if idx == -1 {
+				path = ""
+				break
+			}
+
+			path = path[idx+1:]
+		}
+	}
+
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+
+	return path
+}
+
 // RoutePathReverserOption option signature for the RoutePathReverser.
 type RoutePathReverserOption func(*RoutePathReverser)
 
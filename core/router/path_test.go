@@ -134,3 +134,25 @@ func TestSplitSubdomainAndPath(t *testing.T) {
 		}
 	}
 }
+
+func TestTrimPathPrefix(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		path     string
+		expected string
+	}{
+		{"/", "/users", "/users"},
+		{"/api", "/api", "/"},
+		{"/api", "/api/", "/"},
+		{"/api", "/api/users/42", "/users/42"},
+		{"/users/{id}", "/users/42", "/"},
+		{"/users/{id}", "/users/42/friends", "/friends"},
+		{"/users/{id:uint64}/friends", "/users/42/friends/1", "/1"},
+	}
+
+	for i, tt := range tests {
+		if got := trimPathPrefix(tt.prefix, tt.path); got != tt.expected {
+			t.Fatalf("[%d] expected trimmed path of prefix: '%s' and path: '%s' to be: '%s' but got: '%s'", i, tt.prefix, tt.path, tt.expected, got)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/core/memstore"
 	"github.com/kataras/iris/v12/macro"
 	"github.com/kataras/iris/v12/macro/handler"
 
@@ -21,6 +22,7 @@ import (
 type Route struct {
 	Name        string         `json:"name"`        // "userRoute"
 	Description string         `json:"description"` // "lists a user"
+	Version     string         `json:"version"`     // ">= 1, < 2 | 2.0.0" (filled by the versioning package)
 	Method      string         `json:"method"`      // "GET"
 	StatusCode  int            `json:"statusCode"`  // 404 (only for HTTP error handlers).
 	methodBckp  string         // if Method changed to something else (which is possible at runtime as well, via RefreshRouter) then this field will be filled with the old one.
@@ -64,6 +66,17 @@ type Route struct {
 	ChangeFreq string    `json:"changeFreq,omitempty"`
 	Priority   float32   `json:"priority,omitempty"`
 
+	// Meta keeps custom, typed, data of this route, see `SetMeta`.
+	// Can be read at serve-time through `Context.GetCurrentRoute().Meta()`.
+	Meta memstore.Store `json:"meta,omitempty"`
+	// Tags are custom labels of this route, see `Tag` and `HasTag`.
+	Tags []string `json:"tags,omitempty"`
+
+	// TrailingSlashPolicy and CasePolicy are inherited by the Party,
+	// see `Party.SetTrailingSlashPolicy` and `Party.SetCasePolicy`.
+	TrailingSlashPolicy PathPolicy `json:"trailingSlashPolicy,omitempty"`
+	CasePolicy          PathPolicy `json:"casePolicy,omitempty"`
+
 	// ReadOnly is the read-only structure of the Route.
 	ReadOnly context.RouteReadOnly
 
@@ -168,6 +181,52 @@ func (r *Route) Describe(description string) *Route {
 	return r
 }
 
+// SetVersion sets the route's version (or version constraint),
+// it is filled automatically by the `versioning` package.
+// Returns the `Route` itself.
+func (r *Route) SetVersion(version string) *Route {
+	r.Version = version
+	return r
+}
+
+// SetMeta sets a custom "value" for the metadata "key" of this route.
+// Middlewares (e.g. auth, rate limiting, metrics) can read it at serve-time through:
+// ctx.GetCurrentRoute().Meta().Get(key).
+//
+// Usage:
+// app.Get("/users", listUsers).SetMeta("auth.roles", []string{"admin"})
+//
+// Returns the `Route` itself.
+func (r *Route) SetMeta(key string, value interface{}) *Route {
+	r.Meta.Set(key, value)
+	return r
+}
+
+// Tag adds one or more tags to this route, duplicates are not added.
+// See `HasTag` too.
+//
+// Returns the `Route` itself.
+func (r *Route) Tag(tags ...string) *Route {
+	for _, tag := range tags {
+		if !r.HasTag(tag) {
+			r.Tags = append(r.Tags, tag)
+		}
+	}
+
+	return r
+}
+
+// HasTag reports whether this route is tagged with the given "tag".
+func (r *Route) HasTag(tag string) bool {
+	for _, t := range r.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
 // SetSourceLine sets the route's source caller, useful for debugging.
 // Returns the `Route` itself.
 func (r *Route) SetSourceLine(fileName string, lineNumber int) *Route {
@@ -525,6 +584,14 @@ func (rd routeReadOnlyWrapper) StaticSites() []context.StaticSite {
 	return rd.Route.StaticSites
 }
 
+func (rd routeReadOnlyWrapper) Meta() *memstore.Store {
+	return &rd.Route.Meta
+}
+
+func (rd routeReadOnlyWrapper) Tags() []string {
+	return rd.Route.Tags
+}
+
 func (rd routeReadOnlyWrapper) GetLastMod() time.Time {
 	return rd.Route.LastMod
 }
@@ -0,0 +1,211 @@
+package router
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+type (
+	// RouteInfo is the machine-readable form of a `Route`.
+	// It can be serialized to JSON, YAML and etc.
+	// See `Route.Info`, `APIBuilder.GetRoutesInfo` and `RoutesHandler` too.
+	RouteInfo struct {
+		Name        string             `json:"name" yaml:"Name"`
+		Method      string             `json:"method" yaml:"Method"`
+		StatusCode  int                `json:"statusCode,omitempty" yaml:"StatusCode,omitempty"`
+		Subdomain   string             `json:"subdomain,omitempty" yaml:"Subdomain,omitempty"`
+		Path        string             `json:"path" yaml:"Path"`
+		Params      []RouteParamInfo   `json:"params,omitempty" yaml:"Params,omitempty"`
+		Handlers    []RouteHandlerInfo `json:"handlers" yaml:"Handlers"`
+		Description string             `json:"description,omitempty" yaml:"Description,omitempty"`
+		Status      string             `json:"status" yaml:"Status"` // "online" or "offline".
+		Version     string             `json:"version,omitempty" yaml:"Version,omitempty"`
+		Tags        []string           `json:"tags,omitempty" yaml:"Tags,omitempty"`
+		// Meta is the route's custom metadata, see `Route.SetMeta`.
+		Meta map[string]interface{} `json:"meta,omitempty" yaml:"Meta,omitempty"`
+		// where the route registered.
+		RegisterFile string `json:"registerFile" yaml:"RegisterFile"`
+		RegisterLine int    `json:"registerLine" yaml:"RegisterLine"`
+	}
+
+	// RouteParamInfo describes a dynamic path parameter of a `RouteInfo`,
+	// i.e {id:uint64 min(1)} is Name: "id", Type: "uint64", Funcs: ["min"].
+	RouteParamInfo struct {
+		Name    string   `json:"name" yaml:"Name"`
+		Type    string   `json:"type" yaml:"Type"`
+		Funcs   []string `json:"funcs,omitempty" yaml:"Funcs,omitempty"`
+		ErrCode int      `json:"errCode,omitempty" yaml:"ErrCode,omitempty"`
+	}
+
+	// RouteHandlerInfo describes a handler of the route's handlers chain.
+	RouteHandlerInfo struct {
+		Name string `json:"name" yaml:"Name"`
+		File string `json:"file" yaml:"File"`
+		Line int    `json:"line" yaml:"Line"`
+		Main bool   `json:"main,omitempty" yaml:"Main,omitempty"`
+	}
+)
+
+// Info returns the machine-readable information of this Route.
+// The handlers chain is complete after the `Build` state.
+func (r *Route) Info() RouteInfo {
+	status := "online"
+	if !r.IsOnline() {
+		status = "offline"
+	}
+
+	path := r.tmpl.Src
+	if path == "" {
+		path = "/"
+	}
+
+	info := RouteInfo{
+		Name:         r.Name,
+		Method:       r.Method,
+		StatusCode:   r.StatusCode,
+		Subdomain:    r.Subdomain,
+		Path:         path,
+		Description:  r.Description,
+		Status:       status,
+		Version:      r.Version,
+		Tags:         r.Tags,
+		RegisterFile: r.RegisterFileName,
+		RegisterLine: r.RegisterLineNumber,
+	}
+
+	if r.Meta.Len() > 0 {
+		info.Meta = make(map[string]interface{}, r.Meta.Len())
+		r.Meta.Visit(func(key string, value interface{}) {
+			info.Meta[key] = value
+		})
+	}
+
+	for _, p := range r.tmpl.Params {
+		paramInfo := RouteParamInfo{
+			Name:  p.Name,
+			Funcs: p.FuncNames,
+		}
+
+		if p.Type != nil {
+			paramInfo.Type = p.Type.Indent()
+		}
+
+		if p.ErrCode != http.StatusNotFound {
+			paramInfo.ErrCode = p.ErrCode
+		}
+
+		info.Params = append(info.Params, paramInfo)
+	}
+
+	mainFound := false
+	for _, h := range r.Handlers {
+		name := context.HandlerName(h)
+		if context.IgnoreHandlerName(name) {
+			continue
+		}
+
+		file, line := context.HandlerFileLineRel(h)
+		handlerInfo := RouteHandlerInfo{
+			Name: name,
+			File: file,
+			Line: line,
+		}
+
+		if !mainFound && r.MainHandlerName != "" && file == r.SourceFileName && line == r.SourceLineNumber {
+			// Main handler info can be programmatically
+			// changed to be more specific, respect these changes.
+			handlerInfo.Name = r.MainHandlerName
+			handlerInfo.Main = true
+			mainFound = true
+		}
+
+		info.Handlers = append(info.Handlers, handlerInfo)
+	}
+
+	return info
+}
+
+// GetRoutesInfo returns the machine-readable information of all registered routes.
+// See `RoutesHandler` too.
+func (api *APIBuilder) GetRoutesInfo() []RouteInfo {
+	return getRoutesInfo(api.GetRoutes())
+}
+
+func getRoutesInfo(routes []*Route) []RouteInfo {
+	infos := make([]RouteInfo, 0, len(routes))
+	for _, r := range routes {
+		infos = append(infos, r.Info())
+	}
+
+	return infos
+}
+
+// RoutesHandler returns a Handler which sends the application's registered routes information
+// to the client. The response is negotiated (see `Context.Negotiate`), it sends JSON by default,
+// YAML on "Accept: application/x-yaml" and an HTML table for browsers.
+//
+// Usage:
+// app.Get("/_routes", router.RoutesHandler())
+func RoutesHandler() context.Handler {
+	return func(ctx context.Context) {
+		provider, ok := ctx.Application().(RoutesProvider)
+		if !ok {
+			ctx.StopWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		infos := getRoutesInfo(provider.GetRoutes())
+		ctx.Negotiation().JSON(infos).YAML(infos).HTML(routesInfoHTML(infos))
+		ctx.Negotiate(nil)
+	}
+}
+
+func routesInfoHTML(infos []RouteInfo) string {
+	b := new(strings.Builder)
+	b.WriteString("<!DOCTYPE html><html><head><meta charset=\"utf-8\"><title>Routes</title></head><body>")
+	fmt.Fprintf(b, "<h1>Routes (%d)</h1>", len(infos))
+	b.WriteString("<table border=\"1\" cellpadding=\"4\" cellspacing=\"0\">")
+	b.WriteString("<tr><th>Method</th><th>Subdomain</th><th>Path</th><th>Params</th><th>Handlers</th><th>Description</th><th>Status</th><th>Version</th></tr>")
+
+	for _, info := range infos {
+		method := info.Method
+		if method == "" {
+			method = fmt.Sprintf("%d", info.StatusCode)
+		}
+
+		params := make([]string, 0, len(info.Params))
+		for _, p := range info.Params {
+			param := p.Name + ":" + p.Type
+			if len(p.Funcs) > 0 {
+				param += " " + strings.Join(p.Funcs, " ")
+			}
+			params = append(params, html.EscapeString(param))
+		}
+
+		handlers := make([]string, 0, len(info.Handlers))
+		for _, h := range info.Handlers {
+			handler := html.EscapeString(fmt.Sprintf("%s (%s:%d)", h.Name, h.File, h.Line))
+			if h.Main {
+				handler = "<b>" + handler + "</b>"
+			}
+			handlers = append(handlers, handler)
+		}
+
+		fmt.Fprintf(b, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>",
+			html.EscapeString(method),
+			html.EscapeString(info.Subdomain),
+			html.EscapeString(info.Path),
+			strings.Join(params, "<br>"),
+			strings.Join(handlers, "<br>"),
+			html.EscapeString(info.Description),
+			info.Status,
+			html.EscapeString(info.Version))
+	}
+
+	b.WriteString("</table></body></html>")
+	return b.String()
+}
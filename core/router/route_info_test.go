@@ -0,0 +1,63 @@
+package router_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/router"
+	"github.com/kataras/iris/v12/httptest"
+)
+
+func TestRoutesHandler(t *testing.T) {
+	app := iris.New()
+	app.Get("/_routes", router.RoutesHandler())
+	app.Get("/users/{id:uint64 min(1)}", func(ctx iris.Context) {}).Describe("get a user")
+	app.None("/offline", func(ctx iris.Context) {})
+
+	e := httptest.New(t, app)
+
+	body := e.GET("/_routes").WithHeader("Accept", "application/json").Expect().
+		Status(httptest.StatusOK).ContentType("application/json").Body().Raw()
+
+	var routes []router.RouteInfo
+	if err := json.Unmarshal([]byte(body), &routes); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := 3, len(routes); expected != got {
+		t.Fatalf("expected %d routes but got %d", expected, got)
+	}
+
+	for _, r := range routes {
+		switch r.Path {
+		case "/users/{id:uint64 min(1)}":
+			if expected, got := "get a user", r.Description; expected != got {
+				t.Fatalf("expected description: %q but got: %q", expected, got)
+			}
+
+			if expected, got := "online", r.Status; expected != got {
+				t.Fatalf("expected status: %q but got: %q", expected, got)
+			}
+
+			expectedParams := []router.RouteParamInfo{{Name: "id", Type: "uint64", Funcs: []string{"min"}}}
+			if !reflect.DeepEqual(expectedParams, r.Params) {
+				t.Fatalf("expected params: %#+v but got: %#+v", expectedParams, r.Params)
+			}
+
+			if main := r.Handlers[len(r.Handlers)-1]; !main.Main || main.Line == 0 {
+				t.Fatalf("expected last handler to be the main one with file:line information but got: %#+v", main)
+			}
+		case "/offline":
+			if expected, got := "offline", r.Status; expected != got {
+				t.Fatalf("expected status: %q but got: %q", expected, got)
+			}
+		}
+	}
+
+	e.GET("/_routes").WithHeader("Accept", "application/x-yaml").Expect().
+		Status(httptest.StatusOK).ContentType("application/x-yaml").Body().Contains("Path: /users/{id:uint64 min(1)}")
+	e.GET("/_routes").WithHeader("Accept", "text/html").Expect().
+		Status(httptest.StatusOK).ContentType("text/html").Body().Contains("<td>get a user</td>")
+}
@@ -2,6 +2,7 @@ package router_test
 
 import (
 	"net/http"
+	stdhttptest "net/http/httptest"
 	"strings"
 	"testing"
 
@@ -72,3 +73,104 @@ func TestLowercaseRouting(t *testing.T) {
 		e.GET(strings.ToUpper(tt)).Expect().Status(httptest.StatusOK).Body().Equal(s)
 	}
 }
+
+func TestRouteMetaAndTags(t *testing.T) {
+	app := iris.New()
+	app.Use(func(ctx iris.Context) {
+		route := ctx.GetCurrentRoute()
+		if roles, ok := route.Meta().Get("auth.roles").([]string); ok {
+			if !route.HasTag("billing") || len(route.Tags()) != 1 {
+				ctx.StopWithText(iris.StatusInternalServerError, "expected a single billing tag")
+				return
+			}
+
+			ctx.Values().Set("roles", strings.Join(roles, ","))
+		}
+
+		ctx.Next()
+	})
+
+	h := func(ctx iris.Context) { ctx.WriteString(ctx.Values().GetString("roles")) }
+	app.Get("/invoices", h).SetMeta("auth.roles", []string{"admin", "accountant"}).Tag("billing", "billing")
+	app.Get("/public", h)
+
+	e := httptest.New(t, app)
+	e.GET("/invoices").Expect().Status(httptest.StatusOK).Body().Equal("admin,accountant")
+	e.GET("/public").Expect().Status(httptest.StatusOK).Body().Empty()
+}
+
+func TestPathPolicies(t *testing.T) {
+	app := iris.New()
+	h := func(ctx iris.Context) { ctx.WriteString(ctx.Path()) }
+
+	app.Get("/users", h)
+
+	legacy := app.Party("/API")
+	legacy.SetCasePolicy(iris.PathPolicyTolerant)
+	legacy.SetTrailingSlashPolicy(iris.PathPolicyTolerant)
+	legacy.Get("/Users/{name}", func(ctx iris.Context) { ctx.WriteString(ctx.Params().Get("name")) })
+
+	canonical := app.Party("/docs")
+	canonical.SetCasePolicy(iris.PathPolicyRedirect)
+	canonical.Get("/intro", h)
+
+	strict := app.Party("/strict")
+	strict.SetTrailingSlashPolicy(iris.PathPolicyStrict)
+	strict.Get("/", h)
+	strict.Get("/item", h)
+
+	e := httptest.New(t, app, httptest.LogLevel("disable"))
+	// defaults: strict case, redirect trailing slash.
+	e.GET("/users").Expect().Status(httptest.StatusOK).Body().Equal("/users")
+	e.GET("/Users").Expect().Status(httptest.StatusNotFound)
+	e.GET("/users/").Expect().Status(httptest.StatusOK).Body().Equal("/users")
+	// case-insensitive, tolerant trailing slash.
+	e.GET("/API/Users/john").Expect().Status(httptest.StatusOK).Body().Equal("john")
+	e.GET("/api/users/john").Expect().Status(httptest.StatusOK).Body().Equal("john")
+	e.GET("/Api/USERS/john/").Expect().Status(httptest.StatusOK).Body().Equal("john")
+	// case redirect.
+	e.GET("/Docs/Intro").Expect().Status(httptest.StatusOK).Body().Equal("/docs/intro")
+	w := stdhttptest.NewRecorder()
+	app.ServeHTTP(w, stdhttptest.NewRequest(http.MethodGet, "/Docs/Intro", nil))
+	if expected, got := http.StatusMovedPermanently, w.Code; expected != got {
+		t.Fatalf("expected status code: %d but got: %d", expected, got)
+	}
+	if expected, got := "/docs/intro", w.Header().Get("Location"); expected != got {
+		t.Fatalf("expected location: %q but got: %q", expected, got)
+	}
+	// strict trailing slash.
+	e.GET("/strict/item").Expect().Status(httptest.StatusOK).Body().Equal("/strict/item")
+	e.GET("/strict/item/").Expect().Status(httptest.StatusNotFound)
+	e.GET("/strict/Item").Expect().Status(httptest.StatusNotFound)
+}
+
+func TestPathPoliciesLowercaseRouting(t *testing.T) {
+	app := iris.New()
+	app.Configure(iris.WithLowercaseRouting)
+	h := func(ctx iris.Context) { ctx.WriteString(ctx.Path()) }
+
+	app.Get("/Users/{name}", func(ctx iris.Context) { ctx.WriteString(ctx.Params().Get("name")) })
+
+	legacy := app.Party("/Legacy")
+	legacy.SetCasePolicy(iris.PathPolicyStrict)
+	legacy.Get("/Item", h)
+
+	canonical := app.Party("/docs")
+	canonical.SetCasePolicy(iris.PathPolicyRedirect)
+	canonical.Get("/intro", h)
+
+	e := httptest.New(t, app, httptest.LogLevel("disable"))
+	// the global lowercase routing.
+	e.GET("/users/john").Expect().Status(httptest.StatusOK).Body().Equal("john")
+	e.GET("/USERS/John").Expect().Status(httptest.StatusOK).Body().Equal("john")
+	// a case-sensitive party.
+	e.GET("/Legacy/Item").Expect().Status(httptest.StatusOK).Body().Equal("/Legacy/Item")
+	e.GET("/legacy/item").Expect().Status(httptest.StatusNotFound)
+	e.GET("/LEGACY/ITEM").Expect().Status(httptest.StatusNotFound)
+	// a redirect party.
+	w := stdhttptest.NewRecorder()
+	app.ServeHTTP(w, stdhttptest.NewRequest(http.MethodGet, "/Docs/Intro", nil))
+	if expected, got := http.StatusMovedPermanently, w.Code; expected != got {
+		t.Fatalf("expected status code: %d but got: %d", expected, got)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kataras/iris/v12/cache"
@@ -29,6 +30,8 @@ import (
 	"github.com/kataras/iris/v12/view"
 
 	"github.com/kataras/golog"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"gopkg.in/yaml.v3"
 )
 
@@ -184,6 +187,14 @@ type Application struct {
 	// Hosts field is available after `Run` or `NewHost`.
 	Hosts             []*host.Supervisor
 	hostConfigurators []host.Configurator
+	// registers the host configurators of the settings once, see `prepare`.
+	configureHostsOnce sync.Once
+	// the applications which run along with this one, see `Apps`.
+	apps []*Application
+	// registers the host configurator of `Apps` once.
+	configureAppsOnce sync.Once
+	// non-zero when a server of the current `Apps` run failed.
+	appsFailed uint32
 }
 
 // New creates and returns a fresh empty iris *Application instance.
@@ -632,6 +643,19 @@ const (
 	RouteOverlap = router.RouteOverlap
 )
 
+// Constants for input argument at `router.PathPolicy`.
+// See `Party#SetTrailingSlashPolicy` and `Party#SetCasePolicy`.
+const (
+	// PathPolicyDefault follows the application's `Configuration`, the default policy.
+	PathPolicyDefault = router.PathPolicyDefault
+	// PathPolicyRedirect redirects the client to the registered path.
+	PathPolicyRedirect = router.PathPolicyRedirect
+	// PathPolicyStrict does not match a different path, the client receives a not found.
+	PathPolicyStrict = router.PathPolicyStrict
+	// PathPolicyTolerant executes the route's handlers without a redirection.
+	PathPolicyTolerant = router.PathPolicyTolerant
+)
+
 // Contains the enum values of the `Context.GetReferrer()` method,
 // shortcuts of the context subpackage.
 const (
@@ -680,6 +704,22 @@ func (app *Application) ConfigureHost(configurators ...host.Configurator) *Appli
 	return app
 }
 
+// RegisterOnPreShutdown registers a function to call on the Shutdown of
+// the current and future hosts, before their listeners are closed.
+// See `host.Supervisor.RegisterOnPreShutdown` for more.
+func (app *Application) RegisterOnPreShutdown(cb func(ctx stdContext.Context)) {
+	app.mu.Lock()
+	app.hostConfigurators = append(app.hostConfigurators, func(su *host.Supervisor) {
+		su.RegisterOnPreShutdown(cb)
+	})
+	hosts := append([]*host.Supervisor(nil), app.Hosts...)
+	app.mu.Unlock()
+
+	for _, su := range hosts {
+		su.RegisterOnPreShutdown(cb)
+	}
+}
+
 // NewHost accepts a standard *http.Server object,
 // completes the necessary missing parts of that "srv"
 // and returns a new, ready-to-use, host (supervisor).
@@ -758,31 +798,37 @@ func (app *Application) NewHost(srv *http.Server) *host.Supervisor {
 // A shortcut for the `host#RegisterOnInterrupt`.
 var RegisterOnInterrupt = host.RegisterOnInterrupt
 
-// Shutdown gracefully terminates all the application's server hosts and any tunnels.
-// Returns an error on the first failure, otherwise nil.
+// Shutdown gracefully terminates all the application's server hosts,
+// the applications which run along with it (see `Apps`) and any tunnels.
+// A failure does not stop the rest from being terminated,
+// the errors are returned as an *errgroup.Group, otherwise nil.
 func (app *Application) Shutdown(ctx stdContext.Context) error {
 	app.mu.Lock()
 	defer app.mu.Unlock()
 
+	errs := errgroup.New("Shutdown")
+
 	for i, su := range app.Hosts {
 		app.logger.Debugf("Host[%d]: Shutdown now", i)
 		if err := su.Shutdown(ctx); err != nil {
 			app.logger.Debugf("Host[%d]: Error while trying to shutdown", i)
-			return err
+			errs.Add(err)
 		}
 	}
 
+	for _, other := range app.apps {
+		errs.Add(other.Shutdown(ctx))
+	}
+
 	for _, t := range app.config.Tunneling.Tunnels {
 		if t.Name == "" {
 			continue
 		}
 
-		if err := app.config.Tunneling.stopTunnel(t); err != nil {
-			return err
-		}
+		errs.Add(app.config.Tunneling.stopTunnel(t))
 	}
 
-	return nil
+	return errgroup.Check(errs)
 }
 
 // Build sets up, once, the framework.
@@ -872,8 +918,12 @@ func (app *Application) Build() error {
 		}
 
 		if app.config.ForceLowercaseRouting {
+			// the Parties with their own case policy are matched by the router itself.
+			prefixes := caseSensitivePrefixes(app.APIBuilder.GetRoutes())
 			app.Router.WrapRouter(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-				r.URL.Path = strings.ToLower(r.URL.Path)
+				if !hasPrefixFold(r.URL.Path, prefixes) {
+					r.URL.Path = strings.ToLower(r.URL.Path)
+				}
 				next(w, r)
 			})
 		}
@@ -895,6 +945,42 @@ func (app *Application) Build() error {
 	return errgroup.Check(rp)
 }
 
+// caseSensitivePrefixes returns the static paths of the routes which set
+// a strict or a redirect case policy, see `Party.SetCasePolicy`.
+func caseSensitivePrefixes(routes []*router.Route) []string {
+	var prefixes []string
+	for _, r := range routes {
+		switch r.CasePolicy {
+		case router.PathPolicyStrict, router.PathPolicyRedirect:
+			prefixes = append(prefixes, r.StaticPath())
+		}
+	}
+
+	return prefixes
+}
+
+// hasPrefixFold reports whether the "path" starts with one of the "prefixes", case-insensitively.
+func hasPrefixFold(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if len(path) >= len(prefix) && strings.EqualFold(path[:len(prefix)], prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// serveInherited serves an inherited listener on a new host,
+// over TLS if the "hostConfigs" set the Server's TLSConfig.
+func (app *Application) serveInherited(l net.Listener, hostConfigs ...host.Configurator) error {
+	su := app.NewHost(&http.Server{Addr: l.Addr().String()}).Configure(hostConfigs...)
+	if su.Server.TLSConfig != nil {
+		return su.ServeTLS(l, "", "")
+	}
+
+	return su.Serve(l)
+}
+
 // Runner is just an interface which accepts the framework instance
 // and returns an error.
 //
@@ -928,6 +1014,58 @@ func Listener(l net.Listener, hostConfigs ...host.Configurator) Runner {
 	}
 }
 
+// Inherited can be used as an argument for the `Run` method.
+// It serves the listeners passed by the service manager (systemd socket activation)
+// which are named as "name" (see the "FileDescriptorName" option of the socket unit).
+// If "name" is empty then all inherited listeners are served.
+// Each listener is served by its own host and it blocks until all of them are closed,
+// the returned error contains the errors of all hosts.
+//
+// A host is served under TLS when its `Server.TLSConfig` is set through a host configurator.
+// An application should be ran once, that way different named sockets
+// can be served by different applications and configurations, e.g.
+//
+//     go adminApp.Run(iris.Inherited("admin"))
+//     app.Run(iris.Inherited("https", func(su *host.Supervisor) { su.Server.TLSConfig = tlsConfig }))
+//
+// Second argument is optional, it accepts one or more
+// `func(*host.Configurator)` that are being executed
+// on the hosts that this function will create to start the servers.
+//
+// See `netutil.FromEnv` and `Run` for more.
+func Inherited(name string, hostConfigs ...host.Configurator) Runner {
+	return func(app *Application) error {
+		listeners, err := netutil.FromEnvByName(name)
+		if err != nil {
+			return err
+		}
+
+		if len(listeners) == 0 {
+			return fmt.Errorf("no inherited listeners named %q", name)
+		}
+
+		serve := func(l net.Listener) error {
+			return app.serveInherited(l, hostConfigs...)
+		}
+
+		app.config.vhost = netutil.ResolveVHost(listeners[0].Addr().String())
+
+		errCh := make(chan error, len(listeners))
+		for _, l := range listeners {
+			go func(l net.Listener) {
+				errCh <- serve(l)
+			}(l)
+		}
+
+		errs := errgroup.New("Inherited")
+		for range listeners {
+			errs.Add(<-errCh)
+		}
+
+		return errgroup.Check(errs)
+	}
+}
+
 // Server can be used as an argument for the `Run` method.
 // It can start a server with a *http.Server.
 //
@@ -1005,6 +1143,26 @@ func TLS(addr string, certFileOrContents, keyFileOrContents string, hostConfigs
 	}
 }
 
+// HTTP3 can be used as an argument for the `Run` method.
+// It will start the Application's secure server, exactly like `TLS`,
+// and serve the same router over HTTP/3 (QUIC) on the UDP port of "addr".
+// The HTTP/3 server is advertised to the clients through the "Alt-Svc" response header
+// and it is closed on the host's shutdown.
+//
+// The QUIC implementation is set through the `host.NewHTTP3Server`,
+// e.g. to the quic-go adapter of the "core/host/quic" module,
+// otherwise `Run` fails with `host.ErrHTTP3Unavailable`.
+//
+// Usage:
+//
+//     host.NewHTTP3Server = quic.New
+//     app.Run(iris.HTTP3(":443", "server.crt", "server.key"))
+//
+// See `host.HTTP3` and `TLS` for more.
+func HTTP3(addr string, certFileOrContents, keyFileOrContents string, hostConfigs ...host.Configurator) Runner {
+	return TLS(addr, certFileOrContents, keyFileOrContents, append([]host.Configurator{host.HTTP3()}, hostConfigs...)...)
+}
+
 // AutoTLS can be used as an argument for the `Run` method.
 // It will start the Application's secure server using
 // certifications created on the fly by the "autocert" golang/x package,
@@ -1066,6 +1224,137 @@ func Raw(f func() error) Runner {
 	}
 }
 
+// AppRunner holds an Application and the way it should be served, see `On` and `Apps`.
+type AppRunner struct {
+	app           *Application
+	serve         Runner
+	withOrWithout []Configurator
+}
+
+// On returns an `AppRunner` which serves the "app" through the "serve" Runner
+// and the "withOrWithout" configuration edits (like its `Run` method),
+// it should be passed to the `Apps` Runner.
+func On(app *Application, serve Runner, withOrWithout ...Configurator) *AppRunner {
+	return &AppRunner{app: app, serve: serve, withOrWithout: withOrWithout}
+}
+
+// addApp registers "other" to be shut down along with the Application,
+// once, so a repeated `Run` does not grow the list. The caller should hold the lock.
+func (app *Application) addApp(other *Application) {
+	for _, a := range app.apps {
+		if a == other {
+			return
+		}
+	}
+
+	app.apps = append(app.apps, other)
+}
+
+// configureApps registers a host configurator which shuts down
+// a host that is created after a server of the `Apps` failed.
+func (app *Application) configureApps() {
+	app.ConfigureHost(func(su *host.Supervisor) {
+		if atomic.LoadUint32(&app.appsFailed) == 1 {
+			su.Shutdown(stdContext.Background())
+		}
+	})
+}
+
+// Apps can be used as an argument for the `Run` method.
+// It serves the Application through the "serve" Runner
+// and, at the same time, one or more other applications, each one with its own
+// router, configuration and host(s) (see `On`), e.g. a public API, an admin and a metrics app
+// on separate addresses of the same process.
+//
+// All applications are built before any server starts, so a misconfiguration
+// of one of them does not leave the rest running. If a server fails (e.g. address already in use)
+// the rest are shut down. The interrupt (CTRL/CMD+C) handler shuts down all of them
+// and the Application's `Shutdown` method shuts down the other applications too.
+//
+// It blocks until all servers are closed and returns the first error.
+//
+// Usage:
+//
+//     api.Run(iris.Apps(iris.Addr(":8080"),
+//         iris.On(admin, iris.Addr(":9090", adminHostConfigurator)),
+//         iris.On(metrics, iris.Addr(":9100"), iris.WithoutStartupLog),
+//     ))
+func Apps(serve Runner, others ...*AppRunner) Runner {
+	return func(app *Application) error {
+		for _, other := range others {
+			if err := other.app.prepare(other.withOrWithout...); err != nil {
+				return err
+			}
+		}
+
+		app.mu.Lock()
+		for _, other := range others {
+			app.addApp(other.app)
+		}
+		app.mu.Unlock()
+
+		apps := []*Application{app}
+		for _, other := range others {
+			apps = append(apps, other.app)
+		}
+
+		for _, a := range apps {
+			atomic.StoreUint32(&a.appsFailed, 0)
+			a.configureAppsOnce.Do(a.configureApps)
+		}
+
+		type result struct {
+			app *Application
+			err error
+		}
+
+		results := make(chan result, len(others)+1)
+		run := func(a *Application, serve Runner) {
+			results <- result{a, serve(a)}
+		}
+
+		go run(app, serve)
+		for _, other := range others {
+			go run(other.app, other.serve)
+		}
+
+		var (
+			firstErr     error
+			shuttingDown bool
+		)
+
+		for i := 0; i <= len(others); i++ {
+			r := <-results
+			if r.err == nil {
+				continue
+			}
+
+			if r.app != app {
+				r.app.logger.Error(r.err)
+			}
+
+			if firstErr == nil {
+				firstErr = r.err
+			}
+
+			if !shuttingDown && r.err != ErrServerClosed {
+				// a server failed, stop the rest.
+				shuttingDown = true
+				for _, a := range apps {
+					atomic.StoreUint32(&a.appsFailed, 1)
+				}
+				go func() {
+					ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 10*time.Second)
+					defer cancel()
+					app.Shutdown(ctx)
+				}()
+			}
+		}
+
+		return firstErr
+	}
+}
+
 // ErrServerClosed is returned by the Server's Serve, ServeTLS, ListenAndServe,
 // and ListenAndServeTLS methods after a call to Shutdown or Close.
 //
@@ -1099,20 +1388,12 @@ func (app *Application) Listen(hostPort string, withOrWithout ...Configurator) e
 // The Application can go online with any type of server or iris's host with the help of
 // the following runners:
 // `Listener`, `Server`, `Addr`, `TLS`, `AutoTLS` and `Raw`.
+// See `Apps` to run different applications on separate hosts of the same process.
 func (app *Application) Run(serve Runner, withOrWithout ...Configurator) error {
-	app.Configure(withOrWithout...)
-
-	if err := app.Build(); err != nil {
-		app.logger.Error(err)
+	if err := app.prepare(withOrWithout...); err != nil {
 		return err
 	}
 
-	app.ConfigureHost(func(host *Supervisor) {
-		host.SocketSharding = app.config.SocketSharding
-	})
-
-	app.tryStartTunneling()
-
 	if len(app.Hosts) > 0 {
 		app.logger.Debugf("Application: running using %d host(s)", len(app.Hosts)+1 /* +1 the current */)
 	}
@@ -1126,6 +1407,42 @@ func (app *Application) Run(serve Runner, withOrWithout ...Configurator) error {
 	return err
 }
 
+// prepare configures and builds the application
+// and registers the host configurators of its settings, it is called by `Run`.
+func (app *Application) prepare(withOrWithout ...Configurator) error {
+	app.Configure(withOrWithout...)
+
+	if err := app.Build(); err != nil {
+		app.logger.Error(err)
+		return err
+	}
+
+	// the listener and handler wrappers must not be registered again on the next `Run`.
+	app.configureHostsOnce.Do(func() {
+		app.ConfigureHost(func(host *Supervisor) {
+			host.SocketSharding = app.config.SocketSharding
+		})
+
+		if app.config.EnableProxyProtocol {
+			app.ConfigureHost(host.ProxyProtocol(netutil.ProxyProtocolOptions{
+				TrustedCIDRs: app.config.ProxyProtocolTrustedCIDRs,
+				Strict:       app.config.ProxyProtocolStrict,
+			}))
+		}
+
+		if app.config.EnableH2C {
+			app.ConfigureHost(func(su *host.Supervisor) {
+				su.Server.Handler = h2c.NewHandler(su.Server.Handler, &http2.Server{
+					IdleTimeout: su.Server.IdleTimeout,
+				})
+			})
+		}
+	})
+
+	app.tryStartTunneling()
+	return nil
+}
+
 // tryInjectLiveReload tries to check if this application
 // runs under https://github.com/kataras/iris-cli and if so
 // then it checks if the livereload is enabled and then injects
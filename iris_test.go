@@ -0,0 +1,422 @@
+package iris
+
+import (
+	"bufio"
+	stdContext "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12/core/host"
+	"github.com/kataras/iris/v12/core/netutil"
+
+	"golang.org/x/net/http2"
+)
+
+func TestH2C(t *testing.T) {
+	app := New()
+	app.Get("/", func(ctx Context) {
+		ctx.Writef("%s %v %v", ctx.Request().Proto, ctx.IsHTTP2(), ctx.IsGRPC())
+	})
+
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go app.Run(Listener(l), WithH2C, WithoutStartupLog, WithoutServerError(ErrServerClosed))
+	defer func() {
+		ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 5*time.Second)
+		defer cancel()
+		app.Shutdown(ctx)
+	}()
+
+	priorKnowledge := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+
+	tests := []struct {
+		client      *http.Client
+		contentType string
+		expected    string
+	}{
+		{http.DefaultClient, "", "HTTP/1.1 false false"},
+		{priorKnowledge, "", "HTTP/2.0 true false"},
+		{priorKnowledge, "application/grpc", "HTTP/2.0 true true"},
+		{priorKnowledge, "application/grpc+proto", "HTTP/2.0 true true"},
+		{priorKnowledge, "application/grpc-web+proto", "HTTP/2.0 true false"},
+	}
+
+	for i, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s/", l.Addr()), nil)
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+
+		resp, err := tt.client.Do(req)
+		if err != nil {
+			t.Fatalf("[%d] %v", i, err)
+		}
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if got := string(body); got != tt.expected {
+			t.Fatalf("[%d] expected body: %q but got: %q", i, tt.expected, got)
+		}
+	}
+
+	// the HTTP/1.1 upgrade to h2c.
+	conn, err := net.Dial("tcp4", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %s\r\nConnection: Upgrade, HTTP2-Settings\r\n"+
+		"Upgrade: h2c\r\nHTTP2-Settings: \r\nContent-Type: application/grpc\r\n\r\n", l.Addr())
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected status code: %d but got: %d", http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+
+	// the response of the upgraded request is sent on the stream 1.
+	if _, err = conn.Write([]byte(http2.ClientPreface)); err != nil {
+		t.Fatal(err)
+	}
+	framer := http2.NewFramer(conn, br)
+	if err = framer.WriteSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	var body []byte
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if data, ok := frame.(*http2.DataFrame); ok && data.StreamID == 1 {
+			body = append(body, data.Data()...)
+			if data.StreamEnded() {
+				break
+			}
+		}
+	}
+
+	if expected, got := "HTTP/2.0 true true", string(body); expected != got {
+		t.Fatalf("expected body: %q but got: %q", expected, got)
+	}
+}
+
+func TestApps(t *testing.T) {
+	newApp := func(name string) *Application {
+		app := New()
+		app.Get("/", func(ctx Context) {
+			ctx.WriteString(name)
+		})
+		return app
+	}
+
+	api, admin, metrics := newApp("api"), newApp("admin"), newApp("metrics")
+
+	listen := func() net.Listener {
+		l, err := net.Listen("tcp4", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return l
+	}
+
+	apiL, adminL, metricsL := listen(), listen(), listen()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- api.Run(Apps(Listener(apiL),
+			On(admin, Listener(adminL), WithoutStartupLog),
+			On(metrics, Listener(metricsL), WithoutStartupLog),
+		), WithoutStartupLog, WithoutInterruptHandler)
+	}()
+
+	for l, expected := range map[net.Listener]string{apiL: "api", adminL: "admin", metricsL: "metrics"} {
+		var (
+			resp *http.Response
+			err  error
+		)
+		for i := 0; i < 50; i++ {
+			if resp, err = http.Get("http://" + l.Addr().String()); err == nil {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if got := string(body); got != expected {
+			t.Fatalf("expected body: %q but got: %q", expected, got)
+		}
+	}
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 5*time.Second)
+	defer cancel()
+	if err := api.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != ErrServerClosed {
+			t.Fatalf("expected error: %v but got: %v", ErrServerClosed, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected all servers to be closed")
+	}
+
+	if _, err := http.Get("http://" + adminL.Addr().String()); err == nil {
+		t.Fatal("expected the admin server to be closed")
+	}
+}
+
+func TestAppsFailure(t *testing.T) {
+	busy, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	api, admin := New(), New()
+	api.Logger().SetLevel("disable")
+	admin.Logger().SetLevel("disable")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- api.Run(Apps(Addr("127.0.0.1:0"), On(admin, Addr(busy.Addr().String()))),
+			WithoutStartupLog, WithoutInterruptHandler)
+	}()
+
+	select {
+	case err = <-done:
+		if err == nil || err == ErrServerClosed {
+			t.Fatalf("expected the listen error but got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the rest of the servers to be shut down on failure")
+	}
+}
+
+func TestRunConfiguresHostsOnce(t *testing.T) {
+	app := New()
+	for i := 0; i < 2; i++ {
+		if err := app.Run(Raw(func() error { return nil }), WithH2C, WithoutStartupLog); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// socket sharding and h2c.
+	if expected, got := 2, len(app.hostConfigurators); expected != got {
+		t.Fatalf("expected %d host configurators but got: %d", expected, got)
+	}
+
+	other := New()
+	errFailed := errors.New("failed")
+	runners := []Runner{Raw(func() error { return errFailed }), Raw(func() error { return nil })}
+	for _, serve := range runners {
+		app.Run(Apps(serve, On(other, Raw(func() error { return nil }), WithoutStartupLog)), WithoutStartupLog)
+	}
+
+	// plus the one of the Apps.
+	if expected, got := 3, len(app.hostConfigurators); expected != got {
+		t.Fatalf("expected %d host configurators but got: %d", expected, got)
+	}
+	if expected, got := 2, len(other.hostConfigurators); expected != got {
+		t.Fatalf("expected %d host configurators of the other app but got: %d", expected, got)
+	}
+
+	// the failure of the previous run does not shut down the hosts of the next one.
+	if app.appsFailed != 0 || other.appsFailed != 0 {
+		t.Fatal("expected the failed state to be reset on Run")
+	}
+}
+
+func TestAppsShutdownAll(t *testing.T) {
+	api, admin := New(), New()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	api.Get("/", func(ctx Context) {
+		close(started)
+		<-release
+	})
+
+	apiL, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminL, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go api.Run(Apps(Listener(apiL), On(admin, Listener(adminL), WithoutStartupLog)),
+		WithoutStartupLog, WithoutInterruptHandler)
+
+	go func() {
+		for i := 0; i < 50; i++ {
+			if resp, err := http.Get("http://" + apiL.Addr().String()); err == nil {
+				resp.Body.Close()
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the request to be served")
+	}
+
+	// the api host fails to shutdown because of the in-flight request,
+	// the admin one is still terminated.
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err = api.Shutdown(ctx); err == nil {
+		t.Fatal("expected a shutdown error")
+	}
+
+	if _, err = http.Get("http://" + adminL.Addr().String()); err == nil {
+		t.Fatal("expected the admin server to be closed")
+	}
+}
+
+func TestAppsRegisteredOnce(t *testing.T) {
+	app, other := New(), New()
+	raw := Raw(func() error { return nil })
+	for i := 0; i < 2; i++ {
+		if err := app.Run(Apps(raw, On(other, raw)), WithoutStartupLog); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if expected, got := 1, len(app.apps); expected != got {
+		t.Fatalf("expected %d applications but got: %d", expected, got)
+	}
+}
+
+func TestInheritedProxyProtocolTLS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+
+	// an inherited file descriptor.
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ln.(*net.TCPListener).File()
+	ln.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inherited, err := net.FileListener(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := inherited.Addr().String()
+
+	app := New()
+	app.Get("/", func(ctx Context) {
+		ctx.Writef("%s %s", ctx.Request().RemoteAddr, ctx.Request().Proto)
+	})
+	if err = app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	go app.serveInherited(inherited, host.ProxyProtocol(netutil.ProxyProtocolOptions{TrustedCIDRs: []string{"127.0.0.0/8"}}),
+		func(su *host.Supervisor) {
+			su.Server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+		})
+	defer func() {
+		ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 5*time.Second)
+		defer cancel()
+		app.Shutdown(ctx)
+	}()
+
+	// the PROXY protocol header comes before the TLS handshake.
+	client := &http.Client{Transport: &http2.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			conn, err := net.Dial(network, addr)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, err = conn.Write([]byte("PROXY TCP4 203.0.113.7 127.0.0.1 12345 443\r\n")); err != nil {
+				conn.Close()
+				return nil, err
+			}
+
+			tlsConn := tls.Client(conn, cfg)
+			if err = tlsConn.Handshake(); err != nil {
+				conn.Close()
+				return nil, err
+			}
+
+			return tlsConn, nil
+		},
+	}}
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get("https://" + addr); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if expected, got := "203.0.113.7:12345 HTTP/2.0", string(body); expected != got {
+		t.Fatalf("expected body: %q but got: %q", expected, got)
+	}
+}
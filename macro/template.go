@@ -36,6 +36,9 @@ type TemplateParam struct {
 	ErrCode       int             `json:"errCode"`
 	TypeEvaluator ParamEvaluator  `json:"-"`
 	Funcs         []reflect.Value `json:"-"`
+	// FuncNames keeps the names of the registered "Funcs", in the same order,
+	// i.e ["min", "max"] for {id:int min(1) max(5)}.
+	FuncNames []string `json:"funcs,omitempty"`
 
 	stringInFuncs []func(string) bool
 	canEval       bool
@@ -150,6 +153,7 @@ func Parse(src string, macros Macros) (Template, error) {
 				continue
 			}
 			tmplParam.Funcs = append(tmplParam.Funcs, evalFn)
+			tmplParam.FuncNames = append(tmplParam.FuncNames, paramfn.Name)
 		}
 
 		tmpl.Params = append(tmpl.Params, tmplParam.preComputed())
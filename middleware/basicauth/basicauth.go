@@ -5,10 +5,11 @@ package basicauth
 
 import (
 	"encoding/base64"
+	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
-	"github.com/kataras/iris/v12"
 	"github.com/kataras/iris/v12/context"
 )
 
@@ -16,20 +17,33 @@ func init() {
 	context.SetHandlerName("iris/middleware/basicauth.*", "iris.basicauth")
 }
 
+const (
+	userContextKey       = "iris.basicauth.user"
+	middlewareContextKey = "iris.basicauth"
+	// challengeCookieName is the cookie of the client which was asked
+	// for credentials after a logout, see `login`.
+	challengeCookieName = "iris.basicauth.challenge"
+)
+
 type (
-	encodedUser struct {
-		HeaderValue string
-		Username    string
-		logged      bool
-		expires     time.Time
+	// userState is the login state of a username.
+	userState struct {
+		expires time.Time
+		// realm is rotated on logout.
+		realm     int
+		loggedOut bool
+		// challenge is the value of the challenge cookie of the clients
+		// which were asked for credentials after the logout.
+		challenge string
 	}
-	encodedUsers []*encodedUser
 
 	basicAuthMiddleware struct {
-		config Config
-		// these are filled from the config.Users map at the startup
-		auth             encodedUsers
-		realmHeaderValue string
+		config   Config
+		provider UserProvider
+		attempts *attempts // nil if the lockout is disabled.
+
+		mu    sync.Mutex
+		users map[string]*userState
 
 		// The below can be removed but they are here because on the future we may add dynamic options for those two fields,
 		// it is a bit faster to check the b.$bool as well.
@@ -44,14 +58,22 @@ type (
 // which will ask the client for basic auth (username, password),
 // validate that and if valid continues to the next handler, otherwise
 // throws a StatusUnauthorized http error code.
+//
+// The authenticated user is available through `GetUser`
+// and it can be injected into the hero handlers and MVC controllers as *basicauth.User.
 func New(c Config) context.Handler {
 	config := DefaultConfig()
 	if c.Realm != "" {
 		config.Realm = c.Realm
 	}
 	config.Users = c.Users
+	config.Provider = c.Provider
 	config.Expires = c.Expires
 	config.OnAsk = c.OnAsk
+	config.MaxAttempts = c.MaxAttempts
+	if c.LockoutDuration > 0 {
+		config.LockoutDuration = c.LockoutDuration
+	}
 
 	b := &basicAuthMiddleware{config: config}
 	b.init()
@@ -69,63 +91,253 @@ func Default(users map[string]string) context.Handler {
 }
 
 func (b *basicAuthMiddleware) init() {
-	// pass the encoded users from the user's config's Users value
-	b.auth = make(encodedUsers, 0, len(b.config.Users))
-
-	for k, v := range b.config.Users {
-		fullUser := k + ":" + v
-		header := "Basic " + base64.StdEncoding.EncodeToString([]byte(fullUser))
-		b.auth = append(b.auth, &encodedUser{HeaderValue: header, Username: k, logged: false, expires: DefaultExpireTime})
+	b.provider = b.config.Provider
+	if b.provider == nil {
+		b.provider = Static(b.config.Users)
 	}
 
-	// set the auth realm header's value
-	b.realmHeaderValue = "Basic realm=" + strconv.Quote(b.config.Realm)
+	if b.config.MaxAttempts > 0 {
+		b.attempts = newAttempts(b.config.MaxAttempts, b.config.LockoutDuration)
+	}
 
+	b.users = make(map[string]*userState)
 	b.expireEnabled = b.config.Expires > 0
 	b.askHandlerEnabled = b.config.OnAsk != nil
 }
 
-func (b *basicAuthMiddleware) findAuth(headerValue string) (*encodedUser, bool) {
-	if headerValue != "" {
-		for _, user := range b.auth {
-			if user.HeaderValue == headerValue {
-				return user, true
-			}
-		}
+// realmHeaderValue returns the WWW-Authenticate header value of the rotated "realm".
+func (b *basicAuthMiddleware) realmHeaderValue(realm int) string {
+	name := b.config.Realm
+	if realm > 0 {
+		name += " (" + strconv.Itoa(realm) + ")"
 	}
 
-	return nil, false
+	return "Basic realm=" + strconv.Quote(name)
 }
 
-func (b *basicAuthMiddleware) askForCredentials(ctx context.Context) {
-	ctx.Header("WWW-Authenticate", b.realmHeaderValue)
-	ctx.StatusCode(iris.StatusUnauthorized)
+func (b *basicAuthMiddleware) askForCredentials(ctx context.Context, realm int) {
+	ctx.Header("WWW-Authenticate", b.realmHeaderValue(realm))
+	ctx.StatusCode(http.StatusUnauthorized)
 	if b.askHandlerEnabled {
 		b.config.OnAsk(ctx)
 	}
 }
 
+// login reports whether the authenticated "username" can continue,
+// it is asked again after its login expires or after a logout.
+//
+// After a logout all the requests of the user are asked for credentials and
+// the first ask does not clear the logout: the asked client is given a challenge cookie
+// and only its next request, which answers the ask, logs the user in again.
+// The requests of the other clients (and devices) with cached credentials keep being asked.
+func (b *basicAuthMiddleware) login(ctx context.Context, username string) (int, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s, ok := b.users[username]
+	if !ok {
+		s = new(userState)
+		b.users[username] = s
+		if b.expireEnabled {
+			s.expires = time.Now().Add(b.config.Expires)
+		}
+		return s.realm, true
+	}
+
+	if s.loggedOut {
+		if s.challenge != "" && ctx.GetCookie(challengeCookieName) == s.challenge {
+			s.loggedOut = false
+			s.challenge = ""
+			ctx.RemoveCookie(challengeCookieName)
+			return s.realm, true
+		}
+
+		if s.challenge == "" {
+			s.challenge = base64.RawURLEncoding.EncodeToString(randomBytes(16))
+		}
+		ctx.SetCookieKV(challengeCookieName, s.challenge, context.CookieHTTPOnly(true))
+		return s.realm, false
+	}
+
+	if b.expireEnabled {
+		if now := time.Now(); now.After(s.expires) {
+			s.expires = now.Add(b.config.Expires)
+			return s.realm, false
+		}
+	}
+
+	return s.realm, true
+}
+
+func (b *basicAuthMiddleware) logout(ctx context.Context) {
+	username, _, _ := ctx.Request().BasicAuth()
+
+	b.mu.Lock()
+	s, ok := b.users[username]
+	if !ok {
+		s = new(userState)
+		b.users[username] = s
+	}
+	s.realm++
+	s.loggedOut = true
+	s.challenge = ""
+	realm := s.realm
+	b.mu.Unlock()
+
+	b.askForCredentials(ctx, realm)
+	ctx.StopExecution()
+}
+
 // Serve the actual middleware
 func (b *basicAuthMiddleware) Serve(ctx context.Context) {
-	auth, found := b.findAuth(ctx.GetHeader("Authorization"))
-	if !found {
-		b.askForCredentials(ctx)
+	ctx.Values().Set(middlewareContextKey, b)
+
+	username, password, ok := ctx.Request().BasicAuth()
+	ip := ctx.RemoteAddr()
+
+	if b.attempts != nil {
+		if retryAfter := b.attempts.locked(ip, username); retryAfter > 0 {
+			ctx.Header("Retry-After", strconv.FormatInt(int64((retryAfter+time.Second-1)/time.Second), 10))
+			ctx.StopWithStatus(http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	if !ok {
+		b.askForCredentials(ctx, 0)
 		ctx.StopExecution()
 		return
 		// don't continue to the next handler
 	}
-	// all ok
-	if b.expireEnabled {
-		if !auth.logged {
-			auth.expires = time.Now().Add(b.config.Expires)
-			auth.logged = true
-		}
 
-		if time.Now().After(auth.expires) {
-			b.askForCredentials(ctx) // ask for authentication again
-			ctx.StopExecution()
-			return
+	user, ok := b.provider.Authenticate(ctx, username, password)
+	if !ok {
+		if b.attempts != nil {
+			b.attempts.fail(ip, username)
 		}
+
+		b.askForCredentials(ctx, 0)
+		ctx.StopExecution()
+		return
+	}
+
+	if b.attempts != nil {
+		b.attempts.reset(ip, username)
+	}
+
+	if realm, ok := b.login(ctx, username); !ok {
+		b.askForCredentials(ctx, realm) // ask for authentication again
+		ctx.StopExecution()
+		return
 	}
+
+	if user == nil {
+		user = &User{Username: username}
+	}
+
+	ctx.Values().Set(userContextKey, user)
 	ctx.Next() // continue
 }
+
+// GetUser returns the authenticated user of the current request or nil.
+func GetUser(ctx context.Context) *User {
+	user, _ := ctx.Values().Get(userContextKey).(*User)
+	return user
+}
+
+// Logout logs out the current request's user: it responds with 401 Unauthorized
+// through a new, rotated, realm, so the browsers drop the cached credentials,
+// and the user's next requests, from any tab or client, are asked for credentials too.
+// Register it on a route after the basicauth middleware, e.g.
+// app.Get("/logout", auth, basicauth.Logout).
+func Logout(ctx context.Context) {
+	b, ok := ctx.Values().Get(middlewareContextKey).(*basicAuthMiddleware)
+	if !ok {
+		ctx.StopWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	b.logout(ctx)
+}
+
+// attempts counts the failed logins per key (client IP and username).
+type attempts struct {
+	max      int
+	duration time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*attempt
+	nextSweep time.Time
+}
+
+type attempt struct {
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+func newAttempts(max int, duration time.Duration) *attempts {
+	return &attempts{max: max, duration: duration, entries: make(map[string]*attempt)}
+}
+
+func attemptKeys(ip, username string) [2]string {
+	return [2]string{"ip:" + ip, "user:" + username}
+}
+
+// locked returns the remaining lockout time of the "ip" or the "username".
+func (a *attempts) locked(ip, username string) time.Duration {
+	now := time.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var remaining time.Duration
+	for _, key := range attemptKeys(ip, username) {
+		if e, ok := a.entries[key]; ok {
+			if d := e.lockedUntil.Sub(now); d > remaining {
+				remaining = d
+			}
+		}
+	}
+
+	return remaining
+}
+
+func (a *attempts) fail(ip, username string) {
+	now := time.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if now.After(a.nextSweep) {
+		for key, e := range a.entries {
+			if now.Sub(e.last) > a.duration && now.After(e.lockedUntil) {
+				delete(a.entries, key)
+			}
+		}
+		a.nextSweep = now.Add(time.Minute)
+	}
+
+	for _, key := range attemptKeys(ip, username) {
+		e, ok := a.entries[key]
+		if !ok || now.Sub(e.last) > a.duration {
+			e = new(attempt)
+			a.entries[key] = e
+		}
+
+		e.count++
+		e.last = now
+		if e.count >= a.max {
+			e.count = 0
+			e.lockedUntil = now.Add(a.duration)
+		}
+	}
+}
+
+func (a *attempts) reset(ip, username string) {
+	a.mu.Lock()
+	for _, key := range attemptKeys(ip, username) {
+		delete(a.entries, key)
+	}
+	a.mu.Unlock()
+}
@@ -0,0 +1,103 @@
+package basicauth_test
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/basicauth"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestVerifyPassword(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	salt := []byte("somesalt")
+	argon2Hash := "$argon2id$v=19$m=1024,t=1,p=1$" + base64.RawStdEncoding.EncodeToString(salt) + "$" +
+		base64.RawStdEncoding.EncodeToString(argon2.IDKey([]byte("secret"), salt, 1, 1024, 1, 32))
+
+	sum := sha1.Sum([]byte("secret"))
+	shaHash := "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+
+	for _, hash := range []string{string(bcryptHash), argon2Hash, shaHash, "secret"} {
+		if !basicauth.VerifyPassword(hash, "secret") {
+			t.Fatalf("expected %q to match", hash)
+		}
+
+		if basicauth.VerifyPassword(hash, "wrong") {
+			t.Fatalf("expected %q to not match", hash)
+		}
+	}
+}
+
+func TestHtpasswd(t *testing.T) {
+	sum := sha1.Sum([]byte("secret"))
+	provider, err := basicauth.ParseHtpasswd(strings.NewReader("# users\n\nadmin:{SHA}" + base64.StdEncoding.EncodeToString(sum[:]) + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user, ok := provider.Authenticate(nil, "admin", "secret"); !ok || user.Username != "admin" {
+		t.Fatalf("expected the admin user but got: %#+v", user)
+	}
+
+	if _, ok := provider.Authenticate(nil, "other", "secret"); ok {
+		t.Fatalf("expected an unknown user")
+	}
+
+	if _, err = basicauth.ParseHtpasswd(strings.NewReader("admin:$apr1$salt$hash")); err == nil {
+		t.Fatalf("expected an unsupported hash error")
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	auth := basicauth.New(basicauth.Config{
+		Provider: basicauth.UserFunc(func(ctx context.Context, username, password string) (*basicauth.User, bool) {
+			if username != "admin" || password != "secret" {
+				return nil, false
+			}
+
+			return &basicauth.User{Username: username, Data: "root"}, true
+		}),
+		MaxAttempts: 2,
+	})
+
+	app := iris.New()
+	app.ConfigureContainer(func(api *iris.APIContainer) {
+		api.RegisterDependency(basicauth.Dependency)
+		api.Get("/", auth, func(user *basicauth.User) string {
+			return user.Username + ":" + user.Data.(string)
+		})
+	})
+	app.Get("/logout", auth, basicauth.Logout)
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+	e.GET("/").Expect().Status(httptest.StatusUnauthorized).
+		Header("WWW-Authenticate").Equal(`Basic realm="Authorization Required"`)
+	e.GET("/").WithBasicAuth("admin", "secret").Expect().Status(httptest.StatusOK).Body().Equal("admin:root")
+
+	// logout.
+	e.GET("/logout").WithBasicAuth("admin", "secret").Expect().Status(httptest.StatusUnauthorized).
+		Header("WWW-Authenticate").Equal(`Basic realm="Authorization Required (1)"`)
+	e.GET("/").WithBasicAuth("admin", "secret").Expect().Status(httptest.StatusUnauthorized).
+		Cookie("iris.basicauth.challenge").Value().NotEmpty()
+	// another client with cached credentials is asked too.
+	httptest.New(t, app, httptest.URL("http://example.com")).GET("/").WithBasicAuth("admin", "secret").Expect().Status(httptest.StatusUnauthorized)
+	// the asked client answers.
+	e.GET("/").WithBasicAuth("admin", "secret").Expect().Status(httptest.StatusOK)
+
+	// lockout.
+	e.GET("/").WithBasicAuth("admin", "wrong").Expect().Status(httptest.StatusUnauthorized)
+	e.GET("/").WithBasicAuth("admin", "wrong").Expect().Status(httptest.StatusUnauthorized)
+	e.GET("/").WithBasicAuth("admin", "secret").Expect().Status(httptest.StatusTooManyRequests).
+		Header("Retry-After").Equal("900")
+}
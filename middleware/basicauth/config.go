@@ -16,12 +16,29 @@ var DefaultExpireTime time.Time // 0001-01-01 00:00:00 +0000 UTC
 
 // Config the configs for the basicauth middleware
 type Config struct {
-	// Users a map of login and the value (username/password)
+	// Users a map of login and the value (username/password),
+	// the passwords can be in plain text or hashed, see `Static`.
+	// It is ignored when Provider is set.
 	Users map[string]string
+	// Provider authenticates the users, e.g. a `LoadHtpasswd` file or a custom `UserFunc`.
+	//
+	// Defaults to a `Static` provider of the Users.
+	Provider UserProvider
 	// Realm http://tools.ietf.org/html/rfc2617#section-1.2. Default is "Authorization Required"
 	Realm string
-	// Expires expiration duration, default is 0 never expires
+	// Expires expiration duration, default is 0 never expires.
+	// When a user's login expires the client is asked for credentials again.
 	Expires time.Duration
+	// MaxAttempts is the number of failed logins per client IP and per username
+	// before they are locked out for the LockoutDuration, with a 429 Too Many Requests status code.
+	// Note that an attacker can lock out a known username too.
+	//
+	// Defaults to 0, no lockout.
+	MaxAttempts int
+	// LockoutDuration is the lockout's duration and the time the failed logins are remembered for.
+	//
+	// Defaults to 15 minutes.
+	LockoutDuration time.Duration
 
 	// OnAsk fires each time the server asks to the client for credentials in order to gain access and continue to the next handler.
 	//
@@ -46,7 +63,11 @@ type Config struct {
 
 // DefaultConfig returns the default configs for the BasicAuth middleware
 func DefaultConfig() Config {
-	return Config{make(map[string]string), DefaultBasicAuthRealm, 0, nil}
+	return Config{
+		Users:           make(map[string]string),
+		Realm:           DefaultBasicAuthRealm,
+		LockoutDuration: 15 * time.Minute,
+	}
 }
 
 // User returns the user from context key same as  ctx.Request().BasicAuth().
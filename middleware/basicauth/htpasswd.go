@@ -0,0 +1,95 @@
+package basicauth
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+// Htpasswd is a `UserProvider` of an Apache htpasswd file,
+// its entries are hashed with bcrypt ("htpasswd -B"), argon2 or SHA-1 ("htpasswd -s").
+// The MD5 ("$apr1$") and crypt entries are not supported.
+type Htpasswd struct {
+	filename string
+
+	mu    sync.RWMutex
+	users *staticUsers
+}
+
+var _ UserProvider = (*Htpasswd)(nil)
+
+// LoadHtpasswd returns a new `Htpasswd` of the "filename" file.
+// Call its `Reload` method to read the file again, e.g. on a SIGHUP.
+func LoadHtpasswd(filename string) (*Htpasswd, error) {
+	h := &Htpasswd{filename: filename}
+	if err := h.Reload(); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// Reload reads the file again and replaces the users.
+func (h *Htpasswd) Reload() error {
+	f, err := os.Open(h.filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	users, err := ParseHtpasswd(f)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.users = users.(*staticUsers)
+	h.mu.Unlock()
+	return nil
+}
+
+// Authenticate verifies the credentials against the file's entries.
+func (h *Htpasswd) Authenticate(ctx context.Context, username, password string) (*User, bool) {
+	h.mu.RLock()
+	users := h.users
+	h.mu.RUnlock()
+
+	return users.Authenticate(ctx, username, password)
+}
+
+// ParseHtpasswd returns a `UserProvider` of the htpasswd entries of "r",
+// one "username:hash" per line, empty lines and lines starting with '#' are ignored.
+func ParseHtpasswd(r io.Reader) (UserProvider, error) {
+	users := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || entry[0] == '#' {
+			continue
+		}
+
+		idx := strings.IndexByte(entry, ':')
+		if idx <= 0 || idx == len(entry)-1 {
+			return nil, fmt.Errorf("basicauth: htpasswd: line %d: invalid entry", line)
+		}
+
+		hash := entry[idx+1:]
+		if strings.HasPrefix(hash, "$apr1$") {
+			return nil, fmt.Errorf("basicauth: htpasswd: line %d: unsupported MD5 hash, use bcrypt instead", line)
+		}
+
+		users[entry[:idx]] = hash
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return newStaticUsers(users), nil
+}
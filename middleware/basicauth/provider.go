@@ -0,0 +1,235 @@
+package basicauth
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kataras/iris/v12/context"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User is the authenticated user, see `GetUser`.
+// It can be injected into the handlers and controllers of the hero and MVC packages
+// as *basicauth.User through the `Dependency`.
+type User struct {
+	Username string
+	// Data is any custom data of the user, e.g. its roles,
+	// filled by custom `UserProvider`s.
+	Data interface{}
+}
+
+// Dependency is the *basicauth.User dependency of the hero handlers and MVC controllers,
+// it panics if the user is missing, i.e. the basicauth middleware was not executed.
+//
+// Usage:
+//
+//     app.ConfigureContainer().RegisterDependency(basicauth.Dependency)
+//     app.ConfigureContainer().Get("/", func(user *basicauth.User) string {
+//         return user.Username
+//     })
+func Dependency(ctx context.Context) *User {
+	user := GetUser(ctx)
+	if user == nil {
+		panic("binding: basicauth user is nil - app.Use(basicauth.New(...)) to fix it")
+	}
+
+	return user
+}
+
+// UserProvider authenticates the users, see `Config.Provider`.
+type UserProvider interface {
+	// Authenticate returns the user of the given credentials and true,
+	// or false if the credentials are invalid.
+	Authenticate(ctx context.Context, username, password string) (*User, bool)
+}
+
+// UserFunc is a `UserProvider` of a custom function, e.g. a database lookup.
+type UserFunc func(ctx context.Context, username, password string) (*User, bool)
+
+// Authenticate calls the function.
+func (fn UserFunc) Authenticate(ctx context.Context, username, password string) (*User, bool) {
+	return fn(ctx, username, password)
+}
+
+// Static returns a `UserProvider` of a map of usernames and passwords.
+// The passwords can be in plain text or hashed in one of the formats of the htpasswd files:
+// bcrypt ("$2y$..."), argon2 ("$argon2id$...") and SHA-1 ("{SHA}...").
+func Static(users map[string]string) UserProvider {
+	// copy, so the caller can not modify it concurrently.
+	passwords := make(map[string]string, len(users))
+	for username, password := range users {
+		passwords[username] = password
+	}
+
+	return newStaticUsers(passwords)
+}
+
+type staticUsers struct {
+	passwords map[string]string
+	// dummy is compared on unknown usernames, it is a hash of the same kind
+	// of the stored ones, so an unknown username takes as long as a wrong password.
+	dummy string
+}
+
+func newStaticUsers(passwords map[string]string) *staticUsers {
+	return &staticUsers{passwords: passwords, dummy: dummyHash(passwords)}
+}
+
+func (users *staticUsers) Authenticate(_ context.Context, username, password string) (*User, bool) {
+	hash, ok := users.passwords[username]
+	if !ok {
+		VerifyPassword(users.dummy, password)
+		return nil, false
+	}
+
+	if !VerifyPassword(hash, password) {
+		return nil, false
+	}
+
+	return &User{Username: username}, true
+}
+
+// dummyHash returns a hash of a random password of the slowest kind (and its cost) of the "passwords".
+func dummyHash(passwords map[string]string) string {
+	hashes := make([]string, 0, len(passwords))
+	for _, hash := range passwords {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes) // the same kind is picked on each call.
+
+	var plain, sha string
+	for _, hash := range hashes {
+		switch {
+		case strings.HasPrefix(hash, "$2"):
+			cost, err := bcrypt.Cost([]byte(hash))
+			if err != nil {
+				continue
+			}
+
+			if dummy, err := bcrypt.GenerateFromPassword(randomBytes(16), cost); err == nil {
+				return string(dummy)
+			}
+		case strings.HasPrefix(hash, "$argon2"):
+			// same variant and parameters, a random salt and key of the same length.
+			parts := strings.Split(hash, "$")
+			if len(parts) != 6 {
+				continue
+			}
+
+			salt, err1 := base64.RawStdEncoding.DecodeString(parts[4])
+			key, err2 := base64.RawStdEncoding.DecodeString(parts[5])
+			if err1 != nil || err2 != nil {
+				continue
+			}
+
+			parts[4] = base64.RawStdEncoding.EncodeToString(randomBytes(len(salt)))
+			parts[5] = base64.RawStdEncoding.EncodeToString(randomBytes(len(key)))
+			return strings.Join(parts, "$")
+		case strings.HasPrefix(hash, "{SHA}"):
+			sum := sha1.Sum(randomBytes(16))
+			sha = "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+		default:
+			if len(hash) > len(plain) {
+				plain = base64.RawURLEncoding.EncodeToString(randomBytes(len(hash)))[:len(hash)]
+			}
+		}
+	}
+
+	if sha != "" {
+		return sha
+	}
+
+	return plain
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+// VerifyPassword reports whether the "password" matches the "hash",
+// which can be a bcrypt, argon2 ("$argon2id$v=19$m=65536,t=3,p=4$salt$key"),
+// SHA-1 ("{SHA}" and the base64 of the sum) or a plain text one.
+// The comparison takes constant time.
+func VerifyPassword(hash, password string) bool {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case strings.HasPrefix(hash, "$argon2"):
+		return verifyArgon2(hash, password)
+	case strings.HasPrefix(hash, "{SHA}"):
+		sum := sha1.Sum([]byte(password))
+		return constantTimeEqual(hash[5:], base64.StdEncoding.EncodeToString(sum[:]))
+	default:
+		return constantTimeEqual(hash, password)
+	}
+}
+
+func constantTimeEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// verifyArgon2 verifies a hash of the PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=4$<base64 salt>$<base64 key>.
+func verifyArgon2(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[2] != "v="+strconv.Itoa(argon2.Version) {
+		return false
+	}
+
+	var (
+		memory  uint32
+		time    uint32
+		threads uint8
+	)
+	for _, param := range strings.Split(parts[3], ",") {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return false
+		}
+
+		n, err := strconv.ParseUint(kv[1], 10, 32)
+		if err != nil {
+			return false
+		}
+
+		switch kv[0] {
+		case "m":
+			memory = uint32(n)
+		case "t":
+			time = uint32(n)
+		case "p":
+			threads = uint8(n)
+		}
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 || time == 0 || threads == 0 {
+		return false
+	}
+
+	var got []byte
+	switch parts[1] {
+	case "argon2id":
+		got = argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	case "argon2i":
+		got = argon2.Key([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	default:
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(got, key) == 1
+}
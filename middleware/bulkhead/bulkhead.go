@@ -0,0 +1,395 @@
+// Package bulkhead provides a concurrency limiting (bulkhead) middleware.
+// It caps the in-flight requests per route or custom key, queues the excess requests
+// up to a maximum size and wait time and sheds the rest with 503 Service Unavailable
+// and a Retry-After header, so a slow upstream cannot pile up the server's goroutines.
+// The limits can be fixed or adaptive, see `AIMDLimit` and `GradientLimit`,
+// and the groups' queue depth and rejections are reported through `Stats`.
+package bulkhead
+
+import (
+	"container/list"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/bulkhead.*", "iris.bulkhead")
+}
+
+// LimitMetaKey is the route's metadata key of a per-route fixed limit, e.g.
+// app.Post("/upload", handler).SetMeta(bulkhead.LimitMetaKey, 2).
+// It is applied when the route's group is created, on its first request.
+const LimitMetaKey = "bulkhead.limit"
+
+var (
+	// ErrQueueFull is passed to the `Options.ShedHandler` when the limit and the queue are full.
+	ErrQueueFull = errors.New("bulkhead: queue full")
+	// ErrQueueTimeout is passed to the `Options.ShedHandler` when a queued request
+	// waited longer than the `Options.MaxWait`.
+	ErrQueueTimeout = errors.New("bulkhead: queue timeout")
+)
+
+// Options holds the options for the `Bulkhead`.
+type Options struct {
+	// MaxConcurrent is the maximum number of in-flight requests per group,
+	// the initial limit of the adaptive limits.
+	//
+	// Defaults to 100.
+	MaxConcurrent int
+	// MaxQueue is the maximum number of requests waiting per group.
+	//
+	// Defaults to 0, the requests over the limit are shed immediately.
+	MaxQueue int
+	// MaxWait is the maximum time a request waits in the queue.
+	//
+	// Defaults to 1 second.
+	MaxWait time.Duration
+	// Key returns the group of a request.
+	//
+	// Defaults to the route's name, the unmatched requests share the "" group.
+	Key func(ctx context.Context) string
+	// IdleTimeout is the time after a group without in-flight and queued requests
+	// is removed, along with its limit and statistics, so the groups of a custom Key,
+	// e.g. per client, do not grow the memory forever.
+	// A negative value never removes them.
+	//
+	// Defaults to 5 minutes when a custom Key is set, otherwise the route groups are kept.
+	IdleTimeout time.Duration
+	// NewLimit returns the limit of a new group, e.g.
+	// func(string) bulkhead.Limit { return bulkhead.NewGradientLimit(20) }.
+	// The routes' `LimitMetaKey` has priority over it.
+	//
+	// Defaults to a `FixedLimit` of the MaxConcurrent.
+	NewLimit func(key string) Limit
+	// RetryAfter is the Retry-After header of the shed requests.
+	//
+	// Defaults to 1 second.
+	RetryAfter time.Duration
+	// ShedHandler is fired on shed requests, with `ErrQueueFull` or `ErrQueueTimeout`.
+	// The Retry-After header is already set.
+	//
+	// Defaults to a 503 Service Unavailable status code.
+	ShedHandler func(ctx context.Context, err error)
+}
+
+// Stats are the statistics of a group.
+type Stats struct {
+	Key      string `json:"key"`
+	Limit    int    `json:"limit"`
+	InFlight int    `json:"inFlight"`
+	Queued   int    `json:"queued"`
+	// Accepted is the total of the served requests.
+	Accepted uint64 `json:"accepted"`
+	// Rejected is the total of the requests shed because the queue was full.
+	Rejected uint64 `json:"rejected"`
+	// TimedOut is the total of the requests shed because they waited for too long.
+	TimedOut uint64 `json:"timedOut"`
+}
+
+// Bulkhead is the concurrency limiting middleware, see `New`.
+//
+// Usage:
+//
+//     b := bulkhead.New(bulkhead.Options{
+//         MaxConcurrent: 50,
+//         MaxQueue:      100,
+//         MaxWait:       2 * time.Second,
+//     })
+//     app.Use(b.Serve)
+//     app.Get("/report", slowHandler).SetMeta(bulkhead.LimitMetaKey, 5)
+//     app.Get("/bulkhead", b.Handler)
+//
+// The statistics can be exposed to the metrics middleware too:
+//
+//     m.GaugeFunc("bulkhead_queued", "Number of queued requests.", func() float64 {
+//         return float64(b.Total().Queued)
+//     })
+type Bulkhead struct {
+	opts Options
+
+	mu        sync.RWMutex
+	groups    map[string]*group
+	lastEvict time.Time
+}
+
+// New returns a new `Bulkhead` based on the optional "opts".
+func New(opts ...Options) *Bulkhead {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.MaxConcurrent <= 0 {
+		o.MaxConcurrent = 100
+	}
+
+	if o.MaxWait <= 0 {
+		o.MaxWait = time.Second
+	}
+
+	if o.Key != nil {
+		if o.IdleTimeout == 0 {
+			o.IdleTimeout = 5 * time.Minute
+		}
+	} else {
+		if o.IdleTimeout == 0 {
+			o.IdleTimeout = -1
+		}
+
+		o.Key = func(ctx context.Context) string {
+			if r := ctx.GetCurrentRoute(); r != nil {
+				return r.Name()
+			}
+
+			return ""
+		}
+	}
+
+	if o.NewLimit == nil {
+		o.NewLimit = func(string) Limit {
+			return FixedLimit(o.MaxConcurrent)
+		}
+	}
+
+	if o.RetryAfter <= 0 {
+		o.RetryAfter = time.Second
+	}
+
+	if o.ShedHandler == nil {
+		o.ShedHandler = func(ctx context.Context, err error) {
+			ctx.StopWithError(http.StatusServiceUnavailable, err)
+		}
+	}
+
+	return &Bulkhead{
+		opts:      o,
+		groups:    make(map[string]*group),
+		lastEvict: time.Now(),
+	}
+}
+
+// Serve is the middleware which limits the in-flight requests of their group.
+func (b *Bulkhead) Serve(ctx context.Context) {
+	g := b.group(ctx)
+	inFlight, err := g.acquire(ctx, b.opts.MaxQueue, b.opts.MaxWait)
+	if err != nil {
+		if err != ErrQueueFull && err != ErrQueueTimeout {
+			// the client went away.
+			ctx.StopExecution()
+			return
+		}
+
+		ctx.Header("Retry-After", strconv.FormatInt(int64((b.opts.RetryAfter+time.Second-1)/time.Second), 10))
+		b.opts.ShedHandler(ctx, err)
+		return
+	}
+
+	var (
+		start     = time.Now()
+		completed bool
+	)
+
+	defer func() {
+		// a panic is considered a drop.
+		g.release(time.Since(start), inFlight, !completed || ctx.GetStatusCode() >= http.StatusInternalServerError)
+	}()
+
+	ctx.Next()
+	completed = true
+}
+
+func (b *Bulkhead) group(ctx context.Context) *group {
+	key := b.opts.Key(ctx)
+
+	b.mu.RLock()
+	g, ok := b.groups[key]
+	b.mu.RUnlock()
+	if ok {
+		return g
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if g, ok = b.groups[key]; ok {
+		return g
+	}
+
+	b.evictIdle()
+
+	var limit Limit
+	if r := ctx.GetCurrentRoute(); r != nil {
+		if n, ok := r.Meta().Get(LimitMetaKey).(int); ok && n > 0 {
+			limit = FixedLimit(n)
+		}
+	}
+	if limit == nil {
+		limit = b.opts.NewLimit(key)
+	}
+
+	g = &group{key: key, limit: limit, lastSeen: time.Now()}
+	b.groups[key] = g
+	return g
+}
+
+// evictIdle removes the idle groups, at most once per `Options.IdleTimeout`.
+// The caller should hold the lock.
+func (b *Bulkhead) evictIdle() {
+	if b.opts.IdleTimeout < 0 {
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(b.lastEvict) < b.opts.IdleTimeout {
+		return
+	}
+	b.lastEvict = now
+
+	for key, g := range b.groups {
+		if g.idle(now, b.opts.IdleTimeout) {
+			delete(b.groups, key)
+		}
+	}
+}
+
+// Stats returns the statistics of the groups, sorted by their key.
+func (b *Bulkhead) Stats() []Stats {
+	b.mu.RLock()
+	stats := make([]Stats, 0, len(b.groups))
+	for _, g := range b.groups {
+		stats = append(stats, g.stats())
+	}
+	b.mu.RUnlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Key < stats[j].Key
+	})
+	return stats
+}
+
+// Total returns the sum of the groups' statistics, with an empty key.
+func (b *Bulkhead) Total() Stats {
+	var total Stats
+	for _, s := range b.Stats() {
+		total.Limit += s.Limit
+		total.InFlight += s.InFlight
+		total.Queued += s.Queued
+		total.Accepted += s.Accepted
+		total.Rejected += s.Rejected
+		total.TimedOut += s.TimedOut
+	}
+
+	return total
+}
+
+// Handler writes the groups' statistics as JSON.
+func (b *Bulkhead) Handler(ctx context.Context) {
+	ctx.JSON(b.Stats())
+}
+
+type group struct {
+	key string
+
+	mu       sync.Mutex
+	limit    Limit
+	inFlight int
+	queue    list.List // of chan int.
+	lastSeen time.Time
+
+	accepted, rejected, timedOut uint64
+}
+
+// acquire waits for a slot and returns the in-flight requests, including this one.
+func (g *group) acquire(ctx context.Context, maxQueue int, maxWait time.Duration) (int, error) {
+	g.mu.Lock()
+	g.lastSeen = time.Now()
+	if g.inFlight < g.limit.Current() && g.queue.Len() == 0 {
+		g.inFlight++
+		g.accepted++
+		inFlight := g.inFlight
+		g.mu.Unlock()
+		return inFlight, nil
+	}
+
+	if g.queue.Len() >= maxQueue {
+		g.rejected++
+		g.mu.Unlock()
+		return 0, ErrQueueFull
+	}
+
+	ready := make(chan int, 1)
+	elem := g.queue.PushBack(ready)
+	g.mu.Unlock()
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case inFlight := <-ready:
+		return inFlight, nil
+	case <-timer.C:
+		err = ErrQueueTimeout
+	case <-ctx.Request().Context().Done():
+		err = ctx.Request().Context().Err()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	select {
+	case inFlight := <-ready: // granted meanwhile.
+		return inFlight, nil
+	default:
+	}
+
+	g.queue.Remove(elem)
+	if err == ErrQueueTimeout {
+		g.timedOut++
+	}
+
+	return 0, err
+}
+
+func (g *group) release(rtt time.Duration, inFlight int, dropped bool) {
+	g.mu.Lock()
+	g.lastSeen = time.Now()
+	g.inFlight--
+	g.limit.Update(rtt, inFlight, dropped)
+
+	for g.inFlight < g.limit.Current() && g.queue.Len() > 0 {
+		ready := g.queue.Remove(g.queue.Front()).(chan int)
+		g.inFlight++
+		g.accepted++
+		ready <- g.inFlight
+	}
+	g.mu.Unlock()
+}
+
+// idle reports whether the group has no in-flight and queued requests
+// since the "timeout".
+func (g *group) idle(now time.Time, timeout time.Duration) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.inFlight == 0 && g.queue.Len() == 0 && now.Sub(g.lastSeen) >= timeout
+}
+
+func (g *group) stats() Stats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return Stats{
+		Key:      g.key,
+		Limit:    g.limit.Current(),
+		InFlight: g.inFlight,
+		Queued:   g.queue.Len(),
+		Accepted: g.accepted,
+		Rejected: g.rejected,
+		TimedOut: g.timedOut,
+	}
+}
@@ -0,0 +1,185 @@
+package bulkhead_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/bulkhead"
+)
+
+func TestBulkhead(t *testing.T) {
+	b := bulkhead.New(bulkhead.Options{
+		MaxConcurrent: 1,
+		MaxQueue:      1,
+		MaxWait:       100 * time.Millisecond,
+		RetryAfter:    1500 * time.Millisecond,
+	})
+
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+
+	app := iris.New()
+	app.Use(b.Serve)
+	app.Get("/slow", func(ctx iris.Context) {
+		started <- struct{}{}
+		<-release
+	})
+	app.Get("/fast", func(ctx iris.Context) {}).SetMeta(bulkhead.LimitMetaKey, 2)
+
+	e := httptest.New(t, app)
+
+	codes := make(chan int, 2)
+	get := func() {
+		codes <- e.GET("/slow").Expect().Raw().StatusCode
+	}
+
+	go get()
+	<-started
+	waitQueued := func() {
+		for deadline := time.Now().Add(time.Second); b.Total().Queued != 1; {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected a queued request")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// the queued one times out.
+	go get()
+	waitQueued()
+	if code := <-codes; code != iris.StatusServiceUnavailable {
+		t.Fatalf("expected the queued request to time out but got: %d", code)
+	}
+
+	// the queued one is served after the first.
+	go get()
+	waitQueued()
+	e.GET("/slow").Expect().Status(httptest.StatusServiceUnavailable).Header("Retry-After").Equal("2")
+	// another group.
+	e.GET("/fast").Expect().Status(httptest.StatusOK)
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if code := <-codes; code != iris.StatusOK {
+			t.Fatalf("expected status ok but got: %d", code)
+		}
+	}
+
+	total := b.Total()
+	if total.Accepted != 3 || total.Rejected != 1 || total.TimedOut != 1 || total.InFlight != 0 || total.Queued != 0 {
+		t.Fatalf("unexpected stats: %#+v", total)
+	}
+
+	groups := b.Stats()
+	if len(groups) != 2 || groups[0].Key != "GET/fast" || groups[0].Limit != 2 || groups[1].Limit != 1 {
+		t.Fatalf("unexpected groups: %#+v", groups)
+	}
+}
+
+func TestAIMDLimit(t *testing.T) {
+	l := bulkhead.NewAIMDLimit(10)
+
+	// not used enough.
+	l.Update(time.Millisecond, 2, false)
+	if expected, got := 10, l.Current(); expected != got {
+		t.Fatalf("expected limit: %d but got: %d", expected, got)
+	}
+
+	l.Update(time.Millisecond, 5, false)
+	if expected, got := 11, l.Current(); expected != got {
+		t.Fatalf("expected limit: %d but got: %d", expected, got)
+	}
+
+	l.Update(time.Millisecond, 5, true)
+	if expected, got := 9, l.Current(); expected != got {
+		t.Fatalf("expected limit: %d but got: %d", expected, got)
+	}
+
+	l.Update(10*time.Second, 5, false)
+	if expected, got := 8, l.Current(); expected != got {
+		t.Fatalf("expected limit: %d but got: %d", expected, got)
+	}
+}
+
+func TestGradientLimit(t *testing.T) {
+	l := bulkhead.NewGradientLimit(20)
+
+	for i := 0; i < 50; i++ {
+		l.Update(10*time.Millisecond, l.Current(), false)
+	}
+	grown := l.Current()
+	if grown <= 20 {
+		t.Fatalf("expected the limit to grow on a steady latency but got: %d", grown)
+	}
+
+	// a slow upstream.
+	for i := 0; i < 50; i++ {
+		l.Update(100*time.Millisecond, l.Current(), false)
+	}
+	if got := l.Current(); got >= grown {
+		t.Fatalf("expected the limit to shrink on a growing latency but got: %d (was %d)", got, grown)
+	}
+}
+
+func TestBulkheadIdleTimeout(t *testing.T) {
+	b := bulkhead.New(bulkhead.Options{
+		Key: func(ctx iris.Context) string {
+			return ctx.URLParam("client")
+		},
+		IdleTimeout: 50 * time.Millisecond,
+	})
+
+	app := iris.New()
+	app.Use(b.Serve)
+	app.Get("/", func(ctx iris.Context) {})
+
+	e := httptest.New(t, app)
+	e.GET("/").WithQuery("client", "a").Expect().Status(httptest.StatusOK)
+	e.GET("/").WithQuery("client", "b").Expect().Status(httptest.StatusOK)
+	if expected, got := 2, len(b.Stats()); expected != got {
+		t.Fatalf("expected groups: %d but got: %d", expected, got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	e.GET("/").WithQuery("client", "c").Expect().Status(httptest.StatusOK)
+
+	groups := b.Stats()
+	if len(groups) != 1 || groups[0].Key != "c" {
+		t.Fatalf("expected the idle groups to be removed but got: %#+v", groups)
+	}
+}
+
+func TestLimitDefaults(t *testing.T) {
+	aimd := &bulkhead.AIMDLimit{Max: 50}
+	for i := 0; i < 100; i++ {
+		aimd.Update(time.Millisecond, aimd.Current(), true)
+	}
+	if expected, got := 1, aimd.Current(); expected != got {
+		t.Fatalf("expected limit: %d but got: %d", expected, got)
+	}
+
+	for i := 0; i < 100; i++ {
+		aimd.Update(time.Millisecond, aimd.Current(), false)
+	}
+	if expected, got := 50, aimd.Current(); expected != got {
+		t.Fatalf("expected limit: %d but got: %d", expected, got)
+	}
+
+	gradient := new(bulkhead.GradientLimit)
+	for i := 0; i < 100; i++ {
+		gradient.Update(10*time.Millisecond, gradient.Current(), true)
+	}
+	dropped := gradient.Current()
+	if dropped < 1 {
+		t.Fatalf("expected a positive limit but got: %d", dropped)
+	}
+
+	for i := 0; i < 100; i++ {
+		gradient.Update(10*time.Millisecond, gradient.Current(), false)
+	}
+	if got := gradient.Current(); got <= dropped {
+		t.Fatalf("expected the limit to grow but got: %d (was %d)", got, dropped)
+	}
+}
@@ -0,0 +1,243 @@
+package bulkhead
+
+import (
+	"math"
+	"time"
+)
+
+// Limit is the concurrency limit of a group of requests (see `Options.Key`).
+// The calls are serialized by the group, implementations do not need to be safe for concurrent use.
+type Limit interface {
+	// Current returns the maximum number of in-flight requests.
+	Current() int
+	// Update records a completed request: its latency ("rtt"), the in-flight requests
+	// when it started and whether it was dropped (a server error response).
+	Update(rtt time.Duration, inFlight int, dropped bool)
+}
+
+// FixedLimit is a `Limit` which never changes.
+type FixedLimit int
+
+// Current returns the limit.
+func (l FixedLimit) Current() int { return int(l) }
+
+// Update does nothing.
+func (l FixedLimit) Update(time.Duration, int, bool) {}
+
+// AIMDLimit is an additive-increase/multiplicative-decrease adaptive `Limit`.
+// The limit is increased by one on successful requests which used at least half of it
+// and it is multiplied by the `Backoff` on dropped or slower than the `Timeout` requests.
+// The zero fields are set to their defaults on first use and a limit
+// which is not created through `NewAIMDLimit` starts at its Min.
+type AIMDLimit struct {
+	// Min is the minimum limit.
+	//
+	// Defaults to 1.
+	Min int
+	// Max is the maximum limit.
+	//
+	// Defaults to 1000.
+	Max int
+	// Backoff is the decrease ratio, between 0.5 and 1.
+	//
+	// Defaults to 0.9.
+	Backoff float64
+	// Timeout is the latency which is considered a drop.
+	//
+	// Defaults to 5 seconds.
+	Timeout time.Duration
+
+	limit int
+}
+
+// NewAIMDLimit returns a new `AIMDLimit` starting at "initial" in-flight requests
+// with the default Min, Max, Backoff and Timeout, they can be modified before its first use.
+func NewAIMDLimit(initial int) *AIMDLimit {
+	return &AIMDLimit{
+		Min:     1,
+		Max:     1000,
+		Backoff: 0.9,
+		Timeout: 5 * time.Second,
+		limit:   initial,
+	}
+}
+
+func (l *AIMDLimit) defaults() {
+	if l.Min <= 0 {
+		l.Min = 1
+	}
+
+	if l.Max <= 0 {
+		l.Max = 1000
+	}
+
+	if l.Backoff < 0.5 || l.Backoff >= 1 {
+		l.Backoff = 0.9
+	}
+
+	if l.Timeout <= 0 {
+		l.Timeout = 5 * time.Second
+	}
+
+	if l.limit <= 0 {
+		l.limit = l.Min
+	}
+}
+
+// Current returns the current limit.
+func (l *AIMDLimit) Current() int {
+	l.defaults()
+	return l.limit
+}
+
+// Update increases or decreases the limit based on the request's outcome.
+func (l *AIMDLimit) Update(rtt time.Duration, inFlight int, dropped bool) {
+	l.defaults()
+
+	switch {
+	case dropped || rtt > l.Timeout:
+		l.limit = int(float64(l.limit) * l.Backoff)
+	case inFlight*2 >= l.limit:
+		l.limit++
+	}
+
+	l.limit = clamp(l.limit, l.Min, l.Max)
+}
+
+// GradientLimit is a latency based adaptive `Limit`. It compares a long-term (no load)
+// average latency with the short-term one and scales the limit by their ratio (the gradient),
+// so the limit is decreased while the latency grows because of queuing, e.g. on a slow upstream,
+// and increased, by a queue size of the square root of the limit, while the latency is steady.
+// The zero fields are set to their defaults on first use and a limit
+// which is not created through `NewGradientLimit` starts at its Min.
+type GradientLimit struct {
+	// Min is the minimum limit.
+	//
+	// Defaults to 1.
+	Min int
+	// Max is the maximum limit.
+	//
+	// Defaults to 1000.
+	Max int
+	// Tolerance is the ratio the short-term latency may exceed the long-term one
+	// before the limit is decreased, it should be at least 1.
+	//
+	// Defaults to 1.5.
+	Tolerance float64
+	// Smoothing is the weight of a new limit, between 0 and 1.
+	//
+	// Defaults to 0.2.
+	Smoothing float64
+	// LongWindow is the number of samples of the long-term latency average.
+	//
+	// Defaults to 600.
+	LongWindow int
+
+	limit    float64
+	shortRTT float64
+	longRTT  float64
+	samples  int
+}
+
+// shortRTTWeight is the weight of a sample on the short-term latency average.
+const shortRTTWeight = 0.1
+
+// NewGradientLimit returns a new `GradientLimit` starting at "initial" in-flight requests
+// with the default options, they can be modified before its first use.
+func NewGradientLimit(initial int) *GradientLimit {
+	return &GradientLimit{
+		Min:        1,
+		Max:        1000,
+		Tolerance:  1.5,
+		Smoothing:  0.2,
+		LongWindow: 600,
+		limit:      float64(initial),
+	}
+}
+
+func (l *GradientLimit) defaults() {
+	if l.Min <= 0 {
+		l.Min = 1
+	}
+
+	if l.Max <= 0 {
+		l.Max = 1000
+	}
+
+	if l.Tolerance < 1 {
+		l.Tolerance = 1.5
+	}
+
+	if l.Smoothing <= 0 || l.Smoothing > 1 {
+		l.Smoothing = 0.2
+	}
+
+	if l.LongWindow <= 0 {
+		l.LongWindow = 600
+	}
+
+	if l.limit < float64(l.Min) {
+		l.limit = float64(l.Min)
+	}
+}
+
+// Current returns the current limit.
+func (l *GradientLimit) Current() int {
+	l.defaults()
+	return int(l.limit)
+}
+
+// Update recalculates the limit based on the request's latency.
+func (l *GradientLimit) Update(rtt time.Duration, inFlight int, dropped bool) {
+	l.defaults()
+
+	sample := float64(rtt)
+	if l.samples == 0 {
+		l.shortRTT, l.longRTT = sample, sample
+	} else {
+		l.shortRTT += (sample - l.shortRTT) * shortRTTWeight
+		n := l.samples + 1
+		if n > l.LongWindow {
+			n = l.LongWindow
+		}
+		l.longRTT += (sample - l.longRTT) / float64(n)
+	}
+	l.samples++
+
+	// do not grow the limit while it is not used.
+	if !dropped && float64(inFlight)*2 < l.limit {
+		return
+	}
+
+	gradient := 1.0
+	if dropped {
+		gradient = 0.5
+	} else if l.shortRTT > 0 {
+		// the latency dropped a lot, e.g. the upstream recovered, reset the long-term average.
+		if l.longRTT/l.shortRTT > 2 {
+			l.longRTT = l.shortRTT
+		}
+
+		gradient = math.Max(0.5, math.Min(1, l.Tolerance*l.longRTT/l.shortRTT))
+	}
+
+	newLimit := l.limit*gradient + math.Sqrt(l.limit)
+	l.limit = l.limit*(1-l.Smoothing) + newLimit*l.Smoothing
+	if l.limit < float64(l.Min) {
+		l.limit = float64(l.Min)
+	} else if l.limit > float64(l.Max) {
+		l.limit = float64(l.Max)
+	}
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+
+	if v > max {
+		return max
+	}
+
+	return v
+}
@@ -0,0 +1,373 @@
+// Package csrf provides a Cross-Site Request Forgery protection middleware.
+// The token is stored either to the request's `sessions.Session` (synchronizer token)
+// or to a signed cookie through a `context.SecureCookie` (double-submit cookie),
+// it is validated on unsafe methods from a request header or form field,
+// after the Origin or Referer headers are checked against the request's host.
+// The "csrf_field" and "csrf_token" template functions are registered
+// through `view.RegisterContextFunc`.
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/sessions"
+	"github.com/kataras/iris/v12/view"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/csrf.*", "iris.csrf")
+
+	// {{ csrf_field }} and {{ csrf_token }}
+	view.RegisterContextFunc("csrf_field", func(ctx context.Context) interface{} {
+		return func() template.HTML { return TemplateField(ctx) }
+	})
+	view.RegisterContextFunc("csrf_token", func(ctx context.Context) interface{} {
+		return func() string { return Token(ctx) }
+	})
+}
+
+const (
+	// ExemptTag is the route tag which disables the validation of a route, e.g.
+	// app.Post("/webhook", handler).Tag(csrf.ExemptTag).
+	ExemptTag = "csrf.exempt"
+	// ViewDataTokenKey is the view data key of the masked token.
+	ViewDataTokenKey = "csrf_token"
+	// ViewDataFieldKey is the view data key of the hidden input field.
+	ViewDataFieldKey = "csrf_field"
+
+	tokenLength      = 32
+	tokenContextKey  = "iris.csrf.token"
+	exemptContextKey = "iris.csrf.exempt"
+)
+
+var (
+	// ErrNoSession is fired when the session mode is used but no session was started.
+	ErrNoSession = errors.New("csrf: no session, register the sessions middleware first")
+	// ErrBadOrigin is fired when the Origin header does not match the request's origin or a trusted one.
+	ErrBadOrigin = errors.New("csrf: origin invalid")
+	// ErrNoReferer is fired when a secure request has no Referer header.
+	ErrNoReferer = errors.New("csrf: referer not supplied")
+	// ErrBadReferer is fired when the Referer header does not match the request's origin or a trusted one.
+	ErrBadReferer = errors.New("csrf: referer invalid")
+	// ErrBadToken is fired when the submitted token is missing or does not match.
+	ErrBadToken = errors.New("csrf: token invalid")
+)
+
+// Options holds the options for the `CSRF` middleware.
+type Options struct {
+	// SecureCookie, if not nil, enables the double-submit cookie mode:
+	// the token is stored to a cookie encoded (signed) through it.
+	// Otherwise the token is stored to the request's session,
+	// see `sessions.Sessions.Handler`.
+	//
+	// Defaults to nil, session mode.
+	SecureCookie context.SecureCookie
+	// CookieName is the name of the token cookie, on double-submit cookie mode.
+	//
+	// Defaults to "_csrf".
+	CookieName string
+	// SessionKey is the session key of the token, on session mode.
+	//
+	// Defaults to "_csrf".
+	SessionKey string
+	// HeaderName is the request header which the token is read from.
+	//
+	// Defaults to "X-CSRF-Token".
+	HeaderName string
+	// FieldName is the form field which the token is read from,
+	// if the header is missing, and the name of the `TemplateField`.
+	//
+	// Defaults to "csrf_token".
+	FieldName string
+	// TrustedOrigins are extra origins (scheme://host[:port]) allowed to send unsafe requests,
+	// e.g. "https://app.example.com".
+	// The origins of the request's host (see `Context.Host`) are always allowed,
+	// whatever their scheme, so a TLS-terminating proxy does not need any configuration.
+	TrustedOrigins []string
+	// Skippers are checked on unsafe methods, if one returns true then the request is not validated.
+	// See the `ExemptTag` and `Exempt` to exempt specific routes.
+	Skippers []func(ctx context.Context) bool
+	// ErrorHandler is fired on invalid requests.
+	//
+	// Defaults to a 403 Forbidden status code.
+	ErrorHandler func(ctx context.Context, err error)
+}
+
+// CSRF is the Cross-Site Request Forgery protection middleware, see `New`.
+type CSRF struct {
+	opts           Options
+	trustedOrigins map[string]struct{}
+}
+
+// New returns a new `CSRF` middleware based on the optional "opts".
+//
+// Usage:
+//
+//     sess := sessions.New(sessions.Config{Cookie: "session"})
+//     app.Use(sess.Handler())
+//     app.Use(csrf.New().Filter)
+//     app.Post("/webhook", handler).Tag(csrf.ExemptTag)
+//
+// Or, without sessions:
+//
+//     s := securecookie.New(hashKey, blockKey)
+//     app.Use(csrf.New(csrf.Options{SecureCookie: s}).Filter)
+//
+// And in a template:
+//
+//     <form method="POST">{{ csrf_field }}</form>
+func New(opts ...Options) *CSRF {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.CookieName == "" {
+		o.CookieName = "_csrf"
+	}
+
+	if o.SessionKey == "" {
+		o.SessionKey = "_csrf"
+	}
+
+	if o.HeaderName == "" {
+		o.HeaderName = "X-CSRF-Token"
+	}
+
+	if o.FieldName == "" {
+		o.FieldName = "csrf_token"
+	}
+
+	if o.ErrorHandler == nil {
+		o.ErrorHandler = func(ctx context.Context, err error) {
+			ctx.StopWithError(http.StatusForbidden, err)
+		}
+	}
+
+	c := &CSRF{opts: o, trustedOrigins: make(map[string]struct{}, len(o.TrustedOrigins))}
+	for _, origin := range o.TrustedOrigins {
+		c.trustedOrigins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = struct{}{}
+	}
+
+	return c
+}
+
+// Exempt is a middleware which disables the validation of the next handlers,
+// it should run before the `Filter`, e.g. app.Post("/webhook", csrf.Exempt, csrf.New().Filter, handler).
+// See `ExemptTag` and `Options.Skippers` too.
+func Exempt(ctx context.Context) {
+	ctx.Values().Set(exemptContextKey, true)
+	ctx.Next()
+}
+
+// Filter is the middleware which generates the token, if missing, makes it available
+// to the handlers and views (see `Token`, `TemplateField`, `ViewDataTokenKey` and `ViewDataFieldKey`)
+// and validates the unsafe (non GET, HEAD, OPTIONS and TRACE) requests.
+func (c *CSRF) Filter(ctx context.Context) {
+	realToken, err := c.getOrCreateToken(ctx)
+	if err != nil {
+		c.opts.ErrorHandler(ctx, err)
+		return
+	}
+
+	masked := mask(realToken)
+	ctx.Values().Set(tokenContextKey, masked)
+	ctx.ViewData(ViewDataTokenKey, masked)
+	ctx.ViewData(ViewDataFieldKey, field(c.opts.FieldName, masked))
+	ctx.ResponseWriter().Header().Add("Vary", "Cookie")
+
+	if isSafeMethod(ctx.Method()) || c.exempt(ctx) {
+		ctx.Next()
+		return
+	}
+
+	if err = c.checkOrigin(ctx); err != nil {
+		c.opts.ErrorHandler(ctx, err)
+		return
+	}
+
+	submitted := ctx.GetHeader(c.opts.HeaderName)
+	if submitted == "" {
+		submitted = ctx.FormValue(c.opts.FieldName)
+	}
+
+	if !validToken(realToken, submitted) {
+		c.opts.ErrorHandler(ctx, ErrBadToken)
+		return
+	}
+
+	ctx.Next()
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *CSRF) exempt(ctx context.Context) bool {
+	if ctx.Values().GetBoolDefault(exemptContextKey, false) {
+		return true
+	}
+
+	if r := ctx.GetCurrentRoute(); r != nil && r.HasTag(ExemptTag) {
+		return true
+	}
+
+	for _, skip := range c.opts.Skippers {
+		if skip(ctx) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *CSRF) getOrCreateToken(ctx context.Context) ([]byte, error) {
+	var (
+		sess    *sessions.Session
+		encoded string
+	)
+
+	if c.opts.SecureCookie != nil {
+		encoded = ctx.GetCookie(c.opts.CookieName, context.CookieEncoding(c.opts.SecureCookie))
+	} else {
+		if sess = sessions.Get(ctx); sess == nil {
+			return nil, ErrNoSession
+		}
+		encoded = sess.GetString(c.opts.SessionKey)
+	}
+
+	if token, err := base64.RawURLEncoding.DecodeString(encoded); err == nil && len(token) == tokenLength {
+		return token, nil
+	}
+
+	token := make([]byte, tokenLength)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	encoded = base64.RawURLEncoding.EncodeToString(token)
+
+	if sess != nil {
+		sess.Set(c.opts.SessionKey, encoded)
+	} else {
+		ctx.SetCookie(&http.Cookie{
+			Name:     c.opts.CookieName,
+			Value:    encoded,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   ctx.IsSSL(),
+			SameSite: http.SameSiteLaxMode,
+		}, context.CookieEncoding(c.opts.SecureCookie))
+	}
+
+	return token, nil
+}
+
+// checkOrigin verifies that the Origin header, if present, or the Referer, on secure requests,
+// matches the request's host or a trusted origin.
+// The scheme is not compared to the request's one, which is unknown behind a TLS-terminating proxy.
+func (c *CSRF) checkOrigin(ctx context.Context) error {
+	r := ctx.Request()
+	host := strings.ToLower(ctx.Host())
+
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || !c.allowedOrigin(host, u) {
+			return ErrBadOrigin
+		}
+		return nil
+	}
+
+	if !ctx.IsSSL() {
+		// plain HTTP: the Referer is not reliable (e.g. stripped by proxies).
+		return nil
+	}
+
+	referer := r.Referer()
+	if referer == "" {
+		return ErrNoReferer
+	}
+
+	u, err := url.Parse(referer)
+	if err != nil || !c.allowedOrigin(host, u) {
+		return ErrBadReferer
+	}
+
+	return nil
+}
+
+func (c *CSRF) allowedOrigin(host string, u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+
+	if strings.ToLower(u.Host) == host {
+		return true
+	}
+
+	_, ok := c.trustedOrigins[strings.ToLower(u.Scheme+"://"+u.Host)]
+	return ok
+}
+
+// mask returns a new one-time-pad encrypted form of the token per request,
+// so the token in the responses differs on each request (BREACH mitigation).
+func mask(token []byte) string {
+	b := make([]byte, 2*tokenLength)
+	pad := b[:tokenLength]
+	if _, err := rand.Read(pad); err != nil {
+		panic("csrf: " + err.Error())
+	}
+
+	for i := range token {
+		b[tokenLength+i] = pad[i] ^ token[i]
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func validToken(realToken []byte, submitted string) bool {
+	b, err := base64.RawURLEncoding.DecodeString(submitted)
+	if err != nil || len(b) != 2*tokenLength {
+		return false
+	}
+
+	token := make([]byte, tokenLength)
+	for i := range token {
+		token[i] = b[i] ^ b[tokenLength+i]
+	}
+
+	return subtle.ConstantTimeCompare(token, realToken) == 1
+}
+
+// Token returns the masked token of the current request,
+// e.g. to send it to a client as the value of the "X-CSRF-Token" header.
+// It returns empty if the `Filter` middleware did not run.
+func Token(ctx context.Context) string {
+	return ctx.Values().GetString(tokenContextKey)
+}
+
+// TemplateField returns a hidden input field which contains the masked token of the current request.
+func TemplateField(ctx context.Context) template.HTML {
+	if v, ok := ctx.GetViewData()[ViewDataFieldKey].(template.HTML); ok {
+		return v
+	}
+
+	return ""
+}
+
+func field(name, token string) template.HTML {
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(name) +
+		`" value="` + token + `">`)
+}
@@ -0,0 +1,163 @@
+package csrf_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/csrf"
+	"github.com/kataras/iris/v12/sessions"
+)
+
+// signer is a minimal `context.SecureCookie` which signs the values with HMAC-SHA256.
+type signer struct{ key []byte }
+
+func (s signer) sign(name, value string) string {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(name + "|" + value))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (s signer) Encode(name string, value interface{}) (string, error) {
+	v := value.(string)
+	return v + "." + s.sign(name, v), nil
+}
+
+func (s signer) Decode(name string, encoded string, valuePtr interface{}) error {
+	idx := strings.LastIndexByte(encoded, '.')
+	if idx == -1 || !hmac.Equal([]byte(encoded[idx+1:]), []byte(s.sign(name, encoded[:idx]))) {
+		return errors.New("invalid signature")
+	}
+
+	*valuePtr.(*string) = encoded[:idx]
+	return nil
+}
+
+func newApp(opts csrf.Options) *iris.Application {
+	app := iris.New()
+	if opts.SecureCookie == nil {
+		app.Use(sessions.New(sessions.Config{Cookie: "session"}).Handler())
+	}
+	app.Use(csrf.New(opts).Filter)
+
+	app.Get("/token", func(ctx iris.Context) {
+		ctx.WriteString(csrf.Token(ctx))
+	})
+	app.Post("/submit", func(ctx iris.Context) {
+		ctx.WriteString("ok")
+	})
+	app.Post("/webhook", func(ctx iris.Context) {
+		ctx.WriteString("exempt")
+	}).Tag(csrf.ExemptTag)
+
+	return app
+}
+
+func testCSRF(t *testing.T, opts csrf.Options) {
+	app := newApp(opts)
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+
+	token := e.GET("/token").Expect().Status(httptest.StatusOK).Body().NotEmpty().Raw()
+	// masked differently on each request, but valid.
+	token2 := e.GET("/token").Expect().Status(httptest.StatusOK).Body().Raw()
+	if token == token2 {
+		t.Fatalf("expected a different masked token per request")
+	}
+
+	e.POST("/submit").Expect().Status(httptest.StatusForbidden)
+	e.POST("/submit").WithHeader("X-CSRF-Token", "invalid").Expect().Status(httptest.StatusForbidden)
+	e.POST("/submit").WithHeader("X-CSRF-Token", token).Expect().Status(httptest.StatusOK).Body().Equal("ok")
+	e.POST("/submit").WithFormField("csrf_token", token2).Expect().Status(httptest.StatusOK)
+	e.POST("/webhook").Expect().Status(httptest.StatusOK).Body().Equal("exempt")
+
+	// origin checks.
+	e.POST("/submit").WithHeader("X-CSRF-Token", token).WithHeader("Origin", "http://evil.com").
+		Expect().Status(httptest.StatusForbidden)
+	e.POST("/submit").WithHeader("X-CSRF-Token", token).WithHeader("Origin", "http://example.com").
+		Expect().Status(httptest.StatusOK)
+	// the request's host served behind a TLS-terminating proxy.
+	e.POST("/submit").WithHeader("X-CSRF-Token", token).WithHeader("Origin", "https://example.com").
+		Expect().Status(httptest.StatusOK)
+	e.POST("/submit").WithHeader("X-CSRF-Token", token).WithHeader("Origin", "https://example.com.evil.com").
+		Expect().Status(httptest.StatusForbidden)
+	e.POST("/submit").WithHeader("X-CSRF-Token", token).WithHeader("Origin", "https://trusted.com").
+		Expect().Status(httptest.StatusOK)
+
+	// a token of another client (cookie jar) is not valid.
+	other := httptest.New(t, app, httptest.URL("http://example.com"))
+	other.POST("/submit").WithHeader("X-CSRF-Token", token).Expect().Status(httptest.StatusForbidden)
+}
+
+func TestCSRFSessions(t *testing.T) {
+	testCSRF(t, csrf.Options{TrustedOrigins: []string{"https://trusted.com/"}})
+}
+
+func TestCSRFCookie(t *testing.T) {
+	testCSRF(t, csrf.Options{
+		SecureCookie:   signer{key: []byte("secret")},
+		TrustedOrigins: []string{"https://trusted.com"},
+	})
+}
+
+func TestCSRFNoSession(t *testing.T) {
+	app := iris.New()
+	app.Use(csrf.New().Filter)
+	app.Get("/", func(ctx iris.Context) {})
+
+	e := httptest.New(t, app)
+	e.GET("/").Expect().Status(httptest.StatusForbidden)
+}
+
+func TestCSRFView(t *testing.T) {
+	app := iris.New()
+	app.RegisterView(iris.HTML("./views", ".html").Binary(func(string) ([]byte, error) {
+		return []byte(`<form>{{ csrf_field }}</form><p>{{ csrf_token }}</p><p>{{ .Title }}</p>`), nil
+	}, func() []string { return []string{"views/form.html"} }))
+
+	app.Use(csrf.New(csrf.Options{SecureCookie: signer{key: []byte("secret")}}).Filter)
+	app.Get("/", func(ctx iris.Context) {
+		// a custom view model.
+		ctx.View("form.html", struct{ Title string }{"Form"})
+	})
+	app.Post("/", func(ctx iris.Context) {
+		ctx.WriteString("ok")
+	})
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+	body := e.GET("/").Expect().Status(httptest.StatusOK).Body().Raw()
+
+	matches := regexp.MustCompile(`^<form><input type="hidden" name="csrf_token" value="([\w-]+)"></form><p>([\w-]+)</p><p>Form</p>$`).FindStringSubmatch(body)
+	if len(matches) != 3 || matches[1] != matches[2] {
+		t.Fatalf("unexpected view: %s", body)
+	}
+
+	e.POST("/").WithFormField("csrf_token", matches[1]).Expect().Status(httptest.StatusOK).Body().Equal("ok")
+	// the token of each request is bound to its own render.
+	if other := e.GET("/").Expect().Status(httptest.StatusOK).Body().Raw(); other == body {
+		t.Fatalf("expected a different masked token per render")
+	}
+
+	// concurrent clients render their own tokens.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			client := httptest.New(t, app, httptest.URL("http://example.com"))
+			for j := 0; j < 10; j++ {
+				body := client.GET("/").Expect().Status(httptest.StatusOK).Body().Raw()
+				token := regexp.MustCompile(`value="([\w-]+)"`).FindStringSubmatch(body)[1]
+				client.POST("/").WithFormField("csrf_token", token).Expect().Status(httptest.StatusOK)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,290 @@
+// Package health provides liveness ("/healthz") and readiness ("/readyz") handlers
+// based on registrable checks, the readiness fails as soon as the server starts shutting down,
+// before its listeners are closed, so load balancers stop sending traffic to it.
+package health
+
+import (
+	stdContext "context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/core/host"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/health.*", "iris.health")
+}
+
+// CheckFunc reports the health of a dependency (e.g. a database), a non-nil error means unhealthy.
+// The "ctx" is canceled on the `Checker.Timeout`.
+type CheckFunc func(ctx stdContext.Context) error
+
+// Status values of the `Result` and `CheckResult` structures.
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting down"
+)
+
+// CheckResult is the result of a single check.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Result is the JSON response of the `Liveness` and `Readiness` handlers.
+type Result struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker holds the liveness and readiness checks.
+//
+// Usage:
+//
+//     checker := health.New()
+//     checker.AddReadinessCheck("database", health.Ping(db))
+//     checker.AddReadinessCheck("sessions", health.PingPong(redisDriver))
+//     checker.Attach(app)
+//     app.Get("/healthz", checker.Liveness)
+//     app.Get("/readyz", checker.Readiness)
+//
+// The `Attach` registers the Checker to the application's hosts,
+// their Shutdown reports the server as not ready and waits for the `DrainDelay`
+// before the listeners are closed.
+type Checker struct {
+	// Timeout is the maximum duration of each check.
+	//
+	// Defaults to 5 seconds.
+	Timeout time.Duration
+	// DrainDelay is the duration that the Shutdown of the hosts waits
+	// after the server is reported as not ready and before its listeners are closed,
+	// it should be longer than the period of the load balancer's readiness probes.
+	// The wait ends earlier if the Shutdown's context is done.
+	//
+	// Defaults to zero, no wait.
+	DrainDelay time.Duration
+
+	mu        sync.RWMutex
+	liveness  []namedCheck
+	readiness []namedCheck
+	// the time the drain ends, set by the first Shutdown.
+	drainUntil time.Time
+
+	notReady uint32 // non-zero when shutting down or set manually.
+}
+
+// New returns a new, empty, Checker.
+func New() *Checker {
+	return &Checker{Timeout: 5 * time.Second}
+}
+
+// AddLivenessCheck registers a check of the process itself (e.g. a deadlock detector),
+// it is executed by both `Liveness` and `Readiness` handlers.
+func (c *Checker) AddLivenessCheck(name string, check CheckFunc) {
+	c.mu.Lock()
+	c.liveness = append(c.liveness, namedCheck{name, check})
+	c.mu.Unlock()
+}
+
+// AddReadinessCheck registers a check of a dependency (e.g. a database),
+// it is executed by the `Readiness` handler only.
+func (c *Checker) AddReadinessCheck(name string, check CheckFunc) {
+	c.mu.Lock()
+	c.readiness = append(c.readiness, namedCheck{name, check})
+	c.mu.Unlock()
+}
+
+// SetReady marks the server as ready or not ready to accept traffic,
+// e.g. to take it out of the load balancer manually.
+// The server is ready by default.
+func (c *Checker) SetReady(ready bool) {
+	if ready {
+		atomic.StoreUint32(&c.notReady, 0)
+	} else {
+		atomic.StoreUint32(&c.notReady, 1)
+	}
+}
+
+// IsReady reports whether the server is ready to accept traffic, see `SetReady`.
+func (c *Checker) IsReady() bool {
+	return atomic.LoadUint32(&c.notReady) == 0
+}
+
+// Attach registers the `Drain` on the Shutdown of the Iris application's hosts,
+// the current and the future ones. It should be called before the application runs, e.g.
+// checker.Attach(app).
+func (c *Checker) Attach(app interface {
+	RegisterOnPreShutdown(cb func(ctx stdContext.Context))
+}) {
+	app.RegisterOnPreShutdown(c.Drain)
+}
+
+// ConfigureHost is a `host.Configurator` which registers the `Drain` on the Supervisor's shutdown.
+// Use it for a Supervisor which does not serve an Iris application, otherwise see `Attach`.
+func (c *Checker) ConfigureHost(su *host.Supervisor) {
+	su.RegisterOnPreShutdown(c.Drain)
+}
+
+// Drain marks the server as not ready and waits for the `DrainDelay`
+// or until "ctx" is done. The delay starts on the first call,
+// so the Shutdown of more than one host waits once.
+func (c *Checker) Drain(ctx stdContext.Context) {
+	c.SetReady(false)
+
+	c.mu.Lock()
+	if c.drainUntil.IsZero() {
+		c.drainUntil = time.Now().Add(c.DrainDelay)
+	}
+	wait := time.Until(c.drainUntil)
+	c.mu.Unlock()
+
+	if wait <= 0 {
+		return
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// Check executes the liveness checks, plus the readiness ones if "readiness" is true,
+// concurrently and returns their result.
+func (c *Checker) Check(ctx stdContext.Context, readiness bool) Result {
+	if readiness && !c.IsReady() {
+		return Result{Status: StatusShuttingDown}
+	}
+
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.liveness...)
+	if readiness {
+		checks = append(checks, c.readiness...)
+	}
+	c.mu.RUnlock()
+
+	result := Result{Status: StatusOK}
+	if len(checks) == 0 {
+		return result
+	}
+
+	if c.Timeout > 0 {
+		var cancel stdContext.CancelFunc
+		ctx, cancel = stdContext.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	wg.Add(len(checks))
+	for i := range checks {
+		go func(i int) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, checks[i].check)
+		}(i)
+	}
+	wg.Wait()
+
+	result.Checks = make(map[string]CheckResult, len(checks))
+	for i, check := range checks {
+		result.Checks[check.name] = results[i]
+		if results[i].Status != StatusOK {
+			result.Status = StatusFail
+		}
+	}
+
+	return result
+}
+
+func runCheck(ctx stdContext.Context, check CheckFunc) (result CheckResult) {
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errCh <- errors.New("panic")
+			}
+		}()
+		errCh <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result.Duration = time.Since(start).String()
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	} else {
+		result.Status = StatusOK
+	}
+
+	return
+}
+
+// Liveness is the "/healthz" handler, it responds with the `Result` of the liveness checks
+// and 200 OK or 503 Service Unavailable status code if any of them failed.
+func (c *Checker) Liveness(ctx context.Context) {
+	c.serve(ctx, false)
+}
+
+// Readiness is the "/readyz" handler, it responds with the `Result` of all checks
+// and 200 OK or 503 Service Unavailable status code if any of them failed
+// or the server is shutting down (see `Attach`).
+func (c *Checker) Readiness(ctx context.Context) {
+	c.serve(ctx, true)
+}
+
+func (c *Checker) serve(ctx context.Context, readiness bool) {
+	result := c.Check(ctx.Request().Context(), readiness)
+
+	ctx.Header("Cache-Control", "no-store")
+	if result.Status != StatusOK {
+		ctx.StatusCode(http.StatusServiceUnavailable)
+	}
+
+	ctx.JSON(result)
+}
+
+// Ping returns a check of a database, e.g. a `*sql.DB`.
+func Ping(db interface {
+	PingContext(ctx stdContext.Context) error
+}) CheckFunc {
+	return db.PingContext
+}
+
+// PingPong returns a check of a value which completes the `PingPong` method,
+// e.g. the redis session database's driver.
+func PingPong(p interface {
+	PingPong() (bool, error)
+}) CheckFunc {
+	return func(stdContext.Context) error {
+		ok, err := p.PingPong()
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New("no pong received")
+		}
+
+		return nil
+	}
+}
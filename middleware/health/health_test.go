@@ -0,0 +1,146 @@
+package health_test
+
+import (
+	stdContext "context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/host"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/health"
+)
+
+type pinger struct{ ok bool }
+
+func (p pinger) PingPong() (bool, error) { return p.ok, nil }
+
+func TestHealth(t *testing.T) {
+	checker := health.New()
+	checker.Timeout = 50 * time.Millisecond
+	checker.AddLivenessCheck("goroutines", func(stdContext.Context) error { return nil })
+
+	var dbErr error
+	checker.AddReadinessCheck("database", func(stdContext.Context) error { return dbErr })
+	checker.AddReadinessCheck("sessions", health.PingPong(pinger{ok: true}))
+
+	app := iris.New()
+	app.Get("/healthz", checker.Liveness)
+	app.Get("/readyz", checker.Readiness)
+
+	e := httptest.New(t, app)
+
+	e.GET("/healthz").Expect().Status(httptest.StatusOK).
+		JSON().Object().
+		ValueEqual("status", health.StatusOK).
+		Value("checks").Object().ContainsKey("goroutines").NotContainsKey("database")
+
+	e.GET("/readyz").Expect().Status(httptest.StatusOK).
+		JSON().Object().
+		ValueEqual("status", health.StatusOK).
+		Value("checks").Object().Keys().ContainsOnly("goroutines", "database", "sessions")
+
+	dbErr = errors.New("connection refused")
+	obj := e.GET("/readyz").Expect().Status(httptest.StatusServiceUnavailable).JSON().Object()
+	obj.ValueEqual("status", health.StatusFail)
+	obj.Value("checks").Object().Value("database").Object().
+		ValueEqual("status", health.StatusFail).
+		ValueEqual("error", "connection refused")
+	// liveness does not depend on the readiness checks.
+	e.GET("/healthz").Expect().Status(httptest.StatusOK)
+	dbErr = nil
+
+	checker.AddReadinessCheck("slow", func(ctx stdContext.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	e.GET("/readyz").Expect().Status(httptest.StatusServiceUnavailable).
+		JSON().Object().Value("checks").Object().Value("slow").Object().
+		ValueEqual("error", stdContext.DeadlineExceeded.Error())
+}
+
+func TestHealthShutdown(t *testing.T) {
+	checker := health.New()
+	checker.DrainDelay = 300 * time.Millisecond
+
+	app := iris.New()
+	checker.Attach(app)
+	app.Get("/healthz", checker.Liveness)
+	app.Get("/readyz", checker.Readiness)
+
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ran := make(chan struct{})
+	go func() {
+		app.Run(iris.Listener(l), iris.WithoutStartupLog, iris.WithoutInterruptHandler,
+			iris.WithoutServerError(iris.ErrServerClosed))
+		close(ran)
+	}()
+
+	get := func(path string) int {
+		resp, err := http.Get("http://" + l.Addr().String() + path)
+		if err != nil {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// no readiness probe before the shutdown.
+	for i := 0; i < 50 && get("/healthz") != http.StatusOK; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !checker.IsReady() {
+		t.Fatal("expected to be ready before shutdown")
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- app.Shutdown(stdContext.Background()) }()
+
+	// the server is reported as not ready while it still accepts connections.
+	status := 0
+	for i := 0; i < 20 && status != http.StatusServiceUnavailable; i++ {
+		status = get("/readyz")
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("expected status code: %d but got: %d", http.StatusServiceUnavailable, status)
+	}
+
+	select {
+	case <-done:
+		t.Fatal("expected the shutdown to wait for the drain delay")
+	default:
+	}
+
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	<-ran
+
+	if result := checker.Check(stdContext.Background(), false); result.Status != health.StatusOK {
+		t.Fatalf("expected liveness status: %q but got: %q", health.StatusOK, result.Status)
+	}
+}
+
+func TestHealthConfigureHost(t *testing.T) {
+	checker := health.New()
+	checker.DrainDelay = time.Minute
+
+	su := host.New(&http.Server{Addr: "127.0.0.1:0"}).Configure(checker.ConfigureHost)
+
+	// the drain ends when the shutdown's context is done.
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 50*time.Millisecond)
+	defer cancel()
+	su.Shutdown(ctx)
+
+	result := checker.Check(stdContext.Background(), true)
+	if result.Status != health.StatusShuttingDown {
+		t.Fatalf("expected status: %q but got: %q", health.StatusShuttingDown, result.Status)
+	}
+}
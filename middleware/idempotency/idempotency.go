@@ -0,0 +1,325 @@
+// Package idempotency provides a middleware which makes the retries of unsafe requests,
+// e.g. payments, safe through the "Idempotency-Key" request header.
+// The first response of a key (status code, headers and body) is recorded on a `Store`
+// and it is replayed on the repeated requests, the concurrent duplicates are rejected while
+// the first one is processed and a key can not be reused with a different request.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/idempotency.*", "iris.idempotency")
+}
+
+const (
+	// HeaderName is the request header of the idempotency key.
+	HeaderName = "Idempotency-Key"
+	// ReplayedHeaderName is the response header which is set to "true" on replayed responses.
+	ReplayedHeaderName = "Idempotent-Replayed"
+)
+
+var (
+	// ErrMissingKey is passed to the `Options.ErrorHandler` when the key is required but missing.
+	ErrMissingKey = errors.New("idempotency: missing key")
+	// ErrInvalidKey is passed to the `Options.ErrorHandler` when the key is longer than the `Options.MaxKeyLength`.
+	ErrInvalidKey = errors.New("idempotency: invalid key")
+	// ErrInProgress is passed to the `Options.ErrorHandler` when a request of the same key is processed.
+	ErrInProgress = errors.New("idempotency: a request with the same key is in progress")
+	// ErrMismatch is passed to the `Options.ErrorHandler` when the key was used by a different request.
+	ErrMismatch = errors.New("idempotency: the key was used by a different request")
+)
+
+// Options holds the options for the `New` middleware.
+type Options struct {
+	// Store keeps the records of the keys.
+	//
+	// Defaults to a `NewMemoryStore()`.
+	Store Store
+	// TTL is the time a response is replayed for.
+	//
+	// Defaults to 24 hours.
+	TTL time.Duration
+	// LockTimeout is the time a key is locked while its first request is processed,
+	// it should be longer than the slowest request. The lock is released
+	// earlier when the request completes, it only matters when a server stops mid-request.
+	//
+	// Defaults to 1 minute.
+	LockTimeout time.Duration
+	// Methods are the request methods which are handled, the rest are passed through.
+	//
+	// Defaults to POST and PATCH.
+	Methods []string
+	// Required rejects the requests without a key.
+	//
+	// Defaults to false, they are passed through.
+	Required bool
+	// MaxKeyLength is the maximum length of a key.
+	//
+	// Defaults to 255.
+	MaxKeyLength int
+	// Scope returns the namespace of the keys, e.g. the authenticated user's id,
+	// so clients can not replay each other's responses.
+	// Applications which authenticate through cookies should set it.
+	//
+	// Defaults to `DefaultScope`, the keys of each Authorization header.
+	Scope func(ctx context.Context) string
+	// Fingerprint identifies a request, a key can not be reused with a different fingerprint.
+	//
+	// Defaults to a hash of the method, the path, the query and the body.
+	Fingerprint func(ctx context.Context) (string, error)
+	// ErrorHandler is fired on rejected requests and on `Store` errors.
+	//
+	// Defaults to a Problem response of 400 Bad Request (`ErrMissingKey` and `ErrInvalidKey`),
+	// 409 Conflict (`ErrInProgress`), 422 Unprocessable Entity (`ErrMismatch`)
+	// and 500 Internal Server Error (any other error).
+	ErrorHandler func(ctx context.Context, err error)
+}
+
+// New returns a new Idempotency-Key middleware based on the optional "opts".
+// The responses are recorded, except the server errors (5xx),
+// their keys are released so the requests can be retried.
+// The Set-Cookie headers are never recorded, a replayed response does not carry
+// the cookies, e.g. a session, of the first one.
+//
+// Usage:
+//
+//     idempotent := idempotency.New(idempotency.Options{Required: true})
+//     app.Post("/payments", idempotent, createPayment)
+func New(opts ...Options) context.Handler {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.Store == nil {
+		o.Store = NewMemoryStore()
+	}
+
+	if o.TTL <= 0 {
+		o.TTL = 24 * time.Hour
+	}
+
+	if o.LockTimeout <= 0 {
+		o.LockTimeout = time.Minute
+	}
+
+	if len(o.Methods) == 0 {
+		o.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+
+	if o.MaxKeyLength <= 0 {
+		o.MaxKeyLength = 255
+	}
+
+	if o.Scope == nil {
+		o.Scope = DefaultScope
+	}
+
+	if o.Fingerprint == nil {
+		o.Fingerprint = DefaultFingerprint
+	}
+
+	if o.ErrorHandler == nil {
+		o.ErrorHandler = DefaultErrorHandler
+	}
+
+	methods := make(map[string]struct{}, len(o.Methods))
+	for _, method := range o.Methods {
+		methods[method] = struct{}{}
+	}
+
+	return func(ctx context.Context) {
+		if _, ok := methods[ctx.Method()]; !ok {
+			ctx.Next()
+			return
+		}
+
+		key := ctx.GetHeader(HeaderName)
+		if key == "" {
+			if o.Required {
+				o.ErrorHandler(ctx, ErrMissingKey)
+				return
+			}
+
+			ctx.Next()
+			return
+		}
+
+		if len(key) > o.MaxKeyLength {
+			o.ErrorHandler(ctx, ErrInvalidKey)
+			return
+		}
+
+		key = o.Scope(ctx) + "|" + key
+
+		fingerprint, err := o.Fingerprint(ctx)
+		if err != nil {
+			o.ErrorHandler(ctx, err)
+			return
+		}
+
+		record, err := o.Store.Reserve(key, fingerprint, o.LockTimeout)
+		if err != nil {
+			o.ErrorHandler(ctx, err)
+			return
+		}
+
+		if record != nil {
+			switch {
+			case record.Fingerprint != fingerprint:
+				o.ErrorHandler(ctx, ErrMismatch)
+			case record.Response == nil:
+				o.ErrorHandler(ctx, ErrInProgress)
+			default:
+				replay(ctx, record.Response)
+			}
+			return
+		}
+
+		completed := false
+		defer func() {
+			// release the key on panics too.
+			if !completed {
+				o.Store.Release(key)
+			}
+		}()
+
+		// the headers set by the previous handlers, e.g. a request id, are not replayed.
+		header := ctx.ResponseWriter().Header().Clone()
+
+		ctx.Record()
+		ctx.Next()
+
+		resp, ok := recorded(ctx, header)
+		if !ok || resp.StatusCode >= http.StatusInternalServerError {
+			return
+		}
+
+		if err = o.Store.Complete(key, Record{Fingerprint: fingerprint, Response: resp}, o.TTL); err != nil {
+			ctx.Application().Logger().Errorf("idempotency: %v", err)
+			return
+		}
+		completed = true
+	}
+}
+
+const setCookieHeaderKey = "Set-Cookie"
+
+func recorded(ctx context.Context, previous http.Header) (*Response, bool) {
+	rec, ok := ctx.IsRecording()
+	if !ok {
+		return nil, false
+	}
+
+	header := make(http.Header)
+	for key, values := range rec.Header() {
+		if key == setCookieHeaderKey {
+			continue
+		}
+
+		if !equalValues(previous[key], values) {
+			header[key] = append([]string(nil), values...)
+		}
+	}
+
+	body := make([]byte, len(rec.Body()))
+	copy(body, rec.Body())
+
+	return &Response{
+		StatusCode: ctx.GetStatusCode(),
+		Header:     header,
+		Body:       body,
+	}, true
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func replay(ctx context.Context, resp *Response) {
+	h := ctx.ResponseWriter().Header()
+	for key, values := range resp.Header {
+		h[key] = append([]string(nil), values...)
+	}
+	h.Set(ReplayedHeaderName, "true")
+
+	ctx.StopExecution()
+	ctx.StatusCode(resp.StatusCode)
+	ctx.Write(resp.Body)
+}
+
+// DefaultScope is the default `Options.Scope`,
+// a SHA-256 hash of the request's Authorization header, if any.
+func DefaultScope(ctx context.Context) string {
+	authorization := ctx.GetHeader("Authorization")
+	if authorization == "" {
+		return ""
+	}
+
+	h := sha256.Sum256([]byte(authorization))
+	return hex.EncodeToString(h[:])
+}
+
+// DefaultFingerprint is the default `Options.Fingerprint`,
+// a SHA-256 hash of the request's method, path, query and body.
+func DefaultFingerprint(ctx context.Context) (string, error) {
+	body, err := context.GetBody(ctx.Request(), true)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(ctx.Method()))
+	h.Write([]byte{0})
+	h.Write([]byte(ctx.Path()))
+	h.Write([]byte{0})
+	h.Write([]byte(ctx.Request().URL.RawQuery))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// DefaultErrorHandler is the default `Options.ErrorHandler`, it writes a Problem response.
+func DefaultErrorHandler(ctx context.Context, err error) {
+	problem := context.NewProblem()
+
+	switch err {
+	case ErrMissingKey:
+		problem.Status(http.StatusBadRequest).Title("Missing Idempotency-Key").
+			Detail("This operation is idempotent and it requires the Idempotency-Key header.")
+	case ErrInvalidKey:
+		problem.Status(http.StatusBadRequest).Title("Invalid Idempotency-Key").
+			Detail("The Idempotency-Key header is too long.")
+	case ErrInProgress:
+		ctx.Header("Retry-After", "1")
+		problem.Status(http.StatusConflict).Title("Request in progress").
+			Detail("A request with the same Idempotency-Key is being processed, retry later.")
+	case ErrMismatch:
+		problem.Status(http.StatusUnprocessableEntity).Title("Idempotency-Key reused").
+			Detail("The Idempotency-Key was used by a different request.")
+	default:
+		ctx.Application().Logger().Errorf("idempotency: %v", err)
+		problem.Status(http.StatusInternalServerError).Title("Internal Server Error")
+	}
+
+	ctx.StopExecution()
+	ctx.Problem(problem)
+}
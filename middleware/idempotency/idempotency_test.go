@@ -0,0 +1,125 @@
+package idempotency_test
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/idempotency"
+)
+
+func TestIdempotency(t *testing.T) {
+	var (
+		payments int32
+		started  = make(chan struct{})
+		release  = make(chan struct{})
+	)
+
+	app := iris.New()
+	app.Use(func(ctx iris.Context) {
+		ctx.Header("X-Request-Id", ctx.GetHeader("X-Request-Id"))
+		ctx.Next()
+	})
+	app.Use(idempotency.New(idempotency.Options{Required: true}))
+	app.Post("/payments", func(ctx iris.Context) {
+		id := atomic.AddInt32(&payments, 1)
+		ctx.Header("Location", "/payments/"+strconv.Itoa(int(id)))
+		ctx.StatusCode(iris.StatusCreated)
+		ctx.JSON(iris.Map{"id": id})
+	})
+	app.Post("/slow", func(ctx iris.Context) {
+		close(started)
+		<-release
+	})
+	app.Post("/fail", func(ctx iris.Context) {
+		atomic.AddInt32(&payments, 1)
+		ctx.StatusCode(iris.StatusInternalServerError)
+	})
+	app.Get("/payments", func(ctx iris.Context) {})
+
+	e := httptest.New(t, app)
+
+	for i := 0; i < 2; i++ {
+		resp := e.POST("/payments").WithHeader(idempotency.HeaderName, "k1").WithHeader("X-Request-Id", strconv.Itoa(i)).
+			WithJSON(iris.Map{"amount": 10}).Expect().Status(httptest.StatusCreated)
+		resp.JSON().Object().Value("id").Equal(1)
+		resp.Header("Location").Equal("/payments/1")
+		resp.Header("X-Request-Id").Equal(strconv.Itoa(i))
+		if i == 0 {
+			resp.Header(idempotency.ReplayedHeaderName).Empty()
+		} else {
+			resp.Header(idempotency.ReplayedHeaderName).Equal("true")
+		}
+	}
+
+	// a different request.
+	e.POST("/payments").WithHeader(idempotency.HeaderName, "k1").WithJSON(iris.Map{"amount": 20}).
+		Expect().Status(httptest.StatusUnprocessableEntity).
+		ContentType("application/problem+json").Body().Contains(`"title": "Idempotency-Key reused"`)
+
+	// a new key.
+	e.POST("/payments").WithHeader(idempotency.HeaderName, "k2").WithJSON(iris.Map{"amount": 10}).
+		Expect().Status(httptest.StatusCreated).JSON().Object().Value("id").Equal(2)
+
+	e.POST("/payments").WithJSON(iris.Map{"amount": 10}).Expect().Status(httptest.StatusBadRequest)
+	// not an unsafe method.
+	e.GET("/payments").Expect().Status(httptest.StatusOK)
+
+	// server errors are not recorded.
+	e.POST("/fail").WithHeader(idempotency.HeaderName, "k3").Expect().Status(httptest.StatusInternalServerError)
+	e.POST("/fail").WithHeader(idempotency.HeaderName, "k3").Expect().Status(httptest.StatusInternalServerError).
+		Header(idempotency.ReplayedHeaderName).Empty()
+	if expected, got := int32(4), atomic.LoadInt32(&payments); expected != got {
+		t.Fatalf("expected %d executions but got: %d", expected, got)
+	}
+
+	// concurrent duplicates.
+	done := make(chan struct{})
+	go func() {
+		e.POST("/slow").WithHeader(idempotency.HeaderName, "k4").Expect().Status(httptest.StatusOK)
+		close(done)
+	}()
+	<-started
+	e.POST("/slow").WithHeader(idempotency.HeaderName, "k4").Expect().
+		Status(httptest.StatusConflict).Header("Retry-After").Equal("1")
+	close(release)
+	<-done
+	e.POST("/slow").WithHeader(idempotency.HeaderName, "k4").Expect().
+		Status(httptest.StatusOK).Header(idempotency.ReplayedHeaderName).Equal("true")
+}
+
+func TestIdempotencyScope(t *testing.T) {
+	var executions int32
+
+	app := iris.New()
+	app.Use(idempotency.New())
+	app.Post("/", func(ctx iris.Context) {
+		id := atomic.AddInt32(&executions, 1)
+		ctx.SetCookieKV("session", "secret-"+strconv.Itoa(int(id)))
+		ctx.WriteString(strconv.Itoa(int(id)))
+	})
+
+	e := httptest.New(t, app)
+
+	alice := e.POST("/").WithHeader(idempotency.HeaderName, "k1").WithHeader("Authorization", "Bearer alice").Expect().
+		Status(httptest.StatusOK)
+	alice.Body().Equal("1")
+	alice.Cookie("session").Value().Equal("secret-1")
+
+	// another client with the same key.
+	e.POST("/").WithHeader(idempotency.HeaderName, "k1").WithHeader("Authorization", "Bearer bob").Expect().
+		Status(httptest.StatusOK).Body().Equal("2")
+
+	// the cookies of the first response are not replayed.
+	replayed := e.POST("/").WithHeader(idempotency.HeaderName, "k1").WithHeader("Authorization", "Bearer alice").Expect().
+		Status(httptest.StatusOK)
+	replayed.Header(idempotency.ReplayedHeaderName).Equal("true")
+	replayed.Body().Equal("1")
+	replayed.Header("Set-Cookie").Empty()
+
+	// the query is part of the request.
+	e.POST("/").WithHeader(idempotency.HeaderName, "k1").WithHeader("Authorization", "Bearer alice").
+		WithQuery("amount", "20").Expect().Status(httptest.StatusUnprocessableEntity)
+}
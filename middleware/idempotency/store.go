@@ -0,0 +1,110 @@
+package idempotency
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Response is a recorded response, replayed on the repeated requests.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+// Record is the stored state of an idempotency key.
+// It is JSON encodable, so stores can keep it on external databases.
+type Record struct {
+	// Fingerprint identifies the first request of the key, see `Options.Fingerprint`.
+	Fingerprint string `json:"fingerprint"`
+	// Response is the first request's response, nil while it is processed.
+	Response *Response `json:"response,omitempty"`
+}
+
+// Store keeps the records of the idempotency keys, shared across the application's replicas
+// on external databases. Its methods must be atomic per key.
+type Store interface {
+	// Reserve stores a new record of the "key", without a response, which expires after "ttl"
+	// and returns nil if the key does not exist, otherwise it returns the existing record.
+	Reserve(key, fingerprint string, ttl time.Duration) (*Record, error)
+	// Complete saves the "record" of a reserved key, which expires after "ttl".
+	Complete(key string, record Record, ttl time.Duration) error
+	// Release removes the "key", so its request can be retried.
+	Release(key string) error
+}
+
+// MemoryStore is the default, process-local, `Store`.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	nextSweep time.Time
+}
+
+type memoryEntry struct {
+	record  Record
+	expires time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns a new in-memory `Store`.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+// Reserve stores a new record of the "key" if it does not exist (or it is expired).
+func (s *MemoryStore) Reserve(key, fingerprint string, ttl time.Duration) (*Record, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.After(s.nextSweep) {
+		s.sweep(now)
+		s.nextSweep = now.Add(time.Minute)
+	}
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		record := e.record
+		return &record, nil
+	}
+
+	s.entries[key] = &memoryEntry{
+		record:  Record{Fingerprint: fingerprint},
+		expires: now.Add(ttl),
+	}
+	return nil, nil
+}
+
+// Complete saves the "record" of the "key".
+func (s *MemoryStore) Complete(key string, record Record, ttl time.Duration) error {
+	s.mu.Lock()
+	s.entries[key] = &memoryEntry{record: record, expires: time.Now().Add(ttl)}
+	s.mu.Unlock()
+	return nil
+}
+
+// Release removes the "key".
+func (s *MemoryStore) Release(key string) error {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// Len returns the number of the stored keys.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	n := len(s.entries)
+	s.mu.Unlock()
+	return n
+}
@@ -14,6 +14,9 @@ type (
 	// epoch, including leap seconds. Non-integer values can be represented
 	// in the serialized format, but we round to the nearest second.
 	NumericDate = jwt.NumericDate
+	// Expected defines values used for protected claims validation.
+	// If field has zero value then validation is skipped.
+	Expected = jwt.Expected
 )
 
 var (
@@ -0,0 +1,76 @@
+package jwt
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBlocked is returned when a token's "jti" is blocked, e.g. a revoked or an already used refresh token.
+var ErrBlocked = errors.New("token is blocked (jti)")
+
+// Blocklist keeps the revoked token ids (the "jti" claim) until the tokens expire.
+// See `JWT.Blocklist`, `MemoryBlocklist` and the redis one at the "blocklist/redis" subpackage.
+type Blocklist interface {
+	// Block revokes the "jti" until "expiry".
+	// It returns `ErrBlocked` if the "jti" is already blocked,
+	// atomically, so a refresh token can not be used twice.
+	Block(jti string, expiry time.Time) error
+	// IsBlocked reports whether the "jti" is revoked.
+	IsBlocked(jti string) (bool, error)
+}
+
+// MemoryBlocklist is a process-local `Blocklist`, the expired ids are removed periodically.
+type MemoryBlocklist struct {
+	mu        sync.Mutex
+	entries   map[string]time.Time
+	nextSweep time.Time
+}
+
+var _ Blocklist = (*MemoryBlocklist)(nil)
+
+// NewMemoryBlocklist returns a new in-memory `Blocklist`.
+func NewMemoryBlocklist() *MemoryBlocklist {
+	return &MemoryBlocklist{entries: make(map[string]time.Time)}
+}
+
+// Block revokes the "jti" until "expiry", it returns `ErrBlocked` if it is already blocked.
+func (b *MemoryBlocklist) Block(jti string, expiry time.Time) error {
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if exp, ok := b.entries[jti]; ok && now.Before(exp) {
+		return ErrBlocked
+	}
+
+	if now.After(b.nextSweep) {
+		for id, exp := range b.entries {
+			if now.After(exp) {
+				delete(b.entries, id)
+			}
+		}
+		b.nextSweep = now.Add(time.Minute)
+	}
+
+	b.entries[jti] = expiry
+	return nil
+}
+
+// IsBlocked reports whether the "jti" is revoked.
+func (b *MemoryBlocklist) IsBlocked(jti string) (bool, error) {
+	b.mu.Lock()
+	expiry, ok := b.entries[jti]
+	b.mu.Unlock()
+
+	return ok && time.Now().Before(expiry), nil
+}
+
+// Len returns the number of the blocked ids, including the expired ones which are not removed yet.
+func (b *MemoryBlocklist) Len() int {
+	b.mu.Lock()
+	n := len(b.entries)
+	b.mu.Unlock()
+	return n
+}
@@ -0,0 +1,79 @@
+// Package redis provides a redis `jwt.Blocklist` based on the redis drivers
+// of the sessions database, so the revoked tokens are shared across the application's replicas.
+package redis
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/kataras/iris/v12/middleware/jwt"
+	sessionredis "github.com/kataras/iris/v12/sessions/sessiondb/redis"
+)
+
+// Blocklist is a redis `jwt.Blocklist`, the ids expire with their tokens.
+type Blocklist struct {
+	// Prefix is the prefix of the keys.
+	//
+	// Defaults to "jwt:blocked:".
+	Prefix string
+
+	driver sessionredis.Evaler
+}
+
+var _ jwt.Blocklist = (*Blocklist)(nil)
+
+// New returns a new redis `jwt.Blocklist` which uses the driver of the (connected) redis sessions database "db".
+// It returns `sessionredis.ErrDriverNotSupported` if the driver cannot run Lua scripts.
+//
+// Usage:
+//
+//     db := redis.New(redis.Config{Addr: "127.0.0.1:6379"}) // sessions/sessiondb/redis
+//     j.Blocklist, err = blocklistredis.New(db)
+func New(db *sessionredis.Database) (*Blocklist, error) {
+	driver, err := db.Evaler()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Blocklist{Prefix: "jwt:blocked:", driver: driver}, nil
+}
+
+const (
+	blockScript = `
+if redis.call("SET", KEYS[1], "1", "PX", ARGV[1], "NX") then
+  return {"1"}
+end
+return {"0"}
+`
+	isBlockedScript = `return {tostring(redis.call("EXISTS", KEYS[1]))}`
+)
+
+// Block revokes the "jti" until "expiry", it returns `jwt.ErrBlocked` if it is already blocked.
+func (b *Blocklist) Block(jti string, expiry time.Time) error {
+	ttl := time.Until(expiry).Milliseconds()
+	if ttl <= 0 {
+		// already expired.
+		return nil
+	}
+
+	reply, err := b.driver.Eval(blockScript, []string{b.Prefix + jti}, strconv.FormatInt(ttl, 10))
+	if err != nil {
+		return err
+	}
+
+	if len(reply) == 0 || reply[0] != "1" {
+		return jwt.ErrBlocked
+	}
+
+	return nil
+}
+
+// IsBlocked reports whether the "jti" is revoked.
+func (b *Blocklist) IsBlocked(jti string) (bool, error) {
+	reply, err := b.driver.Eval(isBlockedScript, []string{b.Prefix + jti})
+	if err != nil {
+		return false, err
+	}
+
+	return len(reply) > 0 && reply[0] == "1", nil
+}
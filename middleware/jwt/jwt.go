@@ -2,10 +2,13 @@ package jwt
 
 import (
 	"crypto"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/kataras/iris/v12/context"
@@ -104,11 +107,35 @@ type JWT struct {
 	// VerificationKey is used to verify the token (public key).
 	VerificationKey interface{}
 
+	// VerificationKeys are used to verify the tokens by their "kid" header.
+	// They are set on `SetSigningKey` and `AddVerificationKey` methods
+	// and they are served by the `JWKS` handler.
+	VerificationKeys JSONWebKeySet
+	// RemoteKeys, optionally, verifies the tokens signed by third-parties, by their "kid" header.
+	// It is set on `Remote` package-level function.
+	RemoteKeys *RemoteKeySet
+	// Expected, optionally, validates the issuer (iss), subject (sub), audience (aud) and id (jti)
+	// of the verified tokens, its Time field is ignored.
+	// It is set on `Remote` package-level function.
+	Expected Expected
+
 	// Encrypter is used to, optionally, encrypt the token.
 	// It is set on `WithEncryption` method.
 	Encrypter jose.Encrypter
 	// DecriptionKey is used to decrypt the token (private key)
 	DecriptionKey interface{}
+
+	// RefreshMaxAge is the expiration duration of the refresh tokens, see `TokenPair`.
+	// Defaults to `DefaultRefreshMaxAge`.
+	RefreshMaxAge time.Duration
+	// RefreshExtractors are used to extract the refresh token from the request, see `Refresh`.
+	// Defaults to a slice of `FromJSON("refresh_token")` and `FromHeader`.
+	RefreshExtractors []TokenExtractor
+	// Blocklist, optionally, keeps the revoked token ids (jti), see `Block`.
+	// It is required for the rotation of the refresh tokens.
+	Blocklist Blocklist
+
+	mu sync.RWMutex // protects the keys and the signer.
 }
 
 type privateKey interface{ Public() crypto.PublicKey }
@@ -287,15 +314,28 @@ func (j *JWT) WithEncryption(contentEncryption ContentEncryption, alg KeyAlgorit
 // Expiry returns a new standard Claims with
 // the `Expiry` and `IssuedAt` fields of the "claims" filled
 // based on the given "maxAge" duration.
+// The `ID` (jti) is filled with a random value, if empty, so the token can be blocked.
 //
 // See the `JWT.Expiry` method too.
 func Expiry(maxAge time.Duration, claims Claims) Claims {
 	now := time.Now()
 	claims.Expiry = NewNumericDate(now.Add(maxAge))
 	claims.IssuedAt = NewNumericDate(now)
+	if claims.ID == "" {
+		claims.ID = newID()
+	}
 	return claims
 }
 
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("jwt: " + err.Error())
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
 // Expiry method same as `Expiry` package-level function,
 // it returns a Claims with the expiration fields of the "claims"
 // filled based on the JWT's `MaxAge` field.
@@ -332,6 +372,18 @@ func (j *JWT) Token(claims interface{}) (string, error) {
 		claims = Expiry(j.MaxAge, c)
 	}
 
+	return j.sign(claims)
+}
+
+func (j *JWT) sign(claims interface{}) (string, error) {
+	j.mu.RLock()
+	signer := j.Signer
+	j.mu.RUnlock()
+
+	if signer == nil {
+		return "", ErrNoSigner
+	}
+
 	var (
 		token string
 		err   error
@@ -339,9 +391,9 @@ func (j *JWT) Token(claims interface{}) (string, error) {
 
 	// jwt.Builder and jwt.NestedBuilder contain same methods but they are not the same.
 	if j.DecriptionKey != nil {
-		token, err = jwt.SignedAndEncrypted(j.Signer, j.Encrypter).Claims(claims).CompactSerialize()
+		token, err = jwt.SignedAndEncrypted(signer, j.Encrypter).Claims(claims).CompactSerialize()
 	} else {
-		token, err = jwt.Signed(j.Signer).Claims(claims).CompactSerialize()
+		token, err = jwt.Signed(signer).Claims(claims).CompactSerialize()
 	}
 
 	if err != nil {
@@ -408,6 +460,12 @@ var (
 	ErrNotValidYet = errors.New("token not valid yet (nbf)")
 	// ErrIssuedInTheFuture indicates that the iat field is in the future.
 	ErrIssuedInTheFuture = errors.New("token issued in the future (iat)")
+	// ErrTokenType indicates that a refresh token is used as an access token or the opposite.
+	ErrTokenType = errors.New("token type mismatch (refresh)")
+	// ErrInvalidIssuer indicates that the iss claim does not match the `JWT.Expected` one.
+	ErrInvalidIssuer = jwt.ErrInvalidIssuer
+	// ErrInvalidAudience indicates that the aud claim does not contain the `JWT.Expected` ones.
+	ErrInvalidAudience = jwt.ErrInvalidAudience
 )
 
 type (
@@ -466,20 +524,38 @@ func validateClaims(ctx context.Context, claims interface{}) (err error) {
 
 // VerifyToken verifies (and decrypts) the request token,
 // it also validates and binds the parsed token's claims to the "claimsPtr" (destination).
+// The token is verified by the key of its "kid" header, if any, see `VerificationKeys` and `RemoteKeys`,
+// and it is rejected if its "jti" is blocked, see `Blocklist`.
 // It does return a nil error on success.
 func (j *JWT) VerifyToken(ctx context.Context, claimsPtr interface{}) error {
-	var token string
+	token := extractToken(ctx, j.Extractors)
+	if token == "" {
+		return ErrMissing
+	}
+
+	_, err := j.verify(ctx, token, false, claimsPtr)
+	return err
+}
 
-	for _, extract := range j.Extractors {
-		if token = extract(ctx); token != "" {
-			break // ok we found it.
+func extractToken(ctx context.Context, extractors []TokenExtractor) string {
+	for _, extract := range extractors {
+		if token := extract(ctx); token != "" {
+			return token // ok we found it.
 		}
 	}
 
-	if token == "" {
-		return ErrMissing
-	}
+	return ""
+}
 
+// tokenClaims are the standard claims and the refresh token's marker of a token.
+type tokenClaims struct {
+	Claims
+	Refresh bool `json:"refresh,omitempty"`
+}
+
+// verify verifies and binds the "token" to the "claimsPtr",
+// the refresh tokens are validated and blocked by the caller.
+func (j *JWT) verify(ctx context.Context, token string, refresh bool, claimsPtr interface{}) (Claims, error) {
 	var (
 		parsedToken *jwt.JSONWebToken
 		err         error
@@ -488,7 +564,7 @@ func (j *JWT) VerifyToken(ctx context.Context, claimsPtr interface{}) error {
 	if j.DecriptionKey != nil {
 		t, cerr := jwt.ParseSignedAndEncrypted(token)
 		if cerr != nil {
-			return cerr
+			return Claims{}, cerr
 		}
 
 		parsedToken, err = t.Decrypt(j.DecriptionKey)
@@ -496,14 +572,55 @@ func (j *JWT) VerifyToken(ctx context.Context, claimsPtr interface{}) error {
 		parsedToken, err = jwt.ParseSigned(token)
 	}
 	if err != nil {
-		return err
+		return Claims{}, err
 	}
 
-	if err = parsedToken.Claims(j.VerificationKey, claimsPtr); err != nil {
-		return err
+	var kid string
+	if len(parsedToken.Headers) > 0 {
+		kid = parsedToken.Headers[0].KeyID
+	}
+
+	key, err := j.verificationKey(kid)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	var std tokenClaims
+	if err = parsedToken.Claims(key, claimsPtr, &std); err != nil {
+		return Claims{}, err
+	}
+
+	if std.Refresh != refresh {
+		return Claims{}, ErrTokenType
+	}
+
+	// the time is validated below, through the claims' validators.
+	expected := j.Expected
+	expected.Time = time.Time{}
+	if err = std.Claims.ValidateWithLeeway(expected, 0); err != nil {
+		return Claims{}, err
+	}
+
+	if refresh {
+		return std.Claims, validateClaims(ctx, &std.Claims)
+	}
+
+	if err = validateClaims(ctx, claimsPtr); err != nil {
+		return Claims{}, err
+	}
+
+	if j.Blocklist != nil && std.ID != "" {
+		blocked, err := j.Blocklist.IsBlocked(std.ID)
+		if err != nil {
+			return Claims{}, err
+		}
+
+		if blocked {
+			return Claims{}, ErrBlocked
+		}
 	}
 
-	return validateClaims(ctx, claimsPtr)
+	return std.Claims, nil
 }
 
 const (
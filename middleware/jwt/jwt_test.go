@@ -2,7 +2,12 @@
 package jwt_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	stdhttptest "net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
@@ -137,3 +142,190 @@ func testWriteVerifyToken(t *testing.T, j *jwt.JWT) {
 		e.POST(path).WithQuery("token", rawToken).Expect().Status(httptest.StatusUnauthorized)
 	}
 }
+
+func TestTokenPair(t *testing.T) {
+	j, err := jwt.New(time.Minute, jwt.HS256, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Blocklist = jwt.NewMemoryBlocklist()
+
+	app := iris.New()
+	app.Post("/login", func(ctx iris.Context) {
+		user := jwt.Claims{Subject: "kataras"}
+		pair, err := j.TokenPair(user, user)
+		if err != nil {
+			ctx.StopWithError(iris.StatusInternalServerError, err)
+			return
+		}
+
+		ctx.JSON(pair)
+	})
+	app.Post("/refresh", func(ctx iris.Context) {
+		var claims jwt.Claims
+		if err := j.Refresh(ctx, &claims); err != nil {
+			ctx.StopWithError(iris.StatusUnauthorized, err)
+			return
+		}
+
+		user := jwt.Claims{Subject: claims.Subject}
+		pair, _ := j.TokenPair(user, user)
+		ctx.JSON(pair)
+	})
+	app.Get("/restricted", func(ctx iris.Context) {
+		var claims jwt.Claims
+		if err := j.VerifyToken(ctx, &claims); err != nil {
+			ctx.StopWithError(iris.StatusUnauthorized, err)
+			return
+		}
+
+		ctx.WriteString(claims.Subject)
+	})
+	app.Post("/logout", func(ctx iris.Context) {
+		var claims jwt.Claims
+		if err := j.VerifyToken(ctx, &claims); err != nil {
+			ctx.StopWithError(iris.StatusUnauthorized, err)
+			return
+		}
+
+		j.Block(claims)
+	})
+
+	e := httptest.New(t, app)
+
+	pair := e.POST("/login").Expect().Status(httptest.StatusOK).JSON().Object()
+	pair.Value("token_type").Equal("Bearer")
+	pair.Value("expires_in").Equal(60)
+	accessToken := pair.Value("access_token").String().Raw()
+	refreshToken := pair.Value("refresh_token").String().Raw()
+
+	e.GET("/restricted").WithHeader("Authorization", "Bearer "+accessToken).Expect().
+		Status(httptest.StatusOK).Body().Equal("kataras")
+	// a refresh token is not an access token and the opposite.
+	e.GET("/restricted").WithHeader("Authorization", "Bearer "+refreshToken).Expect().
+		Status(httptest.StatusUnauthorized).Body().Equal(jwt.ErrTokenType.Error())
+	e.POST("/refresh").WithJSON(iris.Map{"refresh_token": accessToken}).Expect().
+		Status(httptest.StatusUnauthorized)
+
+	newRefreshToken := e.POST("/refresh").WithJSON(iris.Map{"refresh_token": refreshToken}).Expect().
+		Status(httptest.StatusOK).JSON().Object().Value("refresh_token").String().NotEqual(refreshToken).Raw()
+	// rotated.
+	e.POST("/refresh").WithJSON(iris.Map{"refresh_token": refreshToken}).Expect().
+		Status(httptest.StatusUnauthorized).Body().Equal(jwt.ErrBlocked.Error())
+	e.POST("/refresh").WithHeader("Authorization", "Bearer "+newRefreshToken).Expect().
+		Status(httptest.StatusOK)
+
+	// revoked.
+	e.POST("/logout").WithHeader("Authorization", "Bearer "+accessToken).Expect().Status(httptest.StatusOK)
+	e.GET("/restricted").WithHeader("Authorization", "Bearer "+accessToken).Expect().
+		Status(httptest.StatusUnauthorized).Body().Equal(jwt.ErrBlocked.Error())
+
+	// refresh tokens are not rotated without a blocklist.
+	j.Blocklist = nil
+	e.POST("/refresh").WithHeader("Authorization", "Bearer "+newRefreshToken).Expect().
+		Status(httptest.StatusUnauthorized).Body().Equal(jwt.ErrNoBlocklist.Error())
+}
+
+func TestKeyRotation(t *testing.T) {
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+
+	j, err := jwt.New(time.Minute, jwt.ES256, newKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// signed without a kid.
+	token0, err := j.Token(jwt.Claims{Subject: "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tokens []string
+	for _, kid := range []string{"k1", "k2"} {
+		if err = j.SetSigningKey(kid, jwt.ES256, newKey()); err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := j.Token(jwt.Claims{Subject: kid, Issuer: "issuer", Audience: jwt.Audience{"app"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	// signed by the current key for another client.
+	otherAudienceToken, err := j.Token(jwt.Claims{Subject: "other", Issuer: "issuer", Audience: jwt.Audience{"other"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := iris.New()
+	app.Get(jwt.JWKSPath, j.JWKS)
+	app.Get("/", func(ctx iris.Context) {
+		var claims jwt.Claims
+		if err := j.VerifyToken(ctx, &claims); err != nil {
+			ctx.StopWithError(iris.StatusUnauthorized, err)
+			return
+		}
+
+		ctx.WriteString(claims.Subject)
+	})
+
+	e := httptest.New(t, app)
+	for i, token := range []string{token0, tokens[0], tokens[1]} {
+		expected := "0"
+		if i > 0 {
+			expected = "k" + strconv.Itoa(i)
+		}
+		e.GET("/").WithQuery("token", token).Expect().Status(httptest.StatusOK).Body().Equal(expected)
+	}
+
+	j.RemoveVerificationKey("k1")
+	e.GET("/").WithQuery("token", tokens[0]).Expect().
+		Status(httptest.StatusUnauthorized).Body().Equal(jwt.ErrUnknownKey.Error())
+
+	keys := e.GET(jwt.JWKSPath).Expect().Status(httptest.StatusOK).JSON().Object().Value("keys").Array()
+	keys.Length().Equal(1)
+	keys.Element(0).Object().Value("kid").Equal("k2")
+	keys.Element(0).Object().NotContainsKey("d")
+
+	// verify through the remote key set.
+	app.Build()
+	srv := stdhttptest.NewServer(app)
+	defer srv.Close()
+
+	if _, err = jwt.Remote(srv.URL+jwt.JWKSPath, "issuer"); err != jwt.ErrNoExpected {
+		t.Fatalf("expected ErrNoExpected but got: %v", err)
+	}
+
+	remote, err := jwt.Remote(srv.URL+jwt.JWKSPath, "issuer", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remoteApp := iris.New()
+	remoteApp.Get("/", func(ctx iris.Context) {
+		var claims jwt.Claims
+		if err := remote.VerifyToken(ctx, &claims); err != nil {
+			ctx.StopWithError(iris.StatusUnauthorized, err)
+			return
+		}
+
+		ctx.WriteString(claims.Subject)
+	})
+
+	re := httptest.New(t, remoteApp)
+	re.GET("/").WithQuery("token", tokens[1]).Expect().Status(httptest.StatusOK).Body().Equal("k2")
+	re.GET("/").WithQuery("token", tokens[0]).Expect().Status(httptest.StatusUnauthorized)
+	re.GET("/").WithQuery("token", otherAudienceToken).Expect().
+		Status(httptest.StatusUnauthorized).Body().Equal(jwt.ErrInvalidAudience.Error())
+
+	if _, err = remote.Token(jwt.Claims{}); err != jwt.ErrNoSigner {
+		t.Fatalf("expected ErrNoSigner but got: %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+
+	"github.com/square/go-jose/v3"
+)
+
+// JWKSPath is the well-known path of the `JWT.JWKS` handler.
+const JWKSPath = "/.well-known/jwks.json"
+
+var (
+	// ErrUnknownKey is returned when a token's "kid" header does not match any verification key.
+	ErrUnknownKey = errors.New("token signed by an unknown key (kid)")
+	// ErrNoSigner is returned by `Token` when the JWT can only verify tokens, see `Remote`.
+	ErrNoSigner = errors.New("no signing key")
+	// ErrNoExpected is returned by `Remote` when the expected issuer or audience is empty.
+	ErrNoExpected = errors.New("remote: the expected issuer and audience are required")
+)
+
+// JSONWebKey represents a public or private key in JWK format.
+type JSONWebKey = jose.JSONWebKey
+
+// JSONWebKeySet represents a JWK Set object.
+type JSONWebKeySet = jose.JSONWebKeySet
+
+// SetSigningKey sets a new key to sign the tokens with, its "kid" is included on the tokens' header.
+// The key, its public part, is added to the `VerificationKeys` too,
+// so the tokens signed by the previous keys are still verified until `RemoveVerificationKey` is called.
+//
+// Usage to rotate the keys:
+//
+//     j.SetSigningKey("2020-07", jwt.RS256, newPrivateKey)
+//     // after the MaxAge (and the RefreshMaxAge) of the tokens signed by the old key:
+//     j.RemoveVerificationKey("2020-06")
+func (j *JWT) SetSigningKey(kid string, alg SignatureAlgorithm, key interface{}) error {
+	sig, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: alg,
+		Key:       jose.JSONWebKey{Key: key, KeyID: kid, Algorithm: string(alg)},
+	}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	j.Signer = sig
+	j.mu.Unlock()
+
+	j.AddVerificationKey(kid, alg, key)
+	return nil
+}
+
+// AddVerificationKey adds, or replaces, a key of the "kid" to the `VerificationKeys`.
+// The public part of a private key is stored.
+func (j *JWT) AddVerificationKey(kid string, alg SignatureAlgorithm, key interface{}) {
+	if s, ok := key.(privateKey); ok {
+		key = s.Public()
+	}
+
+	jwk := jose.JSONWebKey{Key: key, KeyID: kid, Algorithm: string(alg), Use: "sig"}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for i, k := range j.VerificationKeys.Keys {
+		if k.KeyID == kid {
+			j.VerificationKeys.Keys[i] = jwk
+			return
+		}
+	}
+
+	j.VerificationKeys.Keys = append(j.VerificationKeys.Keys, jwk)
+}
+
+// RemoveVerificationKey removes the key of the "kid" from the `VerificationKeys`,
+// the tokens signed by that key are no longer verified.
+func (j *JWT) RemoveVerificationKey(kid string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	keys := j.VerificationKeys.Keys[:0]
+	for _, k := range j.VerificationKeys.Keys {
+		if k.KeyID != kid {
+			keys = append(keys, k)
+		}
+	}
+	j.VerificationKeys.Keys = keys
+}
+
+// verificationKey returns the key to verify a token of the "kid" header.
+func (j *JWT) verificationKey(kid string) (interface{}, error) {
+	if kid == "" {
+		j.mu.RLock()
+		key := j.VerificationKey
+		j.mu.RUnlock()
+		if key == nil {
+			return nil, ErrUnknownKey
+		}
+
+		return key, nil
+	}
+
+	j.mu.RLock()
+	keys := j.VerificationKeys.Key(kid)
+	j.mu.RUnlock()
+	if len(keys) > 0 {
+		return keys[0], nil
+	}
+
+	if j.RemoteKeys != nil {
+		return j.RemoteKeys.Key(kid)
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// JWKS is a handler which writes the public `VerificationKeys` as a JSON Web Key Set,
+// so third-parties can verify the tokens. The symmetric (HMAC) keys are never exposed.
+//
+// Usage:
+//
+//     app.Get(jwt.JWKSPath, j.JWKS)
+func (j *JWT) JWKS(ctx context.Context) {
+	var set jose.JSONWebKeySet
+
+	j.mu.RLock()
+	for _, k := range j.VerificationKeys.Keys {
+		if k.IsPublic() {
+			set.Keys = append(set.Keys, k)
+		}
+	}
+	j.mu.RUnlock()
+
+	if set.Keys == nil {
+		set.Keys = []jose.JSONWebKey{}
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=3600")
+	ctx.JSON(set)
+}
+
+// RemoteKeySet is a cache of a remote JSON Web Key Set, e.g. of an OpenID Connect provider,
+// to verify the tokens signed by third-parties. See `Remote` and the `JWT.RemoteKeys` field.
+// It is safe for concurrent use.
+type RemoteKeySet struct {
+	// URL is the location of the key set.
+	URL string
+	// Client is used to fetch the key set.
+	//
+	// Defaults to a client of 10 seconds timeout.
+	Client *http.Client
+	// MaxAge is the time the fetched keys are cached for.
+	//
+	// Defaults to 1 hour.
+	MaxAge time.Duration
+	// MinRefreshInterval is the minimum time between two fetches,
+	// as the set is fetched again on unknown "kid" headers, e.g. after a key rotation.
+	//
+	// Defaults to 1 minute.
+	MinRefreshInterval time.Duration
+
+	mu          sync.Mutex
+	keys        jose.JSONWebKeySet
+	fetched     time.Time
+	lastAttempt time.Time
+	inflight    *remoteFetch // the running fetch, if any.
+}
+
+// remoteFetch is a fetch of the key set shared by the concurrent `Key` calls.
+type remoteFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// NewRemoteKeySet returns a new `RemoteKeySet` of the "url" with the default options.
+func NewRemoteKeySet(url string) *RemoteKeySet {
+	return &RemoteKeySet{
+		URL:                url,
+		Client:             &http.Client{Timeout: 10 * time.Second},
+		MaxAge:             time.Hour,
+		MinRefreshInterval: time.Minute,
+	}
+}
+
+// Key returns the key of the "kid", it fetches the key set if the cache is expired
+// or if the key is missing. The fetch is made out of the lock and it is shared
+// by the concurrent calls, the cached keys are served meanwhile.
+func (s *RemoteKeySet) Key(kid string) (*JSONWebKey, error) {
+	s.mu.Lock()
+	now := time.Now()
+	if now.Sub(s.fetched) <= s.MaxAge {
+		if keys := s.keys.Key(kid); len(keys) > 0 {
+			s.mu.Unlock()
+			return &keys[0], nil
+		}
+	}
+
+	call := s.inflight
+	if call == nil && now.Sub(s.lastAttempt) >= s.MinRefreshInterval {
+		s.lastAttempt = now
+		call = &remoteFetch{done: make(chan struct{})}
+		s.inflight = call
+		s.mu.Unlock()
+
+		keys, err := s.fetch()
+
+		s.mu.Lock()
+		if err == nil {
+			s.keys = keys
+			s.fetched = now
+		}
+		s.inflight = nil
+		call.err = err
+		close(call.done)
+	}
+	s.mu.Unlock()
+
+	var err error
+	if call != nil {
+		<-call.done
+		err = call.err
+	}
+
+	s.mu.Lock()
+	// on fetch errors the stale keys are used while the remote is unavailable.
+	keys := s.keys.Key(kid)
+	s.mu.Unlock()
+	if len(keys) > 0 {
+		return &keys[0], nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, ErrUnknownKey
+}
+
+func (s *RemoteKeySet) fetch() (jose.JSONWebKeySet, error) {
+	var keys jose.JSONWebKeySet
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Get(s.URL)
+	if err != nil {
+		return keys, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return keys, fmt.Errorf("jwt: fetch %s: unexpected status code: %d", s.URL, resp.StatusCode)
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(&keys); err != nil {
+		return keys, fmt.Errorf("jwt: fetch %s: %w", s.URL, err)
+	}
+
+	return keys, nil
+}
+
+// Remote returns a new `JWT` which only verifies the tokens signed by the keys
+// of the remote JSON Web Key Set of "jwksURL", e.g. "https://example.com/.well-known/jwks.json".
+// The keys are cached, see `RemoteKeySet`.
+//
+// The tokens must be issued by the "issuer" (iss) for the "audience" (aud), e.g. the client id
+// of the application, otherwise a token of the same provider issued for any other client is accepted too.
+// It returns `ErrNoExpected` if one of them is empty, see the `JWT.Expected` field.
+func Remote(jwksURL, issuer string, audience ...string) (*JWT, error) {
+	if issuer == "" || len(audience) == 0 {
+		return nil, ErrNoExpected
+	}
+
+	return &JWT{
+		Extractors: []TokenExtractor{FromHeader, FromQuery},
+		RemoteKeys: NewRemoteKeySet(jwksURL),
+		Expected:   Expected{Issuer: issuer, Audience: audience},
+	}, nil
+}
@@ -0,0 +1,118 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+// DefaultRefreshMaxAge is the default `JWT.RefreshMaxAge`.
+const DefaultRefreshMaxAge = 7 * 24 * time.Hour
+
+var (
+	// ErrNoID is returned by `Block` when the claims have no ID (jti).
+	ErrNoID = errors.New("token has no id (jti)")
+	// ErrNoBlocklist is returned by `Block` and `Refresh` when the `JWT.Blocklist` is nil.
+	ErrNoBlocklist = errors.New("no blocklist")
+)
+
+// TokenPair holds a short-lived access token and a long-lived refresh token,
+// it is written as the JSON response of a token endpoint (RFC 6749).
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	// ExpiresIn is the access token's lifetime in seconds.
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// TokenPair generates a new access token of the "accessClaims" (see `Token`)
+// and a new refresh token of the "refreshClaims", which expires after the `RefreshMaxAge`.
+// The refresh token can only be used by `Refresh`, it is not accepted as an access token.
+// The "refreshClaims" should identify the user, e.g. through the Subject field.
+func (j *JWT) TokenPair(accessClaims interface{}, refreshClaims Claims) (TokenPair, error) {
+	accessToken, err := j.Token(accessClaims)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	maxAge := j.RefreshMaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultRefreshMaxAge
+	}
+
+	refreshToken, err := j.sign(tokenClaims{Claims: Expiry(maxAge, refreshClaims), Refresh: true})
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(j.MaxAge / time.Second),
+	}, nil
+}
+
+// Refresh verifies the request's refresh token (see `RefreshExtractors`)
+// and binds its claims to the "claimsPtr", so a new `TokenPair` can be generated.
+// The refresh tokens are rotated: the verified one is blocked, so it can not be used again
+// and a reused, e.g. stolen, refresh token fails with `ErrBlocked`.
+// This requires a `Blocklist`, it fails with `ErrNoBlocklist` otherwise.
+//
+// Usage:
+//
+//     app.Post("/refresh", func(ctx iris.Context) {
+//         var claims jwt.Claims
+//         if err := j.Refresh(ctx, &claims); err != nil {
+//             ctx.StopWithStatus(iris.StatusUnauthorized)
+//             return
+//         }
+//
+//         user := jwt.Claims{Subject: claims.Subject}
+//         pair, err := j.TokenPair(user, user)
+//         [handle error...]
+//         ctx.JSON(pair)
+//     })
+func (j *JWT) Refresh(ctx context.Context, claimsPtr interface{}) error {
+	if j.Blocklist == nil {
+		return ErrNoBlocklist
+	}
+
+	extractors := j.RefreshExtractors
+	if len(extractors) == 0 {
+		extractors = []TokenExtractor{FromJSON("refresh_token"), FromHeader}
+	}
+
+	token := extractToken(ctx, extractors)
+	if token == "" {
+		return ErrMissing
+	}
+
+	claims, err := j.verify(ctx, token, true, claimsPtr)
+	if err != nil {
+		return err
+	}
+
+	return j.Block(claims)
+}
+
+// Block revokes a token of the "claims" until it expires, it requires a `Blocklist`.
+// Use it to logout a user, e.g. with the claims of the `VerifyToken`.
+func (j *JWT) Block(claims Claims) error {
+	if j.Blocklist == nil {
+		return ErrNoBlocklist
+	}
+
+	if claims.ID == "" {
+		return ErrNoID
+	}
+
+	expiry := time.Now().Add(DefaultRefreshMaxAge)
+	if claims.Expiry != nil {
+		expiry = claims.Expiry.Time()
+	}
+
+	return j.Blocklist.Block(claims.ID, expiry)
+}
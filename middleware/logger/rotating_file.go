@@ -0,0 +1,246 @@
+package logger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotatingFileOptions holds the options for the `RotatingFile`.
+type RotatingFileOptions struct {
+	// Filename is the file to write to, the rotated files are renamed to
+	// "Filename.20060102-150405.000" on the same directory
+	// (followed by a ".1", ".2"... sequence when rotated on the same millisecond).
+	Filename string
+	// MaxSize is the maximum size, in bytes, of the file before it gets rotated.
+	//
+	// Defaults to zero, no size rotation.
+	MaxSize int64
+	// RotateEvery is the interval to rotate the file, based on its creation time.
+	//
+	// Defaults to zero, no time rotation.
+	RotateEvery time.Duration
+	// MaxBackups is the maximum number of rotated files to keep, the oldest are removed.
+	//
+	// Defaults to zero, keep all.
+	MaxBackups int
+	// Perm is the permission of the new files.
+	//
+	// Defaults to 0644.
+	Perm os.FileMode
+}
+
+// RotatingFile is an `io.WriteCloser` which writes to a file
+// and rotates it based on its size and age, see `NewRotatingFile`.
+// It is safe for concurrent use.
+type RotatingFile struct {
+	opts RotatingFileOptions
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// NewRotatingFile opens (or creates) the "opts.Filename" for appending
+// and returns a new `RotatingFile`.
+func NewRotatingFile(opts RotatingFileOptions) (*RotatingFile, error) {
+	if opts.Filename == "" {
+		return nil, fmt.Errorf("rotating file: empty filename")
+	}
+
+	if opts.Perm == 0 {
+		opts.Perm = 0644
+	}
+
+	f := &RotatingFile{opts: opts}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.opts.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, f.opts.Perm)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = info.ModTime()
+	if f.size == 0 {
+		f.openedAt = time.Now()
+	}
+
+	return nil
+}
+
+// Write writes "p" to the file, the file is rotated before the write
+// if "p" does not fit to its `MaxSize` or its `RotateEvery` interval passed.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+
+	if f.opts.MaxSize > 0 && f.size+n > f.opts.MaxSize {
+		return true
+	}
+
+	return f.opts.RotateEvery > 0 && time.Since(f.openedAt) >= f.opts.RotateEvery
+}
+
+// Rotate rotates the file immediately.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+
+	return f.rotate()
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	name := f.opts.Filename + "." + time.Now().Format(backupTimeFormat)
+	backup := name
+	// do not overwrite a backup of the same millisecond.
+	for seq := 1; ; seq++ {
+		if _, err := os.Lstat(backup); os.IsNotExist(err) {
+			break
+		}
+		backup = name + "." + strconv.Itoa(seq)
+	}
+
+	if err := os.Rename(f.opts.Filename, backup); err != nil {
+		// keep writing to the original file.
+		if openErr := f.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.removeOldBackups()
+	return nil
+}
+
+// backupTimeFormat is the suffix of the rotated files.
+const backupTimeFormat = "20060102-150405.000"
+
+// removeOldBackups removes the oldest rotated files over the MaxBackups.
+// Only the "Filename.<backupTimeFormat>[.<seq>]" files are considered,
+// not any other file of the directory with the same prefix.
+func (f *RotatingFile) removeOldBackups() {
+	if f.opts.MaxBackups <= 0 {
+		return
+	}
+
+	dir, name := filepath.Split(f.opts.Filename)
+	files, err := ioutil.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return
+	}
+
+	type backupFile struct {
+		path      string
+		timestamp string
+		seq       int
+	}
+
+	prefix := name + "."
+	var backups []backupFile
+	for _, file := range files {
+		if file.IsDir() || !strings.HasPrefix(file.Name(), prefix) {
+			continue
+		}
+
+		suffix := file.Name()[len(prefix):]
+		if len(suffix) < len(backupTimeFormat) {
+			continue
+		}
+
+		timestamp, seq := suffix[:len(backupTimeFormat)], 0
+		if _, err = time.Parse(backupTimeFormat, timestamp); err != nil {
+			continue
+		}
+
+		if rest := suffix[len(timestamp):]; rest != "" {
+			// a backup of the same millisecond, see `rotate`.
+			if rest[0] != '.' {
+				continue
+			}
+			if seq, err = strconv.Atoi(rest[1:]); err != nil || seq <= 0 {
+				continue
+			}
+		}
+
+		backups = append(backups, backupFile{filepath.Join(dir, file.Name()), timestamp, seq})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].timestamp != backups[j].timestamp {
+			return backups[i].timestamp < backups[j].timestamp
+		}
+		return backups[i].seq < backups[j].seq
+	})
+
+	for i := 0; i < len(backups)-f.opts.MaxBackups; i++ {
+		os.Remove(backups[i].path)
+	}
+}
+
+// Close closes the file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,381 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+// Record is a structured access log entry, see `NewStructured`.
+type Record struct {
+	Time          time.Time         `json:"time"`
+	Latency       time.Duration     `json:"latency"`
+	Method        string            `json:"method"`
+	Path          string            `json:"path"`
+	Query         string            `json:"query,omitempty"`
+	Status        int               `json:"status"`
+	IP            string            `json:"ip"`
+	BytesReceived int64             `json:"bytesReceived"`
+	BytesSent     int64             `json:"bytesSent"`
+	RouteName     string            `json:"route,omitempty"`
+	RequestID     string            `json:"requestID,omitempty"`
+	User          string            `json:"user,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	RequestBody   string            `json:"requestBody,omitempty"`
+	ResponseBody  string            `json:"responseBody,omitempty"`
+}
+
+// MarshalJSON encodes the record as JSON, the latency is encoded as a duration string (e.g. "1.5ms").
+func (r *Record) MarshalJSON() ([]byte, error) {
+	type record Record
+	return json.Marshal(struct {
+		*record
+		Latency string `json:"latency"`
+	}{(*record)(r), r.Latency.String()})
+}
+
+// Formatter encodes a `Record` to a single line, see `JSON` and `Logfmt`.
+type Formatter interface {
+	Format(r *Record) ([]byte, error)
+}
+
+// FormatterFunc is a function which completes the `Formatter` interface.
+type FormatterFunc func(r *Record) ([]byte, error)
+
+// Format calls itself.
+func (f FormatterFunc) Format(r *Record) ([]byte, error) {
+	return f(r)
+}
+
+// JSON is a `Formatter` which encodes the records as JSON objects.
+var JSON Formatter = FormatterFunc(func(r *Record) ([]byte, error) {
+	b, err := r.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+})
+
+// Logfmt is a `Formatter` which encodes the records as logfmt lines,
+// e.g. time=2020-08-20T10:00:00Z latency=1.5ms method=GET path=/ status=200 ...
+var Logfmt Formatter = FormatterFunc(func(r *Record) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	pair := func(key, value string) {
+		if buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		if value == "" || strings.ContainsAny(value, " =\"\\\t\r\n") {
+			value = strconv.Quote(value)
+		}
+		buf.WriteString(value)
+	}
+
+	pair("time", r.Time.Format(time.RFC3339Nano))
+	pair("latency", r.Latency.String())
+	pair("method", r.Method)
+	pair("path", r.Path)
+	if r.Query != "" {
+		pair("query", r.Query)
+	}
+	pair("status", strconv.Itoa(r.Status))
+	pair("ip", r.IP)
+	pair("bytesReceived", strconv.FormatInt(r.BytesReceived, 10))
+	pair("bytesSent", strconv.FormatInt(r.BytesSent, 10))
+	if r.RouteName != "" {
+		pair("route", r.RouteName)
+	}
+	if r.RequestID != "" {
+		pair("requestID", r.RequestID)
+	}
+	if r.User != "" {
+		pair("user", r.User)
+	}
+
+	keys := make([]string, 0, len(r.Headers))
+	for key := range r.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		pair("header."+key, r.Headers[key])
+	}
+
+	if r.RequestBody != "" {
+		pair("requestBody", r.RequestBody)
+	}
+	if r.ResponseBody != "" {
+		pair("responseBody", r.ResponseBody)
+	}
+
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+})
+
+// StructuredConfig contains the options for the structured access log middleware, see `NewStructured`.
+type StructuredConfig struct {
+	// Formatter encodes the records.
+	//
+	// Defaults to `JSON`, see `Logfmt` too.
+	Formatter Formatter
+	// Output is the sink which the encoded records are written to, one write per record,
+	// e.g. a `RotatingFile` or an `AsyncWriter`.
+	//
+	// Defaults to os.Stdout.
+	Output io.Writer
+	// Headers are the request headers to include to the records.
+	//
+	// Defaults to empty.
+	Headers []string
+	// RequestBody and ResponseBody report whether the request and response bodies
+	// should be included to the records, truncated to `MaxBodySize`.
+	// Note that the response body is recorded, so it is sent to the client at the end of the request.
+	//
+	// Defaults to false.
+	RequestBody  bool
+	ResponseBody bool
+	// MaxBodySize is the maximum number of bytes of the logged request and response bodies.
+	//
+	// Defaults to 1024.
+	MaxBodySize int
+	// User, if not nil, returns the authenticated user's name of a request.
+	//
+	// Defaults to the username of the basic authentication.
+	User func(ctx context.Context) string
+	// OnError is called when a record failed to be encoded or written.
+	//
+	// Defaults to the application's logger.
+	OnError func(ctx context.Context, err error)
+	// Skippers used to skip the logging i.e by `ctx.Path()` and serve
+	// the next/main handler immediately.
+	Skippers []SkipperFunc
+}
+
+// NewStructured returns a new access log middleware which writes
+// a structured `Record` per request (latency, bytes in/out, route name, request ID,
+// user, selected headers and, optionally, the request and response bodies)
+// to the configured `Output` sink.
+//
+// Usage:
+//
+//     w, _ := logger.NewRotatingFile(logger.RotatingFileOptions{Filename: "access.log", MaxSize: 100 << 20})
+//     output := logger.NewAsyncWriter(w, 4096)
+//     defer output.Close()
+//     app.Use(logger.NewStructured(logger.StructuredConfig{Output: output, Headers: []string{"User-Agent"}}))
+func NewStructured(cfg StructuredConfig) context.Handler {
+	if cfg.Formatter == nil {
+		cfg.Formatter = JSON
+	}
+
+	if cfg.Output == nil {
+		cfg.Output = os.Stdout
+	}
+
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = 1024
+	}
+
+	if cfg.User == nil {
+		cfg.User = func(ctx context.Context) string {
+			username, _, _ := ctx.Request().BasicAuth()
+			return username
+		}
+	}
+
+	c := Config{Skippers: cfg.Skippers}
+	c.buildSkipper()
+
+	return func(ctx context.Context) {
+		if c.skip != nil && c.skip(ctx) {
+			ctx.Next()
+			return
+		}
+
+		r := ctx.Request()
+		body := &countingBody{ReadCloser: r.Body}
+		if cfg.RequestBody {
+			body.capture = cfg.MaxBodySize
+		}
+		r.Body = body
+
+		if cfg.ResponseBody {
+			ctx.Record()
+		}
+
+		start := time.Now()
+		ctx.Next()
+		end := time.Now()
+
+		rec := &Record{
+			Time:          end,
+			Latency:       end.Sub(start),
+			Method:        ctx.Method(),
+			Path:          ctx.Path(),
+			Query:         r.URL.RawQuery,
+			Status:        ctx.GetStatusCode(),
+			IP:            ctx.RemoteAddr(),
+			BytesReceived: body.n,
+			RouteName:     ctx.RouteName(),
+			User:          cfg.User(ctx),
+		}
+
+		if id, ok := ctx.GetID().(string); ok {
+			rec.RequestID = id
+		}
+
+		if written := ctx.ResponseWriter().Written(); written > 0 {
+			rec.BytesSent = int64(written)
+		}
+
+		if len(cfg.Headers) > 0 {
+			rec.Headers = make(map[string]string, len(cfg.Headers))
+			for _, key := range cfg.Headers {
+				if v := ctx.GetHeader(key); v != "" {
+					rec.Headers[key] = v
+				}
+			}
+		}
+
+		if cfg.RequestBody {
+			rec.RequestBody = body.buf.String()
+		}
+
+		if cfg.ResponseBody {
+			if recorder, ok := ctx.IsRecording(); ok {
+				b := recorder.Body()
+				rec.BytesSent = int64(len(b))
+				if len(b) > cfg.MaxBodySize {
+					b = b[:cfg.MaxBodySize]
+				}
+				rec.ResponseBody = string(b)
+			}
+		}
+
+		b, err := cfg.Formatter.Format(rec)
+		if err == nil {
+			_, err = cfg.Output.Write(b)
+		}
+
+		if err != nil {
+			if cfg.OnError != nil {
+				cfg.OnError(ctx, err)
+			} else {
+				ctx.Application().Logger().Errorf("access log: %v", err)
+			}
+		}
+	}
+}
+
+// countingBody counts the bytes read from the request body
+// and keeps the first "capture" bytes of it.
+type countingBody struct {
+	io.ReadCloser
+	n       int64
+	capture int
+	buf     bytes.Buffer
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if remaining := b.capture - b.buf.Len(); remaining > 0 && n > 0 {
+		if n < remaining {
+			remaining = n
+		}
+		b.buf.Write(p[:remaining])
+	}
+
+	return n, err
+}
+
+// AsyncWriter is an `io.Writer` which writes to another one on its own goroutine
+// through a buffered channel, so the handlers are never blocked by a slow sink.
+// When the buffer is full the writes are dropped, see `Dropped`.
+type AsyncWriter struct {
+	w       io.Writer
+	ch      chan []byte
+	done    chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+	dropped uint64 // atomic.
+}
+
+// NewAsyncWriter returns a new `AsyncWriter` which writes to "w"
+// and buffers up to "bufferSize" writes (defaults to 1024).
+func NewAsyncWriter(w io.Writer, bufferSize int) *AsyncWriter {
+	if bufferSize <= 0 {
+		bufferSize = 1024
+	}
+
+	a := &AsyncWriter{
+		w:    w,
+		ch:   make(chan []byte, bufferSize),
+		done: make(chan struct{}),
+	}
+
+	go a.run()
+	return a
+}
+
+func (a *AsyncWriter) run() {
+	for p := range a.ch {
+		a.w.Write(p)
+	}
+
+	close(a.done)
+}
+
+// Write queues a copy of "p", it never blocks.
+// It returns `os.ErrClosed` after `Close`.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return 0, os.ErrClosed
+	}
+
+	select {
+	case a.ch <- append([]byte(nil), p...):
+	default:
+		atomic.AddUint64(&a.dropped, 1)
+	}
+
+	return len(p), nil
+}
+
+// Dropped returns the number of writes dropped because the buffer was full.
+func (a *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Close writes the queued data and closes the underlying writer, if it is an `io.Closer`.
+func (a *AsyncWriter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.ch)
+	a.mu.Unlock()
+
+	<-a.done
+
+	if c, ok := a.w.(io.Closer); ok && a.w != os.Stdout && a.w != os.Stderr {
+		return c.Close()
+	}
+
+	return nil
+}
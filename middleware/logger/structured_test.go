@@ -0,0 +1,200 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/logger"
+	"github.com/kataras/iris/v12/middleware/requestid"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestStructured(t *testing.T) {
+	output := new(syncBuffer)
+
+	app := iris.New()
+	app.Use(requestid.New(func(iris.Context) string { return "req-1" }))
+	app.Use(logger.NewStructured(logger.StructuredConfig{
+		Output:       output,
+		Headers:      []string{"User-Agent"},
+		RequestBody:  true,
+		ResponseBody: true,
+		MaxBodySize:  5,
+	}))
+	app.Post("/users/{id}", func(ctx iris.Context) {
+		body, _ := ctx.GetBody()
+		ctx.Write(body)
+		ctx.WriteString(" world")
+	}).Name = "user"
+
+	e := httptest.New(t, app)
+	e.POST("/users/42").WithQuery("q", "1").WithBasicAuth("kataras", "pass").
+		WithHeader("User-Agent", "test").WithText("hello").
+		Expect().Status(httptest.StatusOK).Body().Equal("hello world")
+
+	var rec map[string]interface{}
+	if err := json.Unmarshal([]byte(output.String()), &rec); err != nil {
+		t.Fatalf("%v: %s", err, output.String())
+	}
+
+	expected := map[string]interface{}{
+		"method":        "POST",
+		"path":          "/users/42",
+		"query":         "q=1",
+		"status":        float64(200),
+		"bytesReceived": float64(5),
+		"bytesSent":     float64(11),
+		"route":         "user",
+		"requestID":     "req-1",
+		"user":          "kataras",
+		"requestBody":   "hello",
+		"responseBody":  "hello",
+	}
+
+	for key, value := range expected {
+		if got := rec[key]; got != value {
+			t.Fatalf("[%s] expected: %v but got: %v", key, value, got)
+		}
+	}
+
+	if got := rec["headers"].(map[string]interface{})["User-Agent"]; got != "test" {
+		t.Fatalf("expected the User-Agent header but got: %v", got)
+	}
+
+	if _, err := time.ParseDuration(rec["latency"].(string)); err != nil {
+		t.Fatalf("expected a latency duration but got: %v", rec["latency"])
+	}
+}
+
+func TestLogfmt(t *testing.T) {
+	b, err := logger.Logfmt.Format(&logger.Record{
+		Time:    time.Date(2020, 8, 20, 10, 0, 0, 0, time.UTC),
+		Latency: 1500 * time.Microsecond,
+		Method:  "GET",
+		Path:    "/",
+		Status:  200,
+		IP:      "::1",
+		Headers: map[string]string{"User-Agent": "Mozilla/5.0 (X11)"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `time=2020-08-20T10:00:00Z latency=1.5ms method=GET path=/ status=200 ip=::1 bytesReceived=0 bytesSent=0 header.User-Agent="Mozilla/5.0 (X11)"` + "\n"
+	if got := string(b); got != expected {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, got)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotatingfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "access.log")
+	// not a backup.
+	other := filename + ".old"
+	ioutil.WriteFile(other, []byte("old"), 0600)
+
+	f, err := logger.NewRotatingFile(logger.RotatingFileOptions{Filename: filename, MaxSize: 10, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err = f.Write([]byte("12345678\n")); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Millisecond) // unique backup names.
+	}
+
+	async := logger.NewAsyncWriter(f, 8)
+	async.Write([]byte("last\n"))
+	if err = async.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := filepath.Glob(filename + ".2*")
+	if expected, got := 2, len(backups); expected != got {
+		t.Fatalf("expected %d backups but got %d", expected, got)
+	}
+
+	if _, err = os.Stat(other); err != nil {
+		t.Fatalf("expected the other files to be kept but got: %v", err)
+	}
+
+	// each write does not fit to the previous one's file.
+	b, _ := ioutil.ReadFile(filename)
+	if expected, got := "last\n", string(b); expected != got {
+		t.Fatalf("expected the async write to be flushed on close: %q but got: %q", expected, got)
+	}
+
+	if _, err = async.Write([]byte("closed")); err == nil {
+		t.Fatal("expected an error after close")
+	}
+}
+
+func TestRotatingFileSameMillisecond(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotatingfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "access.log")
+	f, err := logger.NewRotatingFile(logger.RotatingFileOptions{Filename: filename, MaxSize: 3, MaxBackups: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err = fmt.Fprintf(f, "%d\n", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// no backup is overwritten.
+	files, _ := filepath.Glob(filename + "*")
+	var lines []string
+	for _, name := range files {
+		b, _ := ioutil.ReadFile(name)
+		lines = append(lines, string(b))
+	}
+	sort.Strings(lines)
+
+	if expected, got := "0\n1\n2\n3\n4\n", strings.Join(lines, ""); expected != got {
+		t.Fatalf("expected all the writes to be kept: %q but got: %q", expected, got)
+	}
+}
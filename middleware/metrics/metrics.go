@@ -0,0 +1,449 @@
+// Package metrics provides a middleware which records HTTP request metrics,
+// labelled by method, status class and route template, and a handler
+// which exposes them, along with Go runtime and custom metrics,
+// in the Prometheus text exposition format, without the Prometheus client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kataras/iris/v12/cache/client"
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/sessions"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/metrics.*", "iris.metrics")
+}
+
+// ContentType is the content type of the `Handler`'s response.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// UnmatchedRoute is the "route" label value of requests that did not match any route (e.g. 404).
+const UnmatchedRoute = "unmatched"
+
+var (
+	// DefaultDurationBuckets are the default latency histogram buckets, in seconds.
+	DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets are the default response size histogram buckets, in bytes.
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1e6, 1e7}
+)
+
+// Options holds the options for the `Metrics`.
+type Options struct {
+	// Namespace is the prefix of the metric names, e.g. "iris_http_requests_total".
+	//
+	// Defaults to "iris".
+	Namespace string
+	// DurationBuckets are the sorted upper bounds of the latency histogram buckets, in seconds.
+	//
+	// Defaults to `DefaultDurationBuckets`.
+	DurationBuckets []float64
+	// SizeBuckets are the sorted upper bounds of the response size histogram buckets, in bytes.
+	//
+	// Defaults to `DefaultSizeBuckets`.
+	SizeBuckets []float64
+	// DisableRuntime disables the Go runtime metrics (goroutines, memory and GC statistics).
+	//
+	// Defaults to false.
+	DisableRuntime bool
+}
+
+type seriesKey struct {
+	method, code, route string
+}
+
+type series struct {
+	duration *histogram
+	size     *histogram
+}
+
+type funcMetric struct {
+	name, help, typ string
+	labels          string // optional.
+	fn              func() float64
+}
+
+// Metrics records the HTTP request metrics through its `Serve` middleware
+// and exposes them through its `Handler`.
+//
+// Usage:
+//
+//     m := metrics.New()
+//     m.Sessions(sess)
+//     m.Cache("products", productsCache)
+//     app.UseGlobal(m.Serve)
+//     app.OnAnyErrorCode(errorHandler)
+//     app.Get("/metrics", m.Handler)
+type Metrics struct {
+	opts Options
+
+	inFlight int64 // atomic.
+
+	mu     sync.Mutex
+	series map[seriesKey]*series
+	funcs  []funcMetric
+}
+
+// New returns a new `Metrics` based on the optional "opts".
+func New(opts ...Options) *Metrics {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.Namespace == "" {
+		o.Namespace = "iris"
+	}
+
+	if len(o.DurationBuckets) == 0 {
+		o.DurationBuckets = DefaultDurationBuckets
+	}
+
+	if len(o.SizeBuckets) == 0 {
+		o.SizeBuckets = DefaultSizeBuckets
+	}
+
+	return &Metrics{
+		opts:   o,
+		series: make(map[seriesKey]*series),
+	}
+}
+
+// GaugeFunc registers a custom gauge whose value is reported by "fn" on each scrape,
+// e.g. the number of active sessions or cache entries. The name is prefixed with the namespace.
+func (m *Metrics) GaugeFunc(name, help string, fn func() float64) {
+	m.addFunc(name, help, "gauge", fn)
+}
+
+// CounterFunc registers a custom counter whose value is reported by "fn" on each scrape,
+// e.g. the number of cache hits. The name is prefixed with the namespace.
+func (m *Metrics) CounterFunc(name, help string, fn func() float64) {
+	m.addFunc(name, help, "counter", fn)
+}
+
+// Sessions registers the "sessions_active" gauge of the "sess" sessions manager.
+func (m *Metrics) Sessions(sess *sessions.Sessions) {
+	m.GaugeFunc("sessions_active", "Number of active sessions.", func() float64 {
+		return float64(sess.Len())
+	})
+}
+
+// Cache registers the "cache_entries" gauge and the "cache_hits_total" and "cache_misses_total"
+// counters of the "cache" handler (see `cache.Cache`), labelled by its "name".
+func (m *Metrics) Cache(name string, cache *client.Handler) {
+	labels := `cache="` + escapeLabel(name) + `"`
+
+	m.addLabelledFunc("cache_entries", "Number of cached responses.", "gauge", labels, func() float64 {
+		return float64(cache.Stats().Entries)
+	})
+	m.addLabelledFunc("cache_hits_total", "Total number of requests served from the cache.", "counter", labels, func() float64 {
+		return float64(cache.Stats().Hits)
+	})
+	m.addLabelledFunc("cache_misses_total", "Total number of cacheable requests not served from the cache.", "counter", labels, func() float64 {
+		return float64(cache.Stats().Misses)
+	})
+}
+
+func (m *Metrics) addFunc(name, help, typ string, fn func() float64) {
+	m.addLabelledFunc(name, help, typ, "", fn)
+}
+
+func (m *Metrics) addLabelledFunc(name, help, typ, labels string, fn func() float64) {
+	m.mu.Lock()
+	m.funcs = append(m.funcs, funcMetric{name: m.opts.Namespace + "_" + name, help: help, typ: typ, labels: labels, fn: fn})
+	m.mu.Unlock()
+}
+
+// Serve is the middleware which records the metrics of a request.
+// Register it through `app.UseGlobal` or `app.Use`, the unmatched requests (e.g. 404)
+// are recorded through the global middleware of the registered error handlers (see `app.OnErrorCode`).
+//
+// The "route" label is the template path of the matched route (e.g. "/users/{id:uint64}"),
+// not the request path, so the number of series stays bounded.
+func (m *Metrics) Serve(ctx context.Context) {
+	atomic.AddInt64(&m.inFlight, 1)
+	defer atomic.AddInt64(&m.inFlight, -1)
+
+	start := time.Now()
+	ctx.Next()
+	elapsed := time.Since(start)
+
+	route := UnmatchedRoute
+	if r := ctx.GetCurrentRoute(); r != nil && r.StatusErrorCode() == 0 {
+		route = r.Path()
+	}
+
+	size := 0
+	if written := ctx.ResponseWriter().Written(); written > 0 {
+		size = written
+	}
+
+	m.observe(seriesKey{
+		method: methodLabel(ctx.Method()),
+		code:   statusClass(ctx.GetStatusCode()),
+		route:  route,
+	}, elapsed.Seconds(), float64(size))
+}
+
+func (m *Metrics) observe(key seriesKey, duration, size float64) {
+	m.mu.Lock()
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{
+			duration: newHistogram(m.opts.DurationBuckets),
+			size:     newHistogram(m.opts.SizeBuckets),
+		}
+		m.series[key] = s
+	}
+
+	s.duration.observe(duration)
+	s.size.observe(size)
+	m.mu.Unlock()
+}
+
+// methodLabel returns the "method" label of a request method,
+// the non-standard ones (chosen by the clients on unmatched routes) share the "OTHER" label.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "unknown"
+	}
+
+	return strconv.Itoa(code/100) + "xx"
+}
+
+// Handler writes the metrics in the Prometheus text format, register it on a "/metrics" route.
+func (m *Metrics) Handler(ctx context.Context) {
+	ctx.Header(context.ContentTypeHeaderKey, ContentType)
+	if _, err := m.WriteTo(ctx); err != nil {
+		ctx.Application().Logger().Debugf("metrics: %v", err)
+	}
+}
+
+// WriteTo writes the metrics in the Prometheus text format to "w".
+// The series are copied first, so a slow "w" does not block the recording of the requests.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	type snapshot struct {
+		key            seriesKey
+		duration, size histogram
+	}
+
+	m.mu.Lock()
+	snapshots := make([]snapshot, 0, len(m.series))
+	for key, s := range m.series {
+		snapshots = append(snapshots, snapshot{key: key, duration: s.duration.clone(), size: s.size.clone()})
+	}
+	funcs := append([]funcMetric(nil), m.funcs...)
+	m.mu.Unlock()
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		a, b := snapshots[i].key, snapshots[j].key
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.code < b.code
+	})
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	ns := m.opts.Namespace
+
+	name := ns + "_http_requests_total"
+	header(cw, name, "Total number of HTTP requests.", "counter")
+	for _, s := range snapshots {
+		fmt.Fprintf(cw, "%s{%s} %d\n", name, s.key.labels(), s.duration.count)
+	}
+
+	name = ns + "_http_request_duration_seconds"
+	header(cw, name, "Latency of HTTP requests in seconds.", "histogram")
+	for _, s := range snapshots {
+		s.duration.write(cw, name, s.key.labels())
+	}
+
+	name = ns + "_http_response_size_bytes"
+	header(cw, name, "Size of HTTP responses in bytes.", "histogram")
+	for _, s := range snapshots {
+		s.size.write(cw, name, s.key.labels())
+	}
+
+	name = ns + "_http_requests_in_flight"
+	header(cw, name, "Number of HTTP requests currently being served.", "gauge")
+	fmt.Fprintf(cw, "%s %d\n", name, atomic.LoadInt64(&m.inFlight))
+
+	writeFuncs(cw, funcs)
+
+	if !m.opts.DisableRuntime {
+		writeRuntime(cw)
+	}
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+
+	return cw.n, cw.err
+}
+
+// writeFuncs writes the custom metrics, the ones of the same name (and different labels)
+// are grouped under a single header.
+func writeFuncs(w io.Writer, funcs []funcMetric) {
+	written := make(map[string]struct{}, len(funcs))
+	for i, f := range funcs {
+		if _, ok := written[f.name]; ok {
+			continue
+		}
+		written[f.name] = struct{}{}
+
+		header(w, f.name, f.help, f.typ)
+		for _, g := range funcs[i:] {
+			if g.name != f.name {
+				continue
+			}
+
+			if g.labels == "" {
+				fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.fn()))
+			} else {
+				fmt.Fprintf(w, "%s{%s} %s\n", g.name, g.labels, formatFloat(g.fn()))
+			}
+		}
+	}
+}
+
+func writeRuntime(w io.Writer) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	header(w, "go_info", "Information about the Go environment.", "gauge")
+	fmt.Fprintf(w, "go_info{version=%q} 1\n", runtime.Version())
+
+	metrics := []struct {
+		name, help, typ string
+		value           float64
+	}{
+		{"go_goroutines", "Number of goroutines that currently exist.", "gauge", float64(runtime.NumGoroutine())},
+		{"go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", "gauge", float64(stats.Alloc)},
+		{"go_memstats_alloc_bytes_total", "Total number of bytes allocated, even if freed.", "counter", float64(stats.TotalAlloc)},
+		{"go_memstats_sys_bytes", "Number of bytes obtained from system.", "gauge", float64(stats.Sys)},
+		{"go_memstats_mallocs_total", "Total number of mallocs.", "counter", float64(stats.Mallocs)},
+		{"go_memstats_frees_total", "Total number of frees.", "counter", float64(stats.Frees)},
+		{"go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", "gauge", float64(stats.HeapInuse)},
+		{"go_memstats_heap_objects", "Number of allocated objects.", "gauge", float64(stats.HeapObjects)},
+		{"go_memstats_gc_cycles_total", "Number of completed GC cycles.", "counter", float64(stats.NumGC)},
+		{"go_memstats_gc_pause_seconds_total", "Total GC pause duration in seconds.", "counter", float64(stats.PauseTotalNs) / 1e9},
+		{"go_memstats_last_gc_time_seconds", "Number of seconds since 1970 of last garbage collection.", "gauge", float64(stats.LastGC) / 1e9},
+	}
+
+	for _, metric := range metrics {
+		header(w, metric.name, metric.help, metric.typ)
+		fmt.Fprintf(w, "%s %s\n", metric.name, formatFloat(metric.value))
+	}
+}
+
+func header(w io.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, typ)
+}
+
+func (key seriesKey) labels() string {
+	return `method="` + escapeLabel(key.method) + `",code="` + key.code + `",route="` + escapeLabel(key.route) + `"`
+}
+
+var (
+	labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelReplacer.Replace(s) }
+
+func escapeHelp(s string) string { return helpReplacer.Replace(s) }
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, +1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64 // per bucket, not cumulative.
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// clone returns a copy of the histogram, its buckets are shared as they are never modified.
+func (h *histogram) clone() histogram {
+	c := *h
+	c.counts = append([]uint64(nil), h.counts...)
+	return c
+}
+
+func (h *histogram) observe(v float64) {
+	idx := sort.SearchFloat64s(h.buckets, v) // the first bucket with upper bound >= v.
+	if idx < len(h.counts) {
+		h.counts[idx]++
+	}
+
+	h.sum += v
+	h.count++
+}
+
+func (h *histogram) write(w io.Writer, name, labels string) {
+	var cumulative uint64
+	for i, upperBound := range h.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(upperBound), cumulative)
+	}
+
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	w.err = err
+	return n, err
+}
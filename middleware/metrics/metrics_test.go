@@ -0,0 +1,100 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/cache"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/metrics"
+	"github.com/kataras/iris/v12/sessions"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New(metrics.Options{
+		DurationBuckets: []float64{0.5, 1},
+		SizeBuckets:     []float64{1, 10},
+	})
+	m.GaugeFunc("queue_length", "Number of queued jobs.", func() float64 { return 3 })
+
+	sess := sessions.New(sessions.Config{Cookie: "sid"})
+	m.Sessions(sess)
+
+	productsCache := cache.Cache(time.Minute)
+	m.Cache("products", productsCache)
+
+	app := iris.New()
+	app.UseGlobal(m.Serve)
+	app.OnErrorCode(iris.StatusNotFound, func(ctx iris.Context) {
+		ctx.WriteString("not found")
+	})
+	app.Get("/users/{id:uint64}", func(ctx iris.Context) {
+		ctx.WriteString("user")
+	})
+	app.Get("/products", productsCache.ServeHTTP, func(ctx iris.Context) {
+		ctx.WriteString("products")
+	})
+	app.Get("/login", func(ctx iris.Context) {
+		sess.Start(ctx).Set("user", "kataras")
+	})
+	app.Get("/metrics", m.Handler)
+
+	e := httptest.New(t, app)
+	e.GET("/login").Expect().Status(httptest.StatusOK)
+	e.GET("/products").Expect().Status(httptest.StatusOK)
+	e.GET("/products").Expect().Status(httptest.StatusOK).Body().Equal("products")
+	e.GET("/users/1").Expect().Status(httptest.StatusOK)
+	e.GET("/users/2").Expect().Status(httptest.StatusOK)
+	e.GET("/notfound").Expect().Status(httptest.StatusNotFound)
+	e.Request("FOO", "/notfound").Expect().Status(httptest.StatusNotFound)
+	e.Request("BAR", "/notfound").Expect().Status(httptest.StatusNotFound)
+
+	body := e.GET("/metrics").Expect().Status(httptest.StatusOK).
+		ContentType("text/plain", "utf-8").Body().Raw()
+
+	expectedLines := []string{
+		"# TYPE iris_http_requests_total counter",
+		`iris_http_requests_total{method="GET",code="2xx",route="/users/{id:uint64}"} 2`,
+		`iris_http_requests_total{method="GET",code="4xx",route="unmatched"} 1`,
+		// the non-standard methods share a series.
+		`iris_http_requests_total{method="OTHER",code="4xx",route="unmatched"} 2`,
+		"# TYPE iris_http_request_duration_seconds histogram",
+		`iris_http_request_duration_seconds_bucket{method="GET",code="2xx",route="/users/{id:uint64}",le="0.5"} 2`,
+		`iris_http_request_duration_seconds_bucket{method="GET",code="2xx",route="/users/{id:uint64}",le="+Inf"} 2`,
+		`iris_http_request_duration_seconds_count{method="GET",code="2xx",route="/users/{id:uint64}"} 2`,
+		`iris_http_response_size_bytes_bucket{method="GET",code="2xx",route="/users/{id:uint64}",le="1"} 0`,
+		`iris_http_response_size_bytes_bucket{method="GET",code="2xx",route="/users/{id:uint64}",le="10"} 2`,
+		`iris_http_response_size_bytes_sum{method="GET",code="2xx",route="/users/{id:uint64}"} 8`,
+		"iris_http_requests_in_flight 1", // the metrics request itself.
+		"# TYPE iris_queue_length gauge",
+		"iris_queue_length 3",
+		"# TYPE iris_sessions_active gauge",
+		"iris_sessions_active 1",
+		"# TYPE iris_cache_entries gauge",
+		`iris_cache_entries{cache="products"} 1`,
+		`iris_cache_hits_total{cache="products"} 1`,
+		`iris_cache_misses_total{cache="products"} 1`,
+		"# TYPE go_goroutines gauge",
+	}
+
+	lines := strings.Split(body, "\n")
+	for _, expected := range expectedLines {
+		found := false
+		for _, line := range lines {
+			if line == expected {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			t.Fatalf("expected line:\n%s\nin:\n%s", expected, body)
+		}
+	}
+
+	if strings.Contains(body, "/users/1") {
+		t.Fatalf("expected route templates only as labels but got:\n%s", body)
+	}
+}
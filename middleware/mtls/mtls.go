@@ -0,0 +1,182 @@
+// Package mtls provides a middleware which maps the verified TLS client certificate
+// of a request to an identity, see the `host.ClientAuth` Configurator to enable mutual TLS.
+package mtls
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/mtls.*", "iris.mtls")
+}
+
+// IdentityContextKey is the context key which the client's `*Identity` is stored.
+const IdentityContextKey = "iris.mtls.identity"
+
+// Identity holds the information of a verified client certificate.
+type Identity struct {
+	// CommonName is the subject's common name.
+	CommonName string `json:"commonName"`
+	// Organization is the subject's organization(s).
+	Organization []string `json:"organization,omitempty"`
+	// OrganizationalUnit is the subject's organizational unit(s).
+	OrganizationalUnit []string `json:"organizationalUnit,omitempty"`
+	// DNSNames, EmailAddresses and URIs are the Subject Alternative Names.
+	DNSNames       []string `json:"dnsNames,omitempty"`
+	EmailAddresses []string `json:"emailAddresses,omitempty"`
+	URIs           []string `json:"uris,omitempty"`
+	// SPIFFEID is the "spiffe://trust-domain/path" URI SAN, if any.
+	SPIFFEID string `json:"spiffeID,omitempty"`
+	// SerialNumber of the certificate, as a decimal string.
+	SerialNumber string `json:"serialNumber"`
+	// Certificate is the client's leaf certificate.
+	Certificate *x509.Certificate `json:"-"`
+}
+
+// TrustDomain returns the trust domain of the SPIFFE ID (e.g. "example.org"), if any.
+func (id *Identity) TrustDomain() string {
+	if u, err := url.Parse(id.SPIFFEID); err == nil {
+		return u.Host
+	}
+
+	return ""
+}
+
+// NewIdentity returns the Identity of a client certificate.
+func NewIdentity(cert *x509.Certificate) *Identity {
+	id := &Identity{
+		CommonName:         cert.Subject.CommonName,
+		Organization:       cert.Subject.Organization,
+		OrganizationalUnit: cert.Subject.OrganizationalUnit,
+		DNSNames:           cert.DNSNames,
+		EmailAddresses:     cert.EmailAddresses,
+		SerialNumber:       cert.SerialNumber.String(),
+		Certificate:        cert,
+	}
+
+	for _, u := range cert.URIs {
+		id.URIs = append(id.URIs, u.String())
+		if id.SPIFFEID == "" && strings.EqualFold(u.Scheme, "spiffe") {
+			id.SPIFFEID = u.String()
+		}
+	}
+
+	return id
+}
+
+// Options holds the options for the mtls middleware.
+type Options struct {
+	// Allow, if not nil, reports whether the identity is allowed to access the resources,
+	// otherwise the request is stopped with 403 Forbidden.
+	// See the `AllowSPIFFE` and `AllowCommonNames` helpers.
+	Allow func(ctx context.Context, id *Identity) bool
+	// Map, if not nil, converts the identity to a custom (e.g. a User) value
+	// which is registered as a dependency for the next handlers too,
+	// can be retrieved through `GetValue`. A non-nil error stops the request with 403 Forbidden.
+	Map func(ctx context.Context, id *Identity) (interface{}, error)
+	// Optional, when true, requests without a verified client certificate
+	// are served without an identity instead of a 401 Unauthorized.
+	Optional bool
+}
+
+const valueContextKey = "iris.mtls.value"
+
+// New returns a new mtls middleware.
+// It stores the `*Identity` of the verified client certificate to the request's values (see `Get`)
+// and registers it as a dependency, so handlers registered through `Party.ConfigureContainer`
+// and MVC controllers can accept it as input argument (or field).
+//
+// Requests without a verified client certificate are stopped with 401 Unauthorized.
+func New(opts ...Options) context.Handler {
+	var options Options
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
+	return func(ctx context.Context) {
+		r := ctx.Request()
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			if options.Optional {
+				ctx.Next()
+				return
+			}
+
+			ctx.StopWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		id := NewIdentity(r.TLS.VerifiedChains[0][0])
+		if options.Allow != nil && !options.Allow(ctx, id) {
+			ctx.StopWithStatus(http.StatusForbidden)
+			return
+		}
+
+		ctx.Values().Set(IdentityContextKey, id)
+		ctx.RegisterDependency(id)
+
+		if options.Map != nil {
+			v, err := options.Map(ctx, id)
+			if err != nil {
+				ctx.StopWithError(http.StatusForbidden, err)
+				return
+			}
+
+			ctx.Values().Set(valueContextKey, v)
+			ctx.RegisterDependency(v)
+		}
+
+		ctx.Next()
+	}
+}
+
+// Get returns the client's identity, stored by the middleware, or nil.
+func Get(ctx context.Context) *Identity {
+	if id, ok := ctx.Values().Get(IdentityContextKey).(*Identity); ok {
+		return id
+	}
+
+	return nil
+}
+
+// GetValue returns the custom value of the `Options.Map`, or nil.
+func GetValue(ctx context.Context) interface{} {
+	return ctx.Values().Get(valueContextKey)
+}
+
+// AllowSPIFFE returns an `Options.Allow` which allows the identities
+// whose SPIFFE ID is one of the "ids" or belongs to one of the trust domains,
+// e.g. "spiffe://example.org/ns/prod/sa/api" or "spiffe://example.org".
+func AllowSPIFFE(idsOrTrustDomains ...string) func(context.Context, *Identity) bool {
+	return func(_ context.Context, id *Identity) bool {
+		if id.SPIFFEID == "" {
+			return false
+		}
+
+		for _, allowed := range idsOrTrustDomains {
+			if id.SPIFFEID == allowed || "spiffe://"+id.TrustDomain() == strings.TrimSuffix(allowed, "/") {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// AllowCommonNames returns an `Options.Allow` which allows the identities
+// whose subject's common name is one of the "names".
+func AllowCommonNames(names ...string) func(context.Context, *Identity) bool {
+	return func(_ context.Context, id *Identity) bool {
+		for _, name := range names {
+			if id.CommonName == name {
+				return true
+			}
+		}
+
+		return false
+	}
+}
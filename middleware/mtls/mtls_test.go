@@ -0,0 +1,134 @@
+package mtls_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/middleware/mtls"
+)
+
+type user struct {
+	Name string
+}
+
+func newCert(t *testing.T, tmpl, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
+
+func TestMTLS(t *testing.T) {
+	ca, caKey := newCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+
+	newClient := func(commonName, spiffeID string) *http.Client {
+		spiffe, _ := url.Parse(spiffeID)
+		cert, key := newCert(t, &x509.Certificate{
+			SerialNumber: big.NewInt(time.Now().UnixNano()),
+			Subject:      pkix.Name{CommonName: commonName},
+			URIs:         []*url.URL{spiffe},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}, ca, caKey)
+
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []tls.Certificate{{Certificate: [][]byte{cert.Raw}, PrivateKey: key}},
+		}}}
+	}
+
+	app := iris.New()
+	app.Use(mtls.New(mtls.Options{
+		Allow: mtls.AllowSPIFFE("spiffe://example.org"),
+		Map: func(ctx iris.Context, id *mtls.Identity) (interface{}, error) {
+			return user{Name: id.CommonName}, nil
+		},
+	}))
+	app.Get("/", func(ctx iris.Context) {
+		id := mtls.Get(ctx)
+		ctx.WriteString(id.CommonName + " " + id.SPIFFEID)
+	})
+	app.ConfigureContainer(func(api *iris.APIContainer) {
+		api.Get("/user", func(u user, id *mtls.Identity) string {
+			return u.Name + " " + id.TrustDomain()
+		})
+	})
+
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	srv := httptest.NewUnstartedServer(app)
+	srv.TLS = &tls.Config{ClientCAs: pool, ClientAuth: tls.VerifyClientCertIfGiven}
+	srv.StartTLS()
+	defer srv.Close()
+
+	get := func(client *http.Client, path string) (int, string) {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	client := newClient("api", "spiffe://example.org/ns/prod/sa/api")
+	if code, body := get(client, "/"); code != http.StatusOK || body != "api spiffe://example.org/ns/prod/sa/api" {
+		t.Fatalf("unexpected response: %d: %q", code, body)
+	}
+
+	if code, body := get(client, "/user"); code != http.StatusOK || body != "api example.org" {
+		t.Fatalf("unexpected response: %d: %q", code, body)
+	}
+
+	if code, _ := get(newClient("other", "spiffe://other.org/sa/api"), "/"); code != http.StatusForbidden {
+		t.Fatalf("expected status code: %d but got: %d", http.StatusForbidden, code)
+	}
+
+	noCertClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	if code, _ := get(noCertClient, "/"); code != http.StatusUnauthorized {
+		t.Fatalf("expected status code: %d but got: %d", http.StatusUnauthorized, code)
+	}
+}
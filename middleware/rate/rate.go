@@ -4,12 +4,10 @@ package rate
 
 import (
 	"math"
-	"sync"
+	"strconv"
 	"time"
 
 	"github.com/kataras/iris/v12/context"
-
-	"golang.org/x/time/rate"
 )
 
 func init() {
@@ -21,6 +19,8 @@ func init() {
 // * ExceedHandler
 // * ClientData
 // * PurgeEvery
+// * Storage
+// * UseAlgorithm
 type Option func(*Limiter)
 
 // ExceedHandler is an `Option` that can be passed at the `Limit` package-level function.
@@ -47,9 +47,10 @@ func ClientData(clientDataFunc func(ctx context.Context) interface{}) Option {
 // E.g. Limit(..., PurgeEvery(time.Minute, 5*time.Minute)) to
 // check every 1 minute if a client's last visit was 5 minutes ago ("old" entry)
 // and remove it from the memory.
+//
+// It has effect only on the default `MemoryStore`, the rest of the stores expire the clients themselves.
 func PurgeEvery(every time.Duration, maxLifetime time.Duration) Option {
 	condition := func(c *Client) bool {
-		// for a custom purger the end-developer may use the c.Data filled from a `ClientData` option.
 		return time.Since(c.LastSeen()) > maxLifetime
 	}
 
@@ -64,6 +65,27 @@ func PurgeEvery(every time.Duration, maxLifetime time.Duration) Option {
 	}
 }
 
+// Storage is an `Option` that can be passed at the `Limit` package-level function.
+// It sets the store of the clients' state, e.g. a redis one
+// to share the limits across the application's replicas.
+//
+// Defaults to a `NewMemoryStore()`.
+func Storage(store Store) Option {
+	return func(l *Limiter) {
+		l.store = store
+	}
+}
+
+// UseAlgorithm is an `Option` that can be passed at the `Limit` package-level function.
+// It sets the algorithm of the limiter's quota, see `LimitQuota` too.
+//
+// Defaults to `TokenBucket`.
+func UseAlgorithm(algorithm Algorithm) Option {
+	return func(l *Limiter) {
+		l.quota.Algorithm = algorithm
+	}
+}
+
 // Every converts a minimum time interval between events to a limit.
 // Usage: Limit(Every(1*time.Minute), 3, options...)
 func Every(interval time.Duration) float64 {
@@ -82,40 +104,61 @@ type (
 		clientDataFunc func(ctx context.Context) interface{} // fill the Client's Data field.
 		exceedHandler  context.Handler                       // when too many requests.
 
-		limit     rate.Limit
-		burstSize int
-
-		clients map[string]*Client
-		mu      sync.RWMutex // mutex for clients.
+		quota Quota
+		store Store
+		// unlimited reports whether the limit is `Inf`, all requests are allowed.
+		unlimited bool
 	}
 
-	// Client holds some request information and the rate limiter itself.
+	// Client holds some request information and the result of its rate limit.
 	// It can be retrieved by the `Get` package-level function.
-	// It can be used to manually add RateLimit response headers.
 	Client struct {
-		ID      string
-		Data    interface{}
-		Limiter *rate.Limiter
+		ID     string
+		Data   interface{}
+		Quota  Quota
+		Result Result
 
 		lastSeen time.Time
-		mu       sync.RWMutex // mutex for lastSeen.
 	}
 )
 
 // Inf is the infinite rate limit; it allows all events (even if burst is zero).
 const Inf = math.MaxFloat64
 
+// QuotaMetaKey is the route's metadata key of a per-route `Quota`, e.g.
+// app.Post("/login", handler).SetMeta(rate.QuotaMetaKey, rate.PerMinute(5)).
+// The requests of a route with its own quota are limited separately from the rest.
+const QuotaMetaKey = "rate.quota"
+
 // Limit returns a new rate limiter handler that allows requests up to rate "limit" and permits
 // bursts of at most "burst" tokens. See `rate.SetKey(ctx, key string)` and `rate.Get` too.
 //
 // E.g. Limit(1, 5) to allow 1 request per second, with a maximum burst size of 5.
 //
-// See `ExceedHandler`, `ClientData` and `PurgeEvery` for the available "options".
+// See `ExceedHandler`, `ClientData`, `PurgeEvery`, `Storage` and `UseAlgorithm` for the available "options".
 func Limit(limit float64, burst int, options ...Option) context.Handler {
+	q := Quota{Limit: burst}
+	if limit > 0 && limit != Inf {
+		q.Window = time.Duration(float64(burst) / limit * float64(time.Second))
+	}
+
+	return newLimiter(q, limit == Inf, options).serveHTTP
+}
+
+// LimitQuota returns a new rate limiter handler based on a `Quota`, e.g.
+// LimitQuota(rate.Quota{Limit: 100, Window: time.Minute, Algorithm: rate.SlidingWindow}).
+//
+// The handler sets the "RateLimit-Limit", "RateLimit-Remaining" and "RateLimit-Reset" response headers
+// and the "Retry-After" one on exceeded requests (429 Too Many Requests).
+// See `QuotaMetaKey` for per-route quotas.
+func LimitQuota(quota Quota, options ...Option) context.Handler {
+	return newLimiter(quota, false, options).serveHTTP
+}
+
+func newLimiter(quota Quota, unlimited bool, options []Option) *Limiter {
 	l := &Limiter{
-		clients:   make(map[string]*Client),
-		limit:     rate.Limit(limit),
-		burstSize: burst,
+		quota:     quota,
+		unlimited: unlimited,
 		exceedHandler: func(ctx context.Context) {
 			ctx.StopWithStatus(429) // Too Many Requests.
 		},
@@ -125,50 +168,63 @@ func Limit(limit float64, burst int, options ...Option) context.Handler {
 		opt(l)
 	}
 
-	return l.serveHTTP
+	if l.store == nil {
+		l.store = NewMemoryStore()
+	}
+
+	return l
 }
 
 // Purge removes client entries from the memory based on the given "condition".
+// It has effect only on the default `MemoryStore`.
 func (l *Limiter) Purge(condition func(*Client) bool) {
-	l.mu.Lock()
-	for id, client := range l.clients {
-		if condition(client) {
-			delete(l.clients, id)
-		}
+	if s, ok := l.store.(*MemoryStore); ok {
+		s.Purge(func(key string, lastSeen time.Time) bool {
+			return condition(&Client{ID: key, Quota: l.quota, lastSeen: lastSeen})
+		})
 	}
-	l.mu.Unlock()
 }
 
 func (l *Limiter) serveHTTP(ctx context.Context) {
+	if l.unlimited {
+		ctx.Next()
+		return
+	}
+
 	id := getIdentifier(ctx)
-	l.mu.RLock()
-	client, ok := l.clients[id]
-	l.mu.RUnlock()
-
-	if !ok {
-		client = &Client{
-			ID:      id,
-			Limiter: rate.NewLimiter(l.limit, l.burstSize),
-		}
+	client := &Client{ID: id, Quota: l.quota, lastSeen: time.Now()}
+	key := id
 
-		if l.clientDataFunc != nil {
-			client.Data = l.clientDataFunc(ctx)
+	if r := ctx.GetCurrentRoute(); r != nil {
+		if q, ok := r.Meta().Get(QuotaMetaKey).(Quota); ok {
+			client.Quota = q
+			key = r.Name() + "|" + id
 		}
-
-		//  if l.store(ctx, client) {
-		// ^ no, let's keep it simple.
-		l.mu.Lock()
-		l.clients[id] = client
-		l.mu.Unlock()
 	}
 
-	client.mu.Lock()
-	client.lastSeen = time.Now()
-	client.mu.Unlock()
+	if l.clientDataFunc != nil {
+		client.Data = l.clientDataFunc(ctx)
+	}
 
 	ctx.Values().Set(clientContextKey, client)
 
-	if client.Limiter.Allow() {
+	if !client.Quota.Valid() {
+		// e.g. a zero burst, nothing is allowed.
+		client.Result = Result{Limit: client.Quota.Limit}
+	} else {
+		result, err := l.store.Take(key, client.Quota, client.lastSeen)
+		if err != nil {
+			// fail open, the store is not available.
+			ctx.Application().Logger().Errorf("rate: %v", err)
+			ctx.Next()
+			return
+		}
+		client.Result = result
+	}
+
+	setHeaders(ctx, client.Result)
+
+	if client.Result.Allowed {
 		ctx.Next()
 		return
 	}
@@ -178,6 +234,23 @@ func (l *Limiter) serveHTTP(ctx context.Context) {
 	}
 }
 
+// setHeaders sets the RateLimit header fields of the IETF draft
+// (https://datatracker.ietf.org/doc/draft-ietf-httpapi-ratelimit-headers/)
+// and the Retry-After header on exceeded requests.
+func setHeaders(ctx context.Context, r Result) {
+	ctx.Header("RateLimit-Limit", strconv.Itoa(r.Limit))
+	ctx.Header("RateLimit-Remaining", strconv.Itoa(r.Remaining))
+	ctx.Header("RateLimit-Reset", strconv.FormatInt(seconds(r.Reset), 10))
+	if !r.Allowed {
+		ctx.Header("Retry-After", strconv.FormatInt(seconds(r.RetryAfter), 10))
+	}
+}
+
+// seconds rounds up "d" to seconds.
+func seconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
+
 const identifierContextKey = "iris.ratelimit.identifier"
 
 // SetIdentifier can be called manually from a handler or a middleare
@@ -197,11 +270,8 @@ func getIdentifier(ctx context.Context) string {
 const clientContextKey = "iris.ratelimit.client"
 
 // Get returns the current rate limited `Client`.
-// Use it when you want to log or add response headers based on the current request limitation.
-//
-// You can read more about X-RateLimit response headers at:
-// https://tools.ietf.org/id/draft-polli-ratelimit-headers-00.html.
-// A good example of that is the GitHub API itself: https://developer.github.com/v3/#rate-limiting
+// Use it when you want to log the current request limitation, e.g. its `Result`.
+// The RateLimit response headers are already set by the limiter.
 func Get(ctx context.Context) *Client {
 	if v := ctx.Values().Get(clientContextKey); v != nil {
 		if c, ok := v.(*Client); ok {
@@ -214,26 +284,25 @@ func Get(ctx context.Context) *Client {
 
 // LastSeen reports the last Client's visit.
 func (c *Client) LastSeen() time.Time {
-	c.mu.RLock()
-	t := c.lastSeen
-	c.mu.RUnlock()
-	return t
+	return c.lastSeen
 }
 
 // TokensFromDuration is a unit conversion function from a time duration to the number of tokens
-// which could be accumulated during that duration at a rate of limit tokens per second.
+// which could be accumulated during that duration at the rate of the client's quota.
 func (c *Client) TokensFromDuration(d time.Duration) float64 {
-	// rate.go#tokensFromDuration
-	limit := float64(c.Limiter.Limit())
-	sec := float64(d/time.Second) * limit
-	nsec := float64(d%time.Second) * limit
-	return sec + nsec/1e9
+	if c.Quota.Window <= 0 {
+		return 0
+	}
+
+	return float64(d) * float64(c.Quota.Limit) / float64(c.Quota.Window)
 }
 
 // DurationFromTokens is a unit conversion function from the number of tokens to the duration
-// of time it takes to accumulate them at a rate of limit tokens per second.
+// of time it takes to accumulate them at the rate of the client's quota.
 func (c *Client) DurationFromTokens(tokens float64) time.Duration {
-	// rate.go#durationFromTokens
-	seconds := tokens / float64(c.Limiter.Limit())
-	return time.Nanosecond * time.Duration(1e9*seconds)
+	if c.Quota.Limit <= 0 {
+		return 0
+	}
+
+	return time.Duration(tokens * float64(c.Quota.Window) / float64(c.Quota.Limit))
 }
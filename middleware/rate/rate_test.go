@@ -0,0 +1,155 @@
+package rate_test
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/rate"
+	ratedbredis "github.com/kataras/iris/v12/middleware/rate/ratedb/redis"
+	sessionredis "github.com/kataras/iris/v12/sessions/sessiondb/redis"
+)
+
+type take struct {
+	at         time.Duration // since the start.
+	allowed    bool
+	remaining  int
+	retryAfter time.Duration
+}
+
+func testAlgorithm(t *testing.T, store rate.Store, quota rate.Quota, takes []take) {
+	t.Helper()
+
+	// aligned to the window, so the fixed and sliding windows start with it.
+	start := time.Unix(1600000000-1600000000%3600, 0)
+	for i, tt := range takes {
+		r, err := store.Take("client", quota, start.Add(tt.at))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r.Allowed != tt.allowed || r.Remaining != tt.remaining || r.RetryAfter != tt.retryAfter {
+			t.Fatalf("[%d] expected allowed: %v, remaining: %d, retry after: %s but got: %#+v",
+				i, tt.allowed, tt.remaining, tt.retryAfter, r)
+		}
+	}
+}
+
+func testAlgorithms(t *testing.T, newStore func() rate.Store) {
+	t.Run("TokenBucket", func(t *testing.T) {
+		// 2 requests per second: burst of 2, a token every 500ms.
+		testAlgorithm(t, newStore(), rate.PerSecond(2), []take{
+			{0, true, 1, 0},
+			{0, true, 0, 0},
+			{0, false, 0, 500 * time.Millisecond},
+			{250 * time.Millisecond, false, 0, 250 * time.Millisecond},
+			{500 * time.Millisecond, true, 0, 0},
+			{2 * time.Second, true, 1, 0},
+		})
+	})
+
+	t.Run("FixedWindow", func(t *testing.T) {
+		testAlgorithm(t, newStore(), rate.Quota{Limit: 2, Window: time.Minute, Algorithm: rate.FixedWindow}, []take{
+			{0, true, 1, 0},
+			{10 * time.Second, true, 0, 0},
+			{20 * time.Second, false, 0, 40 * time.Second},
+			{time.Minute, true, 1, 0},
+		})
+	})
+
+	t.Run("SlidingWindow", func(t *testing.T) {
+		testAlgorithm(t, newStore(), rate.Quota{Limit: 2, Window: time.Minute, Algorithm: rate.SlidingWindow}, []take{
+			{0, true, 1, 0},
+			{10 * time.Second, true, 0, 0},
+			// until the previous window's weight drops to 0.5 (at 90s).
+			{20 * time.Second, false, 0, 70 * time.Second},
+			// the previous window's 2 requests weight 1.5.
+			{75 * time.Second, false, 0, 15 * time.Second},
+			// weight 1.
+			{90 * time.Second, true, 0, 0},
+		})
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	testAlgorithms(t, func() rate.Store { return rate.NewMemoryStore() })
+}
+
+// TestRedisStore runs the same cases against the Lua port of the algorithms,
+// it requires a redis server, e.g. IRIS_TEST_REDIS_ADDR=127.0.0.1:6379.
+func TestRedisStore(t *testing.T) {
+	addr := os.Getenv("IRIS_TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("IRIS_TEST_REDIS_ADDR is not set")
+	}
+
+	db := sessionredis.New(sessionredis.Config{Addr: addr, Timeout: sessionredis.DefaultRedisTimeout})
+	defer db.Close()
+
+	testAlgorithms(t, func() rate.Store {
+		store, err := ratedbredis.New(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// a fresh state on every run.
+		store.Prefix = "ratelimit:test:" + strconv.FormatInt(time.Now().UnixNano(), 10) + ":"
+		return store
+	})
+}
+
+func TestStateBinary(t *testing.T) {
+	expected := rate.State{A: 1.5, B: 2, T: 1600000000000000000}
+	b, _ := expected.MarshalBinary()
+
+	var got rate.State
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if got != expected {
+		t.Fatalf("expected: %#+v but got: %#+v", expected, got)
+	}
+
+	if err := got.UnmarshalBinary(b[1:]); err != rate.ErrInvalidState {
+		t.Fatalf("expected an invalid state error but got: %v", err)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	app := iris.New()
+	app.Use(rate.LimitQuota(rate.Quota{Limit: 2, Window: time.Hour, Algorithm: rate.FixedWindow}))
+	app.Get("/", func(ctx iris.Context) {
+		ctx.WriteString(ctx.RemoteAddr())
+	})
+	app.Post("/login", func(ctx iris.Context) {}).SetMeta(rate.QuotaMetaKey, rate.PerMinute(1))
+
+	e := httptest.New(t, app)
+	for i := 1; i >= 0; i-- {
+		resp := e.GET("/").Expect().Status(httptest.StatusOK)
+		resp.Header("RateLimit-Limit").Equal("2")
+		resp.Header("RateLimit-Remaining").Equal(string(rune('0' + i)))
+		resp.Header("Retry-After").Empty()
+	}
+
+	resp := e.GET("/").Expect().Status(httptest.StatusTooManyRequests)
+	resp.Header("RateLimit-Remaining").Equal("0")
+	resp.Header("Retry-After").NotEmpty()
+
+	// a separate quota.
+	e.POST("/login").Expect().Status(httptest.StatusOK).Header("RateLimit-Limit").Equal("1")
+	e.POST("/login").Expect().Status(httptest.StatusTooManyRequests).Header("Retry-After").Equal("60")
+}
+
+func TestLimitInf(t *testing.T) {
+	app := iris.New()
+	app.Use(rate.Limit(rate.Inf, 0))
+	app.Get("/", func(ctx iris.Context) {})
+
+	e := httptest.New(t, app)
+	for i := 0; i < 3; i++ {
+		e.GET("/").Expect().Status(httptest.StatusOK).Header("RateLimit-Limit").Empty()
+	}
+}
@@ -0,0 +1,59 @@
+// Package badger provides a badger `rate.Store`,
+// e.g. on the database of the badger sessions database.
+package badger
+
+import (
+	"time"
+
+	"github.com/kataras/iris/v12/middleware/rate"
+
+	"github.com/dgraph-io/badger/v2"
+)
+
+// Store is a badger `rate.Store`.
+type Store struct {
+	// Prefix is the prefix of the keys.
+	//
+	// Defaults to "ratelimit:".
+	Prefix string
+
+	db *badger.DB
+}
+
+var _ rate.Store = (*Store)(nil)
+
+// New returns a new badger `rate.Store`, e.g. New(sessionsBadgerDB.Service).
+func New(db *badger.DB) *Store {
+	return &Store{Prefix: "ratelimit:", db: db}
+}
+
+// Take consumes a request of the "key" based on the "quota".
+// The state is updated in a transaction, which is retried on conflicts.
+func (s *Store) Take(key string, quota rate.Quota, now time.Time) (result rate.Result, err error) {
+	k := []byte(s.Prefix + key)
+
+	for {
+		err = s.db.Update(func(txn *badger.Txn) error {
+			var state rate.State
+
+			item, err := txn.Get(k)
+			switch err {
+			case nil:
+				if err = item.Value(state.UnmarshalBinary); err != nil {
+					state = rate.State{} // reset a malformed state.
+				}
+			case badger.ErrKeyNotFound:
+			default:
+				return err
+			}
+
+			result = quota.Apply(&state, now)
+			b, _ := state.MarshalBinary()
+			return txn.SetEntry(badger.NewEntry(k, b).WithTTL(quota.TTL()))
+		})
+
+		if err != badger.ErrConflict {
+			return
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package badger_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12/middleware/rate"
+	ratedb "github.com/kataras/iris/v12/middleware/rate/ratedb/badger"
+
+	"github.com/dgraph-io/badger/v2"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ratedb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	store := ratedb.New(db)
+	quota := rate.Quota{Limit: 2, Window: time.Minute, Algorithm: rate.FixedWindow}
+	now := time.Now()
+
+	for i, expected := range []bool{true, true, false} {
+		r, err := store.Take("client", quota, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r.Allowed != expected {
+			t.Fatalf("[%d] expected allowed: %v but got: %#+v", i, expected, r)
+		}
+	}
+
+	// another key.
+	if r, _ := store.Take("other", quota, now); !r.Allowed || r.Remaining != 1 {
+		t.Fatalf("expected a new state for another key but got: %#+v", r)
+	}
+}
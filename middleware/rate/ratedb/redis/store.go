@@ -0,0 +1,148 @@
+// Package redis provides a redis `rate.Store` based on the redis drivers
+// of the sessions database, so the rate limits are shared across the application's replicas.
+package redis
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/kataras/iris/v12/middleware/rate"
+	sessionredis "github.com/kataras/iris/v12/sessions/sessiondb/redis"
+)
+
+// Store is a redis `rate.Store`, the algorithms run atomically as Lua scripts.
+// The clocks of the application's replicas should be synchronized.
+type Store struct {
+	// Prefix is the prefix of the keys.
+	//
+	// Defaults to "ratelimit:".
+	Prefix string
+
+	driver sessionredis.Evaler
+}
+
+var _ rate.Store = (*Store)(nil)
+
+// New returns a new redis `rate.Store` which uses the driver of the (connected) redis sessions database "db".
+// It returns `sessionredis.ErrDriverNotSupported` if the driver cannot run Lua scripts.
+//
+// Usage:
+//
+//     db := redis.New(redis.Config{Addr: "127.0.0.1:6379"}) // sessions/sessiondb/redis
+//     store, err := ratedbredis.New(db)
+//     app.Use(rate.LimitQuota(rate.PerMinute(100), rate.Storage(store)))
+func New(db *sessionredis.Database) (*Store, error) {
+	driver, err := db.Evaler()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{Prefix: "ratelimit:", driver: driver}, nil
+}
+
+// script implements the `rate.Quota.Apply` on a hash of "a", "b" and "t" fields, in milliseconds.
+const script = `
+local key = KEYS[1]
+local alg = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+
+local st = redis.call("HMGET", key, "a", "b", "t")
+local a = tonumber(st[1]) or 0
+local b = tonumber(st[2]) or 0
+local t = tonumber(st[3])
+local allowed, remaining, reset, retry = 0, 0, 0, 0
+
+if alg == 0 then
+  if t == nil then
+    a = limit
+  elseif now > t then
+    a = math.min(limit, a + (now - t) * limit / window)
+  end
+  t = now
+  if a >= 1 then
+    a = a - 1
+    allowed = 1
+  else
+    retry = (1 - a) * window / limit
+  end
+  remaining = math.floor(a)
+  reset = (limit - a) * window / limit
+else
+  local start = now - (now % window)
+  if t ~= start then
+    if alg == 2 and t == start - window then b = a else b = 0 end
+    a = 0
+    t = start
+  end
+  local elapsed = now - start
+  local weight = 0
+  if alg == 2 then weight = 1 - elapsed / window end
+  reset = window - elapsed
+  local count = b * weight + a
+  if count + 1 <= limit then
+    a = a + 1
+    allowed = 1
+    remaining = math.floor(limit - count - 1)
+  else
+    retry = reset
+    if alg == 2 then
+      if a + 1 <= limit then
+        retry = (1 - (limit - 1 - a) / b) * window - elapsed
+      else
+        retry = reset + (1 - (limit - 1) / a) * window
+      end
+    end
+  end
+end
+
+if retry < 0 then retry = 0 end
+redis.call("HMSET", key, "a", tostring(a), "b", tostring(b), "t", tostring(t))
+redis.call("PEXPIRE", key, 2 * window)
+return {tostring(allowed), tostring(remaining), tostring(math.ceil(reset)), tostring(math.ceil(retry))}
+`
+
+// Take consumes a request of the "key" based on the "quota".
+func (s *Store) Take(key string, quota rate.Quota, now time.Time) (rate.Result, error) {
+	window := int64(quota.Window / time.Millisecond)
+	if window <= 0 {
+		window = 1
+	}
+
+	reply, err := s.driver.Eval(script, []string{s.Prefix + key},
+		strconv.Itoa(int(quota.Algorithm)),
+		strconv.Itoa(quota.Limit),
+		strconv.FormatInt(window, 10),
+		strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10),
+	)
+	if err != nil {
+		return rate.Result{}, err
+	}
+
+	return parseReply(quota, reply)
+}
+
+func parseReply(quota rate.Quota, reply []string) (rate.Result, error) {
+	if len(reply) != 4 {
+		return rate.Result{}, errors.New("rate: redis: unexpected script reply")
+	}
+
+	var values [4]int64
+	for i, s := range reply {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return rate.Result{}, err
+		}
+		values[i] = int64(f)
+	}
+
+	return rate.Result{
+		Allowed:    values[0] == 1,
+		Limit:      quota.Limit,
+		Remaining:  int(values[1]),
+		Reset:      time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,247 @@
+package rate
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// Algorithm is the rate limiting algorithm of a `Quota`.
+type Algorithm uint8
+
+const (
+	// TokenBucket allows bursts up to the quota's limit,
+	// the tokens are refilled continuously at a rate of limit/window.
+	TokenBucket Algorithm = iota
+	// FixedWindow allows up to limit requests per aligned window, e.g. per minute.
+	FixedWindow
+	// SlidingWindow allows up to limit requests per window, weighting the previous
+	// window's count by its overlap with the sliding one, it smooths the bursts
+	// of the fixed window at its boundaries.
+	SlidingWindow
+)
+
+// Quota is the number of requests allowed per window, through an `Algorithm`.
+type Quota struct {
+	Limit     int
+	Window    time.Duration
+	Algorithm Algorithm
+}
+
+// PerSecond returns a token bucket `Quota` of "n" requests per second.
+func PerSecond(n int) Quota { return Quota{Limit: n, Window: time.Second} }
+
+// PerMinute returns a token bucket `Quota` of "n" requests per minute.
+func PerMinute(n int) Quota { return Quota{Limit: n, Window: time.Minute} }
+
+// PerHour returns a token bucket `Quota` of "n" requests per hour.
+func PerHour(n int) Quota { return Quota{Limit: n, Window: time.Hour} }
+
+// Valid reports whether the quota can be applied.
+func (q Quota) Valid() bool {
+	return q.Limit > 0 && q.Window > 0 && q.Algorithm <= SlidingWindow
+}
+
+// Result is the outcome of a `Store.Take`.
+type Result struct {
+	// Allowed reports whether the request is allowed.
+	Allowed bool
+	// Limit is the quota's limit.
+	Limit int
+	// Remaining is the number of requests allowed right now.
+	Remaining int
+	// Reset is the time until the quota is fully restored (token bucket)
+	// or the current window ends (fixed and sliding windows).
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, if not allowed.
+	RetryAfter time.Duration
+}
+
+// Store keeps the rate limiting state of the clients, shared across the application's replicas
+// on the redis and badger stores (see the "ratedb" subpackages). Take must be atomic per key.
+type Store interface {
+	// Take consumes a request of the "key" based on the "quota" and reports the result.
+	Take(key string, quota Quota, now time.Time) (Result, error)
+}
+
+// State is the per key state of the algorithms. Stores which do not implement
+// the algorithms themselves save it, see `Quota.Apply`, `MarshalBinary` and `UnmarshalBinary`.
+type State struct {
+	// A is the tokens (token bucket) or the current window's count.
+	A float64
+	// B is the previous window's count (sliding window).
+	B float64
+	// T is the last refill (token bucket) or the current window's start, in unix nanoseconds.
+	T int64
+}
+
+// ErrInvalidState is returned by `State.UnmarshalBinary` on malformed data.
+var ErrInvalidState = errors.New("rate: invalid state")
+
+// MarshalBinary encodes the state to 24 bytes.
+func (s State) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 24)
+	binary.BigEndian.PutUint64(b, math.Float64bits(s.A))
+	binary.BigEndian.PutUint64(b[8:], math.Float64bits(s.B))
+	binary.BigEndian.PutUint64(b[16:], uint64(s.T))
+	return b, nil
+}
+
+// UnmarshalBinary decodes the state of `MarshalBinary`.
+func (s *State) UnmarshalBinary(b []byte) error {
+	if len(b) != 24 {
+		return ErrInvalidState
+	}
+
+	s.A = math.Float64frombits(binary.BigEndian.Uint64(b))
+	s.B = math.Float64frombits(binary.BigEndian.Uint64(b[8:]))
+	s.T = int64(binary.BigEndian.Uint64(b[16:]))
+	return nil
+}
+
+// TTL returns the time the state of a key should be kept after its last request.
+func (q Quota) TTL() time.Duration {
+	return 2 * q.Window
+}
+
+// Apply takes a request from the state "s", which is zero for a new key, at "now".
+func (q Quota) Apply(s *State, now time.Time) Result {
+	r := Result{Limit: q.Limit}
+	nowNano := now.UnixNano()
+	window := float64(q.Window)
+	limit := float64(q.Limit)
+
+	switch q.Algorithm {
+	case FixedWindow, SlidingWindow:
+		start := nowNano - nowNano%int64(q.Window)
+		if s.T != start {
+			if q.Algorithm == SlidingWindow && s.T == start-int64(q.Window) {
+				s.B = s.A
+			} else {
+				s.B = 0
+			}
+			s.A = 0
+			s.T = start
+		}
+
+		elapsed := float64(nowNano - start)
+		weight := 0.0
+		if q.Algorithm == SlidingWindow {
+			weight = 1 - elapsed/window
+		}
+
+		r.Reset = time.Duration(window - elapsed)
+		count := s.B*weight + s.A
+		if count+1 <= limit {
+			s.A++
+			r.Allowed = true
+			r.Remaining = int(limit - count - 1)
+			return r
+		}
+
+		r.RetryAfter = r.Reset
+		if q.Algorithm == SlidingWindow {
+			// wait until the previous window's weight drops enough.
+			if s.A+1 <= limit {
+				r.RetryAfter = time.Duration((1-(limit-1-s.A)/s.B)*window - elapsed)
+			} else {
+				r.RetryAfter += time.Duration((1 - (limit-1)/s.A) * window)
+			}
+		}
+	default: // TokenBucket.
+		if s.T == 0 {
+			s.A = limit
+		} else if elapsed := nowNano - s.T; elapsed > 0 {
+			s.A = math.Min(limit, s.A+float64(elapsed)*limit/window)
+		}
+		s.T = nowNano
+
+		if s.A >= 1 {
+			s.A--
+			r.Allowed = true
+		} else {
+			r.RetryAfter = time.Duration((1 - s.A) * window / limit)
+		}
+
+		r.Remaining = int(s.A)
+		r.Reset = time.Duration((limit - s.A) * window / limit)
+	}
+
+	if r.RetryAfter < 0 {
+		r.RetryAfter = 0
+	}
+
+	return r
+}
+
+// MemoryStore is the default, process-local, `Store`.
+// Unused keys are removed after their quota's `TTL`.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	nextSweep time.Time
+}
+
+type memoryEntry struct {
+	state    State
+	lastSeen time.Time
+	expires  time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns a new in-memory `Store`.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+// Take consumes a request of the "key" based on the "quota".
+func (s *MemoryStore) Take(key string, quota Quota, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.After(s.nextSweep) {
+		s.sweep(now)
+		s.nextSweep = now.Add(time.Minute)
+	}
+
+	e, ok := s.entries[key]
+	if !ok || now.After(e.expires) {
+		e = new(memoryEntry)
+		s.entries[key] = e
+	}
+
+	r := quota.Apply(&e.state, now)
+	e.lastSeen = now
+	e.expires = now.Add(quota.TTL())
+	return r, nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// Purge removes the keys based on the given "condition".
+func (s *MemoryStore) Purge(condition func(key string, lastSeen time.Time) bool) {
+	s.mu.Lock()
+	for key, e := range s.entries {
+		if condition(key, e.lastSeen) {
+			delete(s.entries, key)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// Len returns the number of the stored keys.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	n := len(s.entries)
+	s.mu.Unlock()
+	return n
+}
@@ -0,0 +1,143 @@
+package secure
+
+import "strings"
+
+// Common Content Security Policy source expressions.
+const (
+	Self          = "'self'"
+	None          = "'none'"
+	UnsafeInline  = "'unsafe-inline'"
+	UnsafeEval    = "'unsafe-eval'"
+	StrictDynamic = "'strict-dynamic'"
+	ReportSample  = "'report-sample'"
+	Data          = "data:"
+	HTTPS         = "https:"
+	// NonceSource is replaced by the per-request "'nonce-...'" source expression, e.g.
+	// NewCSP().ScriptSrc(secure.Self, secure.NonceSource).
+	NonceSource = "{nonce}"
+)
+
+type directive struct {
+	name    string
+	sources []string
+}
+
+// CSP is a Content Security Policy builder, see `NewCSP`.
+type CSP struct {
+	directives []directive
+}
+
+// NewCSP returns a new empty Content Security Policy builder.
+//
+// Usage:
+//
+//     csp := secure.NewCSP().
+//         DefaultSrc(secure.Self).
+//         ScriptSrc(secure.Self, secure.NonceSource, secure.StrictDynamic).
+//         ObjectSrc(secure.None).
+//         ReportURI("/csp-report")
+func NewCSP() *CSP {
+	return new(CSP)
+}
+
+// Add appends the "sources" to the "name" directive, e.g. Add("script-src", "'self'").
+// A directive without sources (e.g. "upgrade-insecure-requests") is valid.
+func (c *CSP) Add(name string, sources ...string) *CSP {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i := range c.directives {
+		if c.directives[i].name == name {
+			c.directives[i].sources = append(c.directives[i].sources, sources...)
+			return c
+		}
+	}
+
+	c.directives = append(c.directives, directive{name: name, sources: sources})
+	return c
+}
+
+// DefaultSrc adds "default-src" sources.
+func (c *CSP) DefaultSrc(sources ...string) *CSP { return c.Add("default-src", sources...) }
+
+// ScriptSrc adds "script-src" sources.
+func (c *CSP) ScriptSrc(sources ...string) *CSP { return c.Add("script-src", sources...) }
+
+// StyleSrc adds "style-src" sources.
+func (c *CSP) StyleSrc(sources ...string) *CSP { return c.Add("style-src", sources...) }
+
+// ImgSrc adds "img-src" sources.
+func (c *CSP) ImgSrc(sources ...string) *CSP { return c.Add("img-src", sources...) }
+
+// FontSrc adds "font-src" sources.
+func (c *CSP) FontSrc(sources ...string) *CSP { return c.Add("font-src", sources...) }
+
+// ConnectSrc adds "connect-src" sources.
+func (c *CSP) ConnectSrc(sources ...string) *CSP { return c.Add("connect-src", sources...) }
+
+// MediaSrc adds "media-src" sources.
+func (c *CSP) MediaSrc(sources ...string) *CSP { return c.Add("media-src", sources...) }
+
+// ObjectSrc adds "object-src" sources.
+func (c *CSP) ObjectSrc(sources ...string) *CSP { return c.Add("object-src", sources...) }
+
+// FrameSrc adds "frame-src" sources.
+func (c *CSP) FrameSrc(sources ...string) *CSP { return c.Add("frame-src", sources...) }
+
+// FrameAncestors adds "frame-ancestors" sources.
+func (c *CSP) FrameAncestors(sources ...string) *CSP { return c.Add("frame-ancestors", sources...) }
+
+// BaseURI adds "base-uri" sources.
+func (c *CSP) BaseURI(sources ...string) *CSP { return c.Add("base-uri", sources...) }
+
+// FormAction adds "form-action" sources.
+func (c *CSP) FormAction(sources ...string) *CSP { return c.Add("form-action", sources...) }
+
+// UpgradeInsecureRequests adds the "upgrade-insecure-requests" directive.
+func (c *CSP) UpgradeInsecureRequests() *CSP { return c.Add("upgrade-insecure-requests") }
+
+// ReportURI adds the "report-uri" directive, e.g. the path of a `ReportHandler`.
+func (c *CSP) ReportURI(uri string) *CSP { return c.Add("report-uri", uri) }
+
+// ReportTo adds the "report-to" directive, the "group" should be declared
+// through the "Reporting-Endpoints" header, see `Options.ReportingEndpoints`.
+func (c *CSP) ReportTo(group string) *CSP { return c.Add("report-to", group) }
+
+// UsesNonce reports whether the policy contains the `NonceSource`.
+func (c *CSP) UsesNonce() bool {
+	for _, d := range c.directives {
+		for _, s := range d.sources {
+			if s == NonceSource {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// String returns the policy, the `NonceSource` is not replaced.
+func (c *CSP) String() string {
+	var b strings.Builder
+	for i, d := range c.directives {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+
+		b.WriteString(d.name)
+		for _, s := range d.sources {
+			b.WriteByte(' ')
+			b.WriteString(s)
+		}
+	}
+
+	return b.String()
+}
+
+// compile splits the policy around the `NonceSource`,
+// so the per-request header is built by concatenation, see `withNonce`.
+func (c *CSP) compile() []string {
+	return strings.Split(c.String(), NonceSource)
+}
+
+func withNonce(parts []string, nonce string) string {
+	return strings.Join(parts, "'nonce-"+nonce+"'")
+}
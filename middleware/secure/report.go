@@ -0,0 +1,214 @@
+package secure
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+// Report is a Content Security Policy violation report,
+// normalized from the "application/csp-report" and "application/reports+json" formats.
+type Report struct {
+	DocumentURI        string `json:"documentURI"`
+	Referrer           string `json:"referrer,omitempty"`
+	BlockedURI         string `json:"blockedURI,omitempty"`
+	ViolatedDirective  string `json:"violatedDirective,omitempty"`
+	EffectiveDirective string `json:"effectiveDirective,omitempty"`
+	OriginalPolicy     string `json:"originalPolicy,omitempty"`
+	Disposition        string `json:"disposition,omitempty"`
+	SourceFile         string `json:"sourceFile,omitempty"`
+	LineNumber         int    `json:"lineNumber,omitempty"`
+	ColumnNumber       int    `json:"columnNumber,omitempty"`
+	StatusCode         int    `json:"statusCode,omitempty"`
+	Sample             string `json:"sample,omitempty"`
+	UserAgent          string `json:"userAgent,omitempty"`
+}
+
+// legacyReport is the body of the "report-uri" directive's reports.
+type legacyReport struct {
+	Body struct {
+		DocumentURI        string `json:"document-uri"`
+		Referrer           string `json:"referrer"`
+		BlockedURI         string `json:"blocked-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		OriginalPolicy     string `json:"original-policy"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		ColumnNumber       int    `json:"column-number"`
+		StatusCode         int    `json:"status-code"`
+		ScriptSample       string `json:"script-sample"`
+	} `json:"csp-report"`
+}
+
+// reportingAPIReport is a report of the "report-to" directive (Reporting API).
+type reportingAPIReport struct {
+	Type      string `json:"type"`
+	UserAgent string `json:"user_agent"`
+	Body      struct {
+		DocumentURL        string `json:"documentURL"`
+		Referrer           string `json:"referrer"`
+		BlockedURL         string `json:"blockedURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		OriginalPolicy     string `json:"originalPolicy"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		ColumnNumber       int    `json:"columnNumber"`
+		StatusCode         int    `json:"statusCode"`
+		Sample             string `json:"sample"`
+	} `json:"body"`
+}
+
+// MaxReportSize is the maximum body size of a violation report request.
+var MaxReportSize int64 = 64 << 10
+
+// ParseReports decodes the violation reports of "body", based on the "contentType":
+// "application/reports+json" (an array of Reporting API reports, non CSP ones are ignored)
+// or "application/csp-report" (a single report).
+func ParseReports(contentType string, body io.Reader) ([]Report, error) {
+	b, err := ioutil.ReadAll(io.LimitReader(body, MaxReportSize))
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(contentType, "application/reports+json") {
+		var in []reportingAPIReport
+		if err = json.Unmarshal(b, &in); err != nil {
+			return nil, err
+		}
+
+		reports := make([]Report, 0, len(in))
+		for _, r := range in {
+			if r.Type != "csp-violation" {
+				continue
+			}
+
+			reports = append(reports, Report{
+				DocumentURI:        r.Body.DocumentURL,
+				Referrer:           r.Body.Referrer,
+				BlockedURI:         r.Body.BlockedURL,
+				ViolatedDirective:  r.Body.EffectiveDirective,
+				EffectiveDirective: r.Body.EffectiveDirective,
+				OriginalPolicy:     r.Body.OriginalPolicy,
+				Disposition:        r.Body.Disposition,
+				SourceFile:         r.Body.SourceFile,
+				LineNumber:         r.Body.LineNumber,
+				ColumnNumber:       r.Body.ColumnNumber,
+				StatusCode:         r.Body.StatusCode,
+				Sample:             r.Body.Sample,
+				UserAgent:          r.UserAgent,
+			})
+		}
+
+		return reports, nil
+	}
+
+	var in legacyReport
+	if err = json.Unmarshal(b, &in); err != nil {
+		return nil, err
+	}
+
+	return []Report{{
+		DocumentURI:        in.Body.DocumentURI,
+		Referrer:           in.Body.Referrer,
+		BlockedURI:         in.Body.BlockedURI,
+		ViolatedDirective:  in.Body.ViolatedDirective,
+		EffectiveDirective: in.Body.EffectiveDirective,
+		OriginalPolicy:     in.Body.OriginalPolicy,
+		Disposition:        in.Body.Disposition,
+		SourceFile:         in.Body.SourceFile,
+		LineNumber:         in.Body.LineNumber,
+		ColumnNumber:       in.Body.ColumnNumber,
+		StatusCode:         in.Body.StatusCode,
+		Sample:             in.Body.ScriptSample,
+	}}, nil
+}
+
+// ReportHandler returns a handler which collects the violation reports sent by the browsers,
+// register it on the path of the `CSP.ReportURI` or the `Options.ReportingEndpoints`.
+// The "onReport" is called for each report, if nil then the reports are logged as warnings
+// through the application's logger. It responds with 204 No Content.
+func ReportHandler(onReport func(ctx context.Context, report Report)) context.Handler {
+	if onReport == nil {
+		onReport = func(ctx context.Context, r Report) {
+			ctx.Application().Logger().Warnf("CSP violation: %s blocked %q on %s", r.EffectiveDirective, r.BlockedURI, r.DocumentURI)
+		}
+	}
+
+	return func(ctx context.Context) {
+		reports, err := ParseReports(ctx.GetContentTypeRequested(), ctx.Request().Body)
+		if err != nil {
+			ctx.StopWithError(http.StatusBadRequest, err)
+			return
+		}
+
+		userAgent := ctx.GetHeader("User-Agent")
+		for _, r := range reports {
+			if r.UserAgent == "" {
+				r.UserAgent = userAgent
+			}
+			onReport(ctx, r)
+		}
+
+		ctx.StatusCode(http.StatusNoContent)
+	}
+}
+
+// ReportCollector keeps the latest violation reports in memory,
+// e.g. to expose them on an admin endpoint. It is safe for concurrent use.
+type ReportCollector struct {
+	max int
+
+	mu      sync.Mutex
+	reports []Report
+	total   uint64
+}
+
+// NewReportCollector returns a new `ReportCollector` which keeps the latest "max" reports (defaults to 100).
+func NewReportCollector(max int) *ReportCollector {
+	if max <= 0 {
+		max = 100
+	}
+
+	return &ReportCollector{max: max}
+}
+
+// Collect stores the "report", it can be passed to the `ReportHandler`.
+func (c *ReportCollector) Collect(_ context.Context, report Report) {
+	c.mu.Lock()
+	if len(c.reports) == c.max {
+		copy(c.reports, c.reports[1:])
+		c.reports = c.reports[:c.max-1]
+	}
+	c.reports = append(c.reports, report)
+	c.total++
+	c.mu.Unlock()
+}
+
+// Reports returns a copy of the latest reports, the oldest first.
+func (c *ReportCollector) Reports() []Report {
+	c.mu.Lock()
+	reports := make([]Report, len(c.reports))
+	copy(reports, c.reports)
+	c.mu.Unlock()
+	return reports
+}
+
+// Total returns the number of the collected reports, including the discarded ones.
+func (c *ReportCollector) Total() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+// Handler writes the latest reports as JSON.
+func (c *ReportCollector) Handler(ctx context.Context) {
+	ctx.JSON(c.Reports())
+}
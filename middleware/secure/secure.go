@@ -0,0 +1,219 @@
+// Package secure provides a middleware which sets the security response headers
+// (Strict-Transport-Security, X-Content-Type-Options, X-Frame-Options, Referrer-Policy,
+// Permissions-Policy, Cross-Origin-Opener-Policy and Content-Security-Policy),
+// based on presets (see `Default` and `Strict`) and a Content Security Policy builder (see `NewCSP`).
+// A per-request nonce is generated for the policy and exposed to the handlers (see `Nonce`)
+// and the views (see `ViewDataNonceKey` and the "csp_nonce" template function). Violation reports are collected by the `ReportHandler`.
+package secure
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/view"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/secure.*", "iris.secure")
+
+	// {{ csp_nonce }}
+	view.RegisterContextFunc("csp_nonce", func(ctx context.Context) interface{} {
+		return func() string { return Nonce(ctx) }
+	})
+}
+
+const (
+	// ViewDataNonceKey is the view data key of the per-request nonce.
+	ViewDataNonceKey = "csp_nonce"
+
+	nonceContextKey = "iris.secure.nonce"
+)
+
+// Options holds the security headers, the empty fields are not sent.
+// See `Default` and `Strict` presets.
+type Options struct {
+	// HSTSMaxAge is the "max-age" of the Strict-Transport-Security header,
+	// it is only sent on secure requests (TLS or "X-Forwarded-Proto: https").
+	HSTSMaxAge time.Duration
+	// HSTSIncludeSubdomains adds the "includeSubDomains" to the Strict-Transport-Security header.
+	HSTSIncludeSubdomains bool
+	// HSTSPreload adds the "preload" to the Strict-Transport-Security header.
+	HSTSPreload bool
+	// ContentTypeNosniff sets the "X-Content-Type-Options: nosniff" header.
+	ContentTypeNosniff bool
+	// FrameOptions is the X-Frame-Options header, e.g. "DENY" or "SAMEORIGIN".
+	FrameOptions string
+	// ReferrerPolicy is the Referrer-Policy header, e.g. "strict-origin-when-cross-origin".
+	ReferrerPolicy string
+	// PermissionsPolicy is the Permissions-Policy header, e.g. "camera=(), microphone=()".
+	PermissionsPolicy string
+	// CrossOriginOpenerPolicy is the Cross-Origin-Opener-Policy header, e.g. "same-origin".
+	CrossOriginOpenerPolicy string
+	// ReportingEndpoints is the Reporting-Endpoints header,
+	// e.g. `csp-endpoint="/csp-report"`, see `CSP.ReportTo`.
+	ReportingEndpoints string
+	// CSP is the Content-Security-Policy.
+	// If it contains the `NonceSource` then a new nonce is generated per request.
+	CSP *CSP
+	// CSPReportOnly sends the CSP through the Content-Security-Policy-Report-Only header,
+	// so violations are reported but not enforced.
+	CSPReportOnly bool
+}
+
+// Default returns a preset of the security headers which does not break most websites:
+// a 1 year HSTS, nosniff, SAMEORIGIN frames, "strict-origin-when-cross-origin" referrer policy
+// and a CSP which allows resources of the same origin, inline styles and nonce-based scripts.
+func Default() Options {
+	return Options{
+		HSTSMaxAge:              365 * 24 * time.Hour,
+		HSTSIncludeSubdomains:   true,
+		ContentTypeNosniff:      true,
+		FrameOptions:            "SAMEORIGIN",
+		ReferrerPolicy:          "strict-origin-when-cross-origin",
+		CrossOriginOpenerPolicy: "same-origin",
+		CSP: NewCSP().
+			DefaultSrc(Self).
+			ScriptSrc(Self, NonceSource).
+			StyleSrc(Self, UnsafeInline).
+			ImgSrc(Self, Data).
+			ObjectSrc(None).
+			BaseURI(Self).
+			FrameAncestors(Self),
+	}
+}
+
+// Strict returns a strict preset of the security headers: a 2 years HSTS with preload,
+// nosniff, no frames, no referrer, no powerful features and a strict, nonce-based, CSP.
+func Strict() Options {
+	return Options{
+		HSTSMaxAge:              2 * 365 * 24 * time.Hour,
+		HSTSIncludeSubdomains:   true,
+		HSTSPreload:             true,
+		ContentTypeNosniff:      true,
+		FrameOptions:            "DENY",
+		ReferrerPolicy:          "no-referrer",
+		PermissionsPolicy:       "accelerometer=(), camera=(), geolocation=(), gyroscope=(), magnetometer=(), microphone=(), payment=(), usb=()",
+		CrossOriginOpenerPolicy: "same-origin",
+		CSP: NewCSP().
+			DefaultSrc(None).
+			ScriptSrc(NonceSource, StrictDynamic).
+			StyleSrc(Self, NonceSource).
+			ImgSrc(Self).
+			FontSrc(Self).
+			ConnectSrc(Self).
+			BaseURI(None).
+			FormAction(Self).
+			FrameAncestors(None),
+	}
+}
+
+// New returns a new middleware which sets the security headers of "opts".
+//
+// Usage:
+//
+//     opts := secure.Default()
+//     opts.CSP.ReportURI("/csp-report")
+//     app.UseGlobal(secure.New(opts))
+//     app.Post("/csp-report", secure.ReportHandler(nil))
+//
+// And in a template:
+//
+//     <script nonce="{{ csp_nonce }}">...</script>
+func New(opts Options) context.Handler {
+	var hsts string
+	if opts.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(opts.HSTSMaxAge/time.Second), 10)
+		if opts.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if opts.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+
+	var (
+		static    = make(http.Header)
+		cspKey    = "Content-Security-Policy"
+		cspParts  []string
+		cspStatic string
+		useNonce  bool
+	)
+
+	if opts.ContentTypeNosniff {
+		static.Set("X-Content-Type-Options", "nosniff")
+	}
+	if opts.FrameOptions != "" {
+		static.Set("X-Frame-Options", opts.FrameOptions)
+	}
+	if opts.ReferrerPolicy != "" {
+		static.Set("Referrer-Policy", opts.ReferrerPolicy)
+	}
+	if opts.PermissionsPolicy != "" {
+		static.Set("Permissions-Policy", opts.PermissionsPolicy)
+	}
+	if opts.CrossOriginOpenerPolicy != "" {
+		static.Set("Cross-Origin-Opener-Policy", opts.CrossOriginOpenerPolicy)
+	}
+	if opts.ReportingEndpoints != "" {
+		static.Set("Reporting-Endpoints", opts.ReportingEndpoints)
+	}
+
+	if opts.CSP != nil {
+		if opts.CSPReportOnly {
+			cspKey = "Content-Security-Policy-Report-Only"
+		}
+
+		if useNonce = opts.CSP.UsesNonce(); useNonce {
+			cspParts = opts.CSP.compile()
+		} else {
+			cspStatic = opts.CSP.String()
+		}
+	}
+
+	return func(ctx context.Context) {
+		h := ctx.ResponseWriter().Header()
+		for key, values := range static {
+			h[key] = values
+		}
+
+		if hsts != "" && isSecure(ctx.Request()) {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+
+		if useNonce {
+			nonce := newNonce()
+			ctx.Values().Set(nonceContextKey, nonce)
+			ctx.ViewData(ViewDataNonceKey, nonce)
+			h.Set(cspKey, withNonce(cspParts, nonce))
+		} else if cspStatic != "" {
+			h.Set(cspKey, cspStatic)
+		}
+
+		ctx.Next()
+	}
+}
+
+func isSecure(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+func newNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("secure: " + err.Error())
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Nonce returns the nonce of the current request's Content-Security-Policy,
+// e.g. ctx.HTML(`<script nonce="%s">...</script>`, secure.Nonce(ctx)).
+// It returns empty if the policy does not contain the `NonceSource`.
+func Nonce(ctx context.Context) string {
+	return ctx.Values().GetString(nonceContextKey)
+}
@@ -0,0 +1,122 @@
+package secure_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/secure"
+)
+
+func TestCSP(t *testing.T) {
+	csp := secure.NewCSP().
+		DefaultSrc(secure.Self).
+		ScriptSrc(secure.Self).
+		ScriptSrc("https://cdn.example.com").
+		ObjectSrc(secure.None).
+		UpgradeInsecureRequests()
+
+	expected := "default-src 'self'; script-src 'self' https://cdn.example.com; object-src 'none'; upgrade-insecure-requests"
+	if got := csp.String(); got != expected {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, got)
+	}
+
+	if csp.UsesNonce() {
+		t.Fatalf("expected no nonce")
+	}
+}
+
+func TestSecure(t *testing.T) {
+	app := iris.New()
+	app.RegisterView(iris.HTML("./views", ".html").Binary(func(string) ([]byte, error) {
+		return []byte(`<script nonce="{{ csp_nonce }}"></script><p>{{ .Title }}</p>`), nil
+	}, func() []string { return []string{"views/index.html"} }))
+
+	opts := secure.Default()
+	opts.CSP.ReportURI("/csp-report")
+	app.UseGlobal(secure.New(opts))
+	app.Get("/", func(ctx iris.Context) {
+		// a custom view model.
+		ctx.View("index.html", struct{ Title string }{"Index"})
+	})
+	app.Get("/html", func(ctx iris.Context) {
+		ctx.HTML(`<script nonce="%s"></script><p>Index</p>`, secure.Nonce(ctx))
+	})
+
+	e := httptest.New(t, app)
+	for _, path := range []string{"/", "/html"} {
+		resp := e.GET(path).Expect().Status(httptest.StatusOK)
+		resp.Header("X-Content-Type-Options").Equal("nosniff")
+		resp.Header("X-Frame-Options").Equal("SAMEORIGIN")
+		resp.Header("Referrer-Policy").Equal("strict-origin-when-cross-origin")
+		// not a secure request.
+		resp.Header("Strict-Transport-Security").Empty()
+
+		policy := resp.Header("Content-Security-Policy").Raw()
+		matches := regexp.MustCompile(`script-src 'self' 'nonce-([^']+)'`).FindStringSubmatch(policy)
+		if len(matches) != 2 {
+			t.Fatalf("[%s] expected a nonce on the policy: %s", path, policy)
+		}
+
+		resp.Body().Equal(`<script nonce="` + matches[1] + `"></script><p>Index</p>`)
+	}
+
+	e.GET("/").WithHeader("X-Forwarded-Proto", "https").Expect().
+		Header("Strict-Transport-Security").Equal("max-age=31536000; includeSubDomains")
+}
+
+func TestSecureReportOnly(t *testing.T) {
+	app := iris.New()
+	app.Use(secure.New(secure.Options{
+		CSP:           secure.NewCSP().DefaultSrc(secure.Self),
+		CSPReportOnly: true,
+	}))
+	app.Get("/", func(ctx iris.Context) {
+		if secure.Nonce(ctx) != "" {
+			ctx.StatusCode(iris.StatusInternalServerError)
+		}
+	})
+
+	e := httptest.New(t, app)
+	resp := e.GET("/").Expect().Status(httptest.StatusOK)
+	resp.Header("Content-Security-Policy-Report-Only").Equal("default-src 'self'")
+	resp.Header("Content-Security-Policy").Empty()
+	resp.Header("X-Frame-Options").Empty()
+}
+
+func TestReportHandler(t *testing.T) {
+	collector := secure.NewReportCollector(2)
+
+	app := iris.New()
+	app.Post("/csp-report", secure.ReportHandler(collector.Collect))
+	app.Get("/reports", collector.Handler)
+
+	e := httptest.New(t, app)
+	e.POST("/csp-report").WithHeader("Content-Type", "application/csp-report").
+		WithHeader("User-Agent", "test").
+		WithBytes([]byte(`{"csp-report":{"document-uri":"https://example.com/","blocked-uri":"inline","effective-directive":"script-src-elem","line-number":10}}`)).
+		Expect().Status(httptest.StatusNoContent)
+
+	e.POST("/csp-report").WithHeader("Content-Type", "application/reports+json").
+		WithBytes([]byte(`[{"type":"csp-violation","user_agent":"chrome","body":{"documentURL":"https://example.com/a","blockedURL":"https://evil.com/x.js","effectiveDirective":"script-src-elem"}},{"type":"deprecation","body":{}}]`)).
+		Expect().Status(httptest.StatusNoContent)
+
+	e.POST("/csp-report").WithHeader("Content-Type", "application/csp-report").WithBytes([]byte(`{`)).
+		Expect().Status(httptest.StatusBadRequest)
+
+	reports := e.GET("/reports").Expect().Status(httptest.StatusOK).JSON().Array()
+	reports.Length().Equal(2)
+	first := reports.Element(0).Object()
+	first.Value("documentURI").Equal("https://example.com/")
+	first.Value("blockedURI").Equal("inline")
+	first.Value("lineNumber").Equal(10)
+	first.Value("userAgent").Equal("test")
+	second := reports.Element(1).Object()
+	second.Value("blockedURI").Equal("https://evil.com/x.js")
+	second.Value("userAgent").Equal("chrome")
+
+	if expected, got := uint64(2), collector.Total(); expected != got {
+		t.Fatalf("expected total: %d but got: %d", expected, got)
+	}
+}
@@ -0,0 +1,278 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Exporter sends a batch of finished spans to a backend.
+// Export is called by a single goroutine of the `Tracer`.
+type Exporter interface {
+	Export(serviceName string, spans []*Span) error
+}
+
+// ExporterFunc is a function which completes the `Exporter` interface.
+type ExporterFunc func(serviceName string, spans []*Span) error
+
+// Export calls itself.
+func (f ExporterFunc) Export(serviceName string, spans []*Span) error {
+	return f(serviceName, spans)
+}
+
+var spanKindNames = map[SpanKind]string{
+	SpanKindInternal: "internal",
+	SpanKindServer:   "server",
+	SpanKindClient:   "client",
+}
+
+// stdoutSpan is the JSON form of a span written by the `StdoutExporter`.
+type stdoutSpan struct {
+	Service       string                 `json:"service"`
+	TraceID       string                 `json:"traceId"`
+	SpanID        string                 `json:"spanId"`
+	ParentSpanID  string                 `json:"parentSpanId,omitempty"`
+	Name          string                 `json:"name"`
+	Kind          string                 `json:"kind"`
+	Start         time.Time              `json:"start"`
+	Duration      string                 `json:"duration"`
+	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+	Error         bool                   `json:"error,omitempty"`
+	StatusMessage string                 `json:"statusMessage,omitempty"`
+}
+
+// StdoutExporter is an `Exporter` which writes the spans
+// as JSON objects, one per line, e.g. for development.
+type StdoutExporter struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// NewStdoutExporter returns a new `StdoutExporter` which writes to "w",
+// if "w" is nil then it writes to os.Stdout.
+func NewStdoutExporter(w io.Writer) *StdoutExporter {
+	if w == nil {
+		w = os.Stdout
+	}
+
+	return &StdoutExporter{w: w}
+}
+
+// Export writes the spans.
+func (e *StdoutExporter) Export(serviceName string, spans []*Span) error {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	for _, s := range spans {
+		s.mu.Lock()
+		out := stdoutSpan{
+			Service:       serviceName,
+			TraceID:       s.sc.TraceID.String(),
+			SpanID:        s.sc.SpanID.String(),
+			Name:          s.Name,
+			Kind:          spanKindNames[s.Kind],
+			Start:         s.StartTime,
+			Duration:      s.EndTime.Sub(s.StartTime).String(),
+			Attributes:    s.Attributes,
+			Error:         s.Status == StatusError,
+			StatusMessage: s.StatusMessage,
+		}
+		if s.ParentSpanID.IsValid() {
+			out.ParentSpanID = s.ParentSpanID.String()
+		}
+		err := enc.Encode(out)
+		s.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	e.mu.Lock()
+	_, err := e.w.Write(buf.Bytes())
+	e.mu.Unlock()
+	return err
+}
+
+// OTLPExporter is an `Exporter` which sends the spans to an OpenTelemetry collector
+// through the OTLP/HTTP protocol with its JSON encoding.
+type OTLPExporter struct {
+	// Endpoint is the traces URL of the collector.
+	Endpoint string
+	// Headers are extra request headers, e.g. for authorization.
+	Headers map[string]string
+	// Client is the HTTP client to send the requests.
+	Client *http.Client
+}
+
+// DefaultOTLPEndpoint is the traces URL of a local OpenTelemetry collector.
+const DefaultOTLPEndpoint = "http://localhost:4318/v1/traces"
+
+// NewOTLPExporter returns a new `OTLPExporter` which sends the spans to "endpoint",
+// if empty then it defaults to the `DefaultOTLPEndpoint`.
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	if endpoint == "" {
+		endpoint = DefaultOTLPEndpoint
+	}
+
+	return &OTLPExporter{
+		Endpoint: endpoint,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type (
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+
+	otlpKeyValue struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+
+	otlpStatus struct {
+		Code    StatusCode `json:"code,omitempty"`
+		Message string     `json:"message,omitempty"`
+	}
+
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		TraceState        string         `json:"traceState,omitempty"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              SpanKind       `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+
+	otlpScopeSpans struct {
+		Scope struct {
+			Name string `json:"name"`
+		} `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+
+	otlpResourceSpans struct {
+		Resource struct {
+			Attributes []otlpKeyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+)
+
+func otlpAttribute(key string, value interface{}) otlpKeyValue {
+	kv := otlpKeyValue{Key: key}
+	switch v := value.(type) {
+	case string:
+		kv.Value.StringValue = &v
+	case bool:
+		kv.Value.BoolValue = &v
+	case int:
+		s := strconv.Itoa(v)
+		kv.Value.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		kv.Value.IntValue = &s
+	case float64:
+		kv.Value.DoubleValue = &v
+	default:
+		s := fmt.Sprint(v)
+		kv.Value.StringValue = &s
+	}
+
+	return kv
+}
+
+func toOTLPSpan(s *Span) otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := otlpSpan{
+		TraceID:           s.sc.TraceID.String(),
+		SpanID:            s.sc.SpanID.String(),
+		TraceState:        s.sc.TraceState,
+		Name:              s.Name,
+		Kind:              s.Kind,
+		StartTimeUnixNano: strconv.FormatInt(s.StartTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.EndTime.UnixNano(), 10),
+		Status:            otlpStatus{Code: s.Status, Message: s.StatusMessage},
+	}
+
+	if s.ParentSpanID.IsValid() {
+		out.ParentSpanID = s.ParentSpanID.String()
+	}
+
+	keys := make([]string, 0, len(s.Attributes))
+	for key := range s.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		out.Attributes = append(out.Attributes, otlpAttribute(key, s.Attributes[key]))
+	}
+
+	return out
+}
+
+// Export sends the spans to the collector.
+func (e *OTLPExporter) Export(serviceName string, spans []*Span) error {
+	var scope otlpScopeSpans
+	scope.Scope.Name = "github.com/kataras/iris/v12/middleware/tracing"
+	for _, s := range spans {
+		scope.Spans = append(scope.Spans, toOTLPSpan(s))
+	}
+
+	var rs otlpResourceSpans
+	rs.Resource.Attributes = []otlpKeyValue{otlpAttribute("service.name", serviceName)}
+	rs.ScopeSpans = []otlpScopeSpans{scope}
+
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{rs}})
+	if err != nil {
+		return err
+	}
+
+	r, err := http.NewRequest(http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	for key, value := range e.Headers {
+		r.Header.Set(key, value)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("tracing: otlp export: %s", resp.Status)
+	}
+
+	return nil
+}
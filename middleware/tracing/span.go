@@ -0,0 +1,254 @@
+package tracing
+
+import (
+	stdContext "context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceID is the 16 bytes identifier of a trace.
+type TraceID [16]byte
+
+// String returns the lowercase hex encoding of the id.
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the id is not all zeros.
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+// SpanID is the 8 bytes identifier of a span.
+type SpanID [8]byte
+
+// String returns the lowercase hex encoding of the id.
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the id is not all zeros.
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// FlagSampled is the "sampled" trace flag.
+const FlagSampled byte = 0x01
+
+// SpanContext is the part of a span which is propagated across services,
+// through the W3C Trace Context "traceparent" and "tracestate" headers.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+	// Remote reports whether it was parsed from an incoming request.
+	Remote bool
+}
+
+// IsValid reports whether both trace and span IDs are valid.
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// IsSampled reports whether the trace is sampled (recorded).
+func (sc SpanContext) IsSampled() bool { return sc.Flags&FlagSampled != 0 }
+
+// TraceParent returns the "traceparent" header value, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func (sc SpanContext) TraceParent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// Header names of the W3C Trace Context.
+const (
+	TraceParentHeaderKey = "traceparent"
+	TraceStateHeaderKey  = "tracestate"
+)
+
+// ErrInvalidTraceParent is returned by `ParseTraceParent` on a malformed value.
+var ErrInvalidTraceParent = errors.New("tracing: invalid traceparent")
+
+// ParseTraceParent parses a "traceparent" header value.
+func ParseTraceParent(v string) (SpanContext, error) {
+	var sc SpanContext
+
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, ErrInvalidTraceParent
+	}
+
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(parts) != 4) {
+		return sc, ErrInvalidTraceParent
+	}
+
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) {
+		return sc, ErrInvalidTraceParent
+	}
+
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, ErrInvalidTraceParent
+	}
+	sc.Flags = flags[0]
+
+	if !sc.IsValid() {
+		return sc, ErrInvalidTraceParent
+	}
+
+	sc.Remote = true
+	return sc, nil
+}
+
+func decodeHex(dst []byte, s string) bool {
+	if strings.ToLower(s) != s {
+		return false
+	}
+
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// Extract returns the remote span context of the "traceparent" and "tracestate" headers.
+// It reports false if the headers are missing or malformed.
+func Extract(h http.Header) (SpanContext, bool) {
+	sc, err := ParseTraceParent(h.Get(TraceParentHeaderKey))
+	if err != nil {
+		return sc, false
+	}
+
+	sc.TraceState = strings.Join(h.Values(TraceStateHeaderKey), ",")
+	return sc, true
+}
+
+// Inject sets the "traceparent" and "tracestate" headers of an outgoing request
+// based on the span of "ctx", e.g. `tracing.Inject(ctx.Request().Context(), req.Header)`.
+func Inject(ctx stdContext.Context, h http.Header) {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+
+	sc := span.SpanContext()
+	h.Set(TraceParentHeaderKey, sc.TraceParent())
+	if sc.TraceState != "" {
+		h.Set(TraceStateHeaderKey, sc.TraceState)
+	} else {
+		h.Del(TraceStateHeaderKey)
+	}
+}
+
+// SpanKind describes the relationship of a span to its parent.
+type SpanKind int
+
+// The span kinds, their values match the OpenTelemetry ones.
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode is the status of a span, its values match the OpenTelemetry ones.
+type StatusCode int
+
+// The status codes.
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Span is a single operation of a trace, e.g. a request or a handler.
+// It is safe for concurrent use.
+type Span struct {
+	Name         string
+	Kind         SpanKind
+	ParentSpanID SpanID
+	StartTime    time.Time
+	EndTime      time.Time
+	// Attributes of string, bool, int, int64 or float64 values.
+	Attributes    map[string]interface{}
+	Status        StatusCode
+	StatusMessage string
+
+	sc     SpanContext
+	tracer *Tracer
+	mu     sync.Mutex
+	ended  bool
+}
+
+// SpanContext returns the propagated part of the span.
+func (s *Span) SpanContext() SpanContext {
+	return s.sc
+}
+
+// SetAttribute sets an attribute (string, bool, int, int64 or float64) of the span.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]interface{})
+	}
+	s.Attributes[key] = value
+	s.mu.Unlock()
+}
+
+// SetStatus sets the status of the span.
+func (s *Span) SetStatus(code StatusCode, message string) {
+	s.mu.Lock()
+	s.Status = code
+	s.StatusMessage = message
+	s.mu.Unlock()
+}
+
+// RecordError marks the span as failed with the error's message.
+func (s *Span) RecordError(err error) {
+	if err != nil {
+		s.SetStatus(StatusError, err.Error())
+	}
+}
+
+// End completes the span and queues it for export, if sampled.
+// Next calls do nothing.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+
+	if s.sc.IsSampled() && s.tracer != nil {
+		s.tracer.enqueue(s)
+	}
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpan returns a copy of "parent" which carries the "span".
+func ContextWithSpan(parent stdContext.Context, span *Span) stdContext.Context {
+	return stdContext.WithValue(parent, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the span of "ctx", if any.
+func SpanFromContext(ctx stdContext.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic("tracing: " + err.Error())
+	}
+}
+
+func newTraceID() (id TraceID) {
+	for !id.IsValid() {
+		randomBytes(id[:])
+	}
+	return
+}
+
+func newSpanID() (id SpanID) {
+	for !id.IsValid() {
+		randomBytes(id[:])
+	}
+	return
+}
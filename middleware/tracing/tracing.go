@@ -0,0 +1,382 @@
+// Package tracing provides a distributed tracing middleware which follows
+// the W3C Trace Context specification. It creates a server span per request,
+// named after the matched route, optional child spans per handler of the chain,
+// propagates the current span through the request's context for outgoing calls
+// (see `Inject`) and sends the finished spans to a pluggable `Exporter`,
+// i.e. `NewStdoutExporter` or `NewOTLPExporter`, without the OpenTelemetry SDK.
+package tracing
+
+import (
+	stdContext "context"
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+func init() {
+	context.SetHandlerName("iris/middleware/tracing.*", "iris.tracing")
+}
+
+// Options holds the options for the `Tracer`.
+type Options struct {
+	// ServiceName is the "service.name" resource attribute of the exported spans.
+	//
+	// Defaults to "iris".
+	ServiceName string
+	// Exporter receives the finished spans in batches.
+	//
+	// Defaults to a `NewStdoutExporter(os.Stdout)`.
+	Exporter Exporter
+	// SampleRatio is the fraction, 0 to 1, of the new traces to record.
+	// Traces started by a remote parent follow its sampled flag.
+	//
+	// Defaults to 1, all traces are recorded.
+	SampleRatio float64
+	// HandlerSpans reports whether a child span should be recorded for each handler
+	// of the route's chain which runs after the tracing middleware, named after
+	// the handler's name (see `context.HandlerName`).
+	// Note that, while enabled, the `ctx.HandlerName()` of those handlers reports "iris.tracing".
+	//
+	// Defaults to false.
+	HandlerSpans bool
+	// BatchSize is the maximum number of spans per export.
+	//
+	// Defaults to 512.
+	BatchSize int
+	// MaxQueueSize is the maximum number of the queued spans,
+	// the spans which end while the queue is full (e.g. the exporter is slow or down)
+	// are dropped, see `Tracer.Dropped`.
+	//
+	// Defaults to 2048.
+	MaxQueueSize int
+	// FlushInterval is the interval which the queued spans are exported,
+	// even if the batch is not full.
+	//
+	// Defaults to 5 seconds.
+	FlushInterval time.Duration
+	// OnError is called when an export failed.
+	//
+	// Defaults to nil, errors are ignored.
+	OnError func(err error)
+}
+
+// Tracer creates and exports spans, see its `Serve` middleware.
+//
+// Usage:
+//
+//     tracer := tracing.New(tracing.Options{
+//         ServiceName: "users",
+//         Exporter:    tracing.NewOTLPExporter("http://localhost:4318/v1/traces"),
+//     })
+//     defer tracer.Close()
+//     app.UseGlobal(tracer.Serve)
+//
+// And inside a handler:
+//
+//     req, _ := http.NewRequestWithContext(ctx.Request().Context(), "GET", url, nil)
+//     tracing.Inject(req.Context(), req.Header)
+type Tracer struct {
+	opts Options
+
+	mu      sync.Mutex
+	queue   []*Span
+	closed  bool
+	flushCh chan struct{}
+	done    chan struct{}
+	exited  chan struct{}
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+
+	dropped uint64 // accessed atomically.
+}
+
+// New returns a new `Tracer` based on the optional "opts"
+// and starts its background exporter, see `Close`.
+func New(opts ...Options) *Tracer {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.ServiceName == "" {
+		o.ServiceName = "iris"
+	}
+
+	if o.Exporter == nil {
+		o.Exporter = NewStdoutExporter(nil)
+	}
+
+	if o.SampleRatio <= 0 || o.SampleRatio > 1 {
+		o.SampleRatio = 1
+	}
+
+	if o.BatchSize <= 0 {
+		o.BatchSize = 512
+	}
+
+	if o.MaxQueueSize <= 0 {
+		o.MaxQueueSize = 2048
+	}
+
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = 5 * time.Second
+	}
+
+	t := &Tracer{
+		opts:    o,
+		flushCh: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		exited:  make(chan struct{}),
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	go t.run()
+	return t
+}
+
+// Start starts a new span as a child of the span of "parent", if any.
+// The caller should call the span's `End` when the operation is completed, e.g.
+//
+//     span, c := tracer.Start(ctx.Request().Context(), "db.query", tracing.SpanKindClient)
+//     defer span.End()
+func (t *Tracer) Start(parent stdContext.Context, name string, kind SpanKind) (*Span, stdContext.Context) {
+	var parentSC SpanContext
+	if p := SpanFromContext(parent); p != nil {
+		parentSC = p.SpanContext()
+	}
+
+	span := t.newSpan(parentSC, name, kind)
+	return span, ContextWithSpan(parent, span)
+}
+
+func (t *Tracer) newSpan(parent SpanContext, name string, kind SpanKind) *Span {
+	span := &Span{
+		Name:      name,
+		Kind:      kind,
+		StartTime: time.Now(),
+		tracer:    t,
+	}
+
+	if parent.IsValid() {
+		span.ParentSpanID = parent.SpanID
+		span.sc = SpanContext{
+			TraceID:    parent.TraceID,
+			Flags:      parent.Flags,
+			TraceState: parent.TraceState,
+		}
+	} else {
+		span.sc.TraceID = newTraceID()
+		if t.sample() {
+			span.sc.Flags = FlagSampled
+		}
+	}
+
+	span.sc.SpanID = newSpanID()
+	return span
+}
+
+func (t *Tracer) sample() bool {
+	if t.opts.SampleRatio >= 1 {
+		return true
+	}
+
+	t.randMu.Lock()
+	f := t.rand.Float64()
+	t.randMu.Unlock()
+	return f < t.opts.SampleRatio
+}
+
+// Serve is the middleware which starts a server span per request, as a child
+// of the incoming "traceparent" header, if valid, and sets the "traceparent" and
+// "tracestate" response headers. The span is stored to the request's context,
+// see `SpanFromContext` and `Inject`.
+//
+// The span is named after the method and the template path of the matched route
+// (e.g. "GET /users/{id:uint64}"), the unmatched requests (e.g. 404) are named after the method
+// and they are traced through the global middleware of the registered error handlers (see `app.OnErrorCode`).
+func (t *Tracer) Serve(ctx context.Context) {
+	r := ctx.Request()
+
+	parent, _ := Extract(r.Header)
+	name := "HTTP " + ctx.Method()
+	route := ctx.GetCurrentRoute()
+	if route != nil && route.StatusErrorCode() == 0 {
+		name = ctx.Method() + " " + route.Path()
+	}
+
+	span := t.newSpan(parent, name, SpanKindServer)
+	span.SetAttribute("http.method", ctx.Method())
+	span.SetAttribute("http.target", r.URL.RequestURI())
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	span.SetAttribute("http.scheme", scheme)
+	span.SetAttribute("net.peer.ip", ctx.RemoteAddr())
+	if ua := r.UserAgent(); ua != "" {
+		span.SetAttribute("http.user_agent", ua)
+	}
+	if route != nil && route.StatusErrorCode() == 0 {
+		span.SetAttribute("http.route", route.Path())
+	}
+
+	sc := span.SpanContext()
+	ctx.Header(TraceParentHeaderKey, sc.TraceParent())
+	if sc.TraceState != "" {
+		ctx.Header(TraceStateHeaderKey, sc.TraceState)
+	}
+
+	ctx.ResetRequest(r.WithContext(ContextWithSpan(r.Context(), span)))
+
+	if t.opts.HandlerSpans && sc.IsSampled() {
+		t.wrapHandlers(ctx)
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			span.SetStatus(StatusError, "panic")
+			span.End()
+			panic(v)
+		}
+
+		status := ctx.GetStatusCode()
+		span.SetAttribute("http.status_code", status)
+		if status >= 500 {
+			span.SetStatus(StatusError, strconv.Itoa(status))
+		}
+		span.End()
+	}()
+
+	ctx.Next()
+}
+
+// wrapHandlers replaces the handlers which run after the current one
+// with ones that record a child span of the current request's span.
+func (t *Tracer) wrapHandlers(ctx context.Context) {
+	handlers := ctx.Handlers()
+	idx := ctx.HandlerIndex(-1)
+	if idx+1 >= len(handlers) {
+		return
+	}
+
+	wrapped := make(context.Handlers, len(handlers))
+	copy(wrapped, handlers)
+	for i := idx + 1; i < len(handlers); i++ {
+		h := handlers[i]
+		name := context.HandlerName(h)
+		wrapped[i] = func(ctx context.Context) {
+			r := ctx.Request()
+			span, c := t.Start(r.Context(), name, SpanKindInternal)
+			traced := r.WithContext(c)
+			ctx.ResetRequest(traced)
+			defer func() {
+				span.End()
+				if ctx.Request() == traced { // not replaced by the handler.
+					ctx.ResetRequest(r)
+				}
+			}()
+
+			h(ctx)
+		}
+	}
+
+	ctx.SetHandlers(wrapped)
+}
+
+func (t *Tracer) enqueue(span *Span) {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return
+	}
+
+	if len(t.queue) >= t.opts.MaxQueueSize {
+		t.mu.Unlock()
+		atomic.AddUint64(&t.dropped, 1)
+		return
+	}
+
+	t.queue = append(t.queue, span)
+	full := len(t.queue) >= t.opts.BatchSize
+	t.mu.Unlock()
+
+	if full {
+		t.Flush()
+	}
+}
+
+// Dropped returns the number of the spans which were dropped
+// because the queue was full, see `Options.MaxQueueSize`.
+func (t *Tracer) Dropped() uint64 {
+	return atomic.LoadUint64(&t.dropped)
+}
+
+// Flush asks the background exporter to export the queued spans.
+// It does not wait for the export to complete.
+func (t *Tracer) Flush() {
+	select {
+	case t.flushCh <- struct{}{}:
+	default:
+	}
+}
+
+func (t *Tracer) run() {
+	ticker := time.NewTicker(t.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.export()
+		case <-t.flushCh:
+			t.export()
+		case <-t.done:
+			t.export()
+			close(t.exited)
+			return
+		}
+	}
+}
+
+func (t *Tracer) export() {
+	for {
+		t.mu.Lock()
+		n := len(t.queue)
+		if n == 0 {
+			t.mu.Unlock()
+			return
+		}
+
+		if n > t.opts.BatchSize {
+			n = t.opts.BatchSize
+		}
+		batch := t.queue[:n:n]
+		t.queue = t.queue[n:]
+		t.mu.Unlock()
+
+		if err := t.opts.Exporter.Export(t.opts.ServiceName, batch); err != nil && t.opts.OnError != nil {
+			t.opts.OnError(err)
+		}
+	}
+}
+
+// Close exports the queued spans and stops the background exporter.
+// The spans which end after Close are dropped.
+func (t *Tracer) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	t.mu.Unlock()
+
+	close(t.done)
+	<-t.exited // wait for the final export.
+	return nil
+}
@@ -0,0 +1,272 @@
+package tracing_test
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	irishttptest "github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/middleware/tracing"
+)
+
+type recorder struct {
+	mu    sync.Mutex
+	spans []*tracing.Span
+}
+
+func (r *recorder) Export(serviceName string, spans []*tracing.Span) error {
+	r.mu.Lock()
+	r.spans = append(r.spans, spans...)
+	r.mu.Unlock()
+	return nil
+}
+
+func TestParseTraceParent(t *testing.T) {
+	const v = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := tracing.ParseTraceParent(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !sc.IsSampled() || !sc.Remote {
+		t.Fatalf("expected a sampled remote span context")
+	}
+
+	if got := sc.TraceParent(); got != v {
+		t.Fatalf("expected: %s but got: %s", v, got)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, err = tracing.ParseTraceParent(invalid); err != tracing.ErrInvalidTraceParent {
+			t.Fatalf("[%s] expected an invalid traceparent error but got: %v", invalid, err)
+		}
+	}
+
+	// future versions may append fields.
+	if _, err = tracing.ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	// the downstream service, which receives the propagated context.
+	var outgoing http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outgoing = r.Header
+	}))
+	defer downstream.Close()
+
+	rec := new(recorder)
+	tracer := tracing.New(tracing.Options{Exporter: rec, HandlerSpans: true})
+
+	app := iris.New()
+	app.Use(tracer.Serve)
+	app.Get("/users/{id:uint64}", func(ctx iris.Context) {
+		ctx.Next()
+	}, func(ctx iris.Context) {
+		req, _ := http.NewRequest(http.MethodGet, downstream.URL, nil)
+		req = req.WithContext(ctx.Request().Context())
+		tracing.Inject(req.Context(), req.Header)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			ctx.StopWithError(iris.StatusBadGateway, err)
+			return
+		}
+		resp.Body.Close()
+	})
+
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+
+	e := irishttptest.New(t, app)
+	resp := e.GET("/users/42").
+		WithHeader(tracing.TraceParentHeaderKey, "00-"+traceID+"-"+parentSpanID+"-01").
+		WithHeader(tracing.TraceStateHeaderKey, "vendor=value").
+		Expect().Status(irishttptest.StatusOK)
+
+	resp.Header(tracing.TraceStateHeaderKey).Equal("vendor=value")
+	serverSC, err := tracing.ParseTraceParent(resp.Raw().Header.Get(tracing.TraceParentHeaderKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = tracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// server span + 2 handler spans.
+	if expected, got := 3, len(rec.spans); expected != got {
+		t.Fatalf("expected %d spans but got %d", expected, got)
+	}
+
+	// the handlers end first.
+	main, middleware, server := rec.spans[0], rec.spans[1], rec.spans[2]
+	if expected, got := "GET /users/{id:uint64}", server.Name; expected != got {
+		t.Fatalf("expected server span name: %s but got: %s", expected, got)
+	}
+
+	if server.Kind != tracing.SpanKindServer || server.SpanContext().SpanID != serverSC.SpanID {
+		t.Fatalf("unexpected server span: %#+v", server)
+	}
+
+	if got := server.ParentSpanID.String(); got != parentSpanID {
+		t.Fatalf("expected the remote parent span id: %s but got: %s", parentSpanID, got)
+	}
+
+	if got := server.Attributes["http.status_code"]; got != iris.StatusOK {
+		t.Fatalf("expected status code attribute: %v", got)
+	}
+
+	for _, s := range rec.spans {
+		if got := s.SpanContext().TraceID.String(); got != traceID {
+			t.Fatalf("[%s] expected trace id: %s but got: %s", s.Name, traceID, got)
+		}
+	}
+
+	if middleware.ParentSpanID != server.SpanContext().SpanID || main.ParentSpanID != middleware.SpanContext().SpanID {
+		t.Fatalf("expected nested handler spans")
+	}
+
+	if main.Kind != tracing.SpanKindInternal || main.Name == "" || middleware.Name == "" {
+		t.Fatalf("expected the handler names as the span names but got: %s and %s", middleware.Name, main.Name)
+	}
+
+	// the outgoing request continues the main handler's span.
+	outSC, ok := tracing.Extract(outgoing)
+	if !ok {
+		t.Fatalf("expected a traceparent on the outgoing request")
+	}
+
+	if outSC.SpanID != main.SpanContext().SpanID || outSC.TraceState != "vendor=value" {
+		t.Fatalf("unexpected outgoing span context: %#+v", outSC)
+	}
+}
+
+func TestTracerNotSampled(t *testing.T) {
+	rec := new(recorder)
+	tracer := tracing.New(tracing.Options{Exporter: rec})
+
+	app := iris.New()
+	app.Use(tracer.Serve)
+	app.Get("/", func(ctx iris.Context) {})
+
+	e := irishttptest.New(t, app)
+	e.GET("/").WithHeader(tracing.TraceParentHeaderKey, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00").
+		Expect().Status(irishttptest.StatusOK).
+		Header(tracing.TraceParentHeaderKey).Match("^00-4bf92f3577b34da6a3ce929d0e0e4736-[0-9a-f]{16}-00$")
+
+	tracer.Close()
+	if len(rec.spans) != 0 {
+		t.Fatalf("expected no exported spans but got %d", len(rec.spans))
+	}
+}
+
+// blockingExporter blocks the exports until "unblock" is closed.
+type blockingExporter struct {
+	recorder
+	unblock chan struct{}
+}
+
+func (b *blockingExporter) Export(serviceName string, spans []*tracing.Span) error {
+	<-b.unblock
+	return b.recorder.Export(serviceName, spans)
+}
+
+func TestTracerMaxQueueSize(t *testing.T) {
+	exporter := &blockingExporter{unblock: make(chan struct{})}
+	tracer := tracing.New(tracing.Options{Exporter: exporter, BatchSize: 1, MaxQueueSize: 2})
+
+	// the first span is exported (and blocks), the next two are queued and the rest are dropped.
+	span, _ := tracer.Start(stdContext.Background(), "0", tracing.SpanKindInternal)
+	span.End()
+	for i := 0; i < 50; i++ {
+		tracer.Flush()
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := 1; i < 6; i++ {
+		span, _ := tracer.Start(stdContext.Background(), strconv.Itoa(i), tracing.SpanKindInternal)
+		span.End()
+	}
+
+	if expected, got := uint64(3), tracer.Dropped(); expected != got {
+		t.Fatalf("expected %d dropped spans but got: %d", expected, got)
+	}
+
+	close(exporter.unblock)
+	tracer.Close()
+	if expected, got := 3, len(exporter.spans); expected != got {
+		t.Fatalf("expected %d exported spans but got: %d", expected, got)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var body map[string]interface{}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(b, &body)
+	}))
+	defer collector.Close()
+
+	var exportErr error
+	tracer := tracing.New(tracing.Options{
+		ServiceName: "users",
+		Exporter:    tracing.NewOTLPExporter(collector.URL + "/v1/traces"),
+		OnError:     func(err error) { exportErr = err },
+	})
+
+	app := iris.New()
+	app.Use(tracer.Serve)
+	app.Get("/", func(ctx iris.Context) {
+		ctx.StatusCode(iris.StatusInternalServerError)
+	})
+
+	e := irishttptest.New(t, app)
+	e.GET("/").Expect().Status(irishttptest.StatusInternalServerError)
+	tracer.Close()
+
+	if exportErr != nil {
+		t.Fatal(exportErr)
+	}
+
+	rs := body["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	service := rs["resource"].(map[string]interface{})["attributes"].([]interface{})[0].(map[string]interface{})
+	if got := service["value"].(map[string]interface{})["stringValue"]; got != "users" {
+		t.Fatalf("expected the service name but got: %v", got)
+	}
+
+	span := rs["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})[0].(map[string]interface{})
+	if expected, got := "GET /", span["name"]; expected != got {
+		t.Fatalf("expected span name: %s but got: %v", expected, got)
+	}
+
+	if got := span["kind"]; got != float64(tracing.SpanKindServer) {
+		t.Fatalf("expected server kind but got: %v", got)
+	}
+
+	if got := span["status"].(map[string]interface{})["code"]; got != float64(tracing.StatusError) {
+		t.Fatalf("expected error status but got: %v", got)
+	}
+}
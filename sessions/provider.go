@@ -129,6 +129,14 @@ func (p *provider) Read(man *Sessions, sid string, expires time.Duration) *Sessi
 	return p.Init(man, sid, expires) // if not found create new
 }
 
+// Len returns the number of the sessions kept in memory.
+func (p *provider) Len() int {
+	p.mu.RLock()
+	n := len(p.sessions)
+	p.mu.RUnlock()
+	return n
+}
+
 func (p *provider) registerDestroyListener(ln DestroyListener) {
 	if ln == nil {
 		return
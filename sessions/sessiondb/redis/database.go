@@ -130,6 +130,18 @@ func (db *Database) Config() *Config {
 	return &db.c // 6 Aug 2019 - keep that for no breaking change.
 }
 
+// Evaler returns the database's driver which runs Lua scripts,
+// it is used by the redis stores of other packages, e.g. the rate limiter's one.
+// It returns `ErrDriverNotSupported` if the driver does not implement the `Evaler` interface.
+func (db *Database) Evaler() (Evaler, error) {
+	driver, ok := db.c.Driver.(Evaler)
+	if !ok {
+		return nil, ErrDriverNotSupported
+	}
+
+	return driver, nil
+}
+
 // Acquire receives a session's lifetime from the database,
 // if the return value is LifeTime{} then the session manager sets the life time based on the expiration duration lives in configuration.
 func (db *Database) Acquire(sid string, expires time.Duration) sessions.LifeTime {
@@ -270,4 +282,6 @@ var (
 	// [...]
 	// }
 	ErrKeyNotFound = errors.New("key not found")
+	// ErrDriverNotSupported is returned by `Database.Evaler` when the driver cannot run Lua scripts.
+	ErrDriverNotSupported = errors.New("redis: driver does not implement the Evaler interface")
 )
@@ -16,9 +16,20 @@ type Driver interface {
 	Delete(key string) error
 }
 
+// Evaler is implemented by the drivers which can run Lua scripts,
+// the `RedigoDriver` and the `RadixDriver`, see `Database.Evaler`.
+type Evaler interface {
+	// Eval runs a Lua "script" atomically, the "keys" are prefixed by the `Config.Prefix`.
+	// It returns the script's array reply as strings.
+	Eval(script string, keys []string, args ...string) ([]string, error)
+}
+
 var (
 	_ Driver = (*RedigoDriver)(nil)
 	_ Driver = (*RadixDriver)(nil)
+
+	_ Evaler = (*RedigoDriver)(nil)
+	_ Evaler = (*RadixDriver)(nil)
 )
 
 // Redigo returns the driver for the redigo go redis client.
@@ -335,3 +335,17 @@ func (r *RadixDriver) Delete(key string) error {
 	err := r.pool.Do(radix.Cmd(nil, "DEL", r.Config.Prefix+key))
 	return err
 }
+
+// Eval runs a Lua "script" atomically, the "keys" are prefixed by the `Config.Prefix`.
+// It returns the script's array reply as strings.
+func (r *RadixDriver) Eval(script string, keys []string, args ...string) ([]string, error) {
+	keysAndArgs := make([]string, 0, len(keys)+len(args))
+	for _, key := range keys {
+		keysAndArgs = append(keysAndArgs, r.Config.Prefix+key)
+	}
+	keysAndArgs = append(keysAndArgs, args...)
+
+	var reply []string
+	err := r.pool.Do(radix.NewEvalScript(len(keys), script).Cmd(&reply, keysAndArgs...))
+	return reply, err
+}
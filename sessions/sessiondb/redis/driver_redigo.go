@@ -280,6 +280,26 @@ func (r *RedigoDriver) Delete(key string) error {
 	return err
 }
 
+// Eval runs a Lua "script" atomically, the "keys" are prefixed by the `Config.Prefix`.
+// It returns the script's array reply as strings.
+func (r *RedigoDriver) Eval(script string, keys []string, args ...string) ([]string, error) {
+	c := r.pool.Get()
+	defer c.Close()
+	if err := c.Err(); err != nil {
+		return nil, err
+	}
+
+	keysAndArgs := make([]interface{}, 0, len(keys)+len(args))
+	for _, key := range keys {
+		keysAndArgs = append(keysAndArgs, r.Config.Prefix+key)
+	}
+	for _, arg := range args {
+		keysAndArgs = append(keysAndArgs, arg)
+	}
+
+	return redis.Strings(redis.NewScript(len(keys), script).Do(c, keysAndArgs...))
+}
+
 // Connect connects to the redis, called only once.
 func (r *RedigoDriver) Connect(c Config) error {
 	if c.Network == "" {
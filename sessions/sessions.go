@@ -32,6 +32,12 @@ func New(cfg Config) *Sessions {
 	}
 }
 
+// Len returns the number of the active sessions of this server,
+// e.g. to be exposed as a metric.
+func (s *Sessions) Len() int {
+	return s.provider.Len()
+}
+
 // UseDatabase adds a session database to the manager's provider,
 // a session db doesn't have write access
 func (s *Sessions) UseDatabase(db Database) {
@@ -178,6 +178,9 @@ func RegisterGroups(r router.Party, notFoundHandler context.Handler, groups ...*
 		}
 
 		route := r.Handle(vr.method, vr.path, NewMatcher(vr.versions))
+		if route != nil {
+			route.SetVersion(vr.versions.String())
+		}
 		actualRoutes = append(actualRoutes, route)
 	}
 
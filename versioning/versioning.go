@@ -1,6 +1,9 @@
 package versioning
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/kataras/iris/v12/context"
 
 	"github.com/hashicorp/go-version"
@@ -48,6 +51,22 @@ func Match(ctx context.Context, expectedVersion string) bool {
 // a handler per version or constraint, the key can be something like ">1, <=2" or just "1".
 type Map map[string]context.Handler
 
+// String returns the sorted, pipe separated, versions (or constraints) of this map,
+// excluding the `NotFound` entry.
+func (m Map) String() string {
+	versions := make([]string, 0, len(m))
+	for v := range m {
+		if v == NotFound {
+			continue
+		}
+
+		versions = append(versions, v)
+	}
+
+	sort.Strings(versions)
+	return strings.Join(versions, " | ")
+}
+
 // NewMatcher creates a single handler which decides what handler
 // should be executed based on the requested version.
 //
@@ -373,7 +373,20 @@ func (s *DjangoEngine) ExecuteWriter(w io.Writer, filename string, layout string
 	}
 
 	if tmpl := s.fromCache(filename); tmpl != nil {
-		return tmpl.ExecuteWriter(getPongoContext(bindingData), w)
+		data := getPongoContext(bindingData)
+		if funcs := contextFuncs(w); funcs != nil {
+			// do not modify the view data, the functions can be overridden by them.
+			withFuncs := make(pongo2.Context, len(funcs)+len(data))
+			for k, v := range funcs {
+				withFuncs[k] = v
+			}
+			for k, v := range data {
+				withFuncs[k] = v
+			}
+			data = withFuncs
+		}
+
+		return tmpl.ExecuteWriter(data, w)
 	}
 
 	return fmt.Errorf("template with name %s doesn't exists in the dir", filename)
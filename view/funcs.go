@@ -1,5 +1,12 @@
 package view
 
+import (
+	"io"
+	"sync"
+
+	"github.com/kataras/iris/v12/context"
+)
+
 // EngineFuncer is an addition of a view engine,
 // if a view engine implements that interface
 // then iris can add some closed-relative iris functions
@@ -8,3 +15,82 @@ type EngineFuncer interface {
 	// AddFunc should adds a function to the template's function map.
 	AddFunc(funcName string, funcBody interface{})
 }
+
+var (
+	globalFuncsMu      sync.RWMutex
+	globalFuncs        = make(map[string]interface{})
+	globalContextFuncs = make(map[string]func(ctx context.Context) interface{})
+)
+
+// RegisterFunc registers a function which is added to every
+// view engine that implements the `EngineFuncer` on its registration, see `View.Register`.
+// It is useful for packages that provide template functions.
+func RegisterFunc(funcName string, funcBody interface{}) {
+	globalFuncsMu.Lock()
+	globalFuncs[funcName] = funcBody
+	globalFuncsMu.Unlock()
+}
+
+// RegisterContextFunc registers a function which is bound to the request's Context
+// on each execution of the HTML, Django and Jet view engines,
+// "newFunc" receives the Context and returns the template function's body.
+// It is useful for packages that provide template functions of the current request,
+// e.g. the csrf middleware registers its "csrf_field" function on init,
+// which can be used as {{ csrf_field }} whatever the view data are.
+//
+// The functions are bound when the template is rendered through `Context.View`.
+func RegisterContextFunc(funcName string, newFunc func(ctx context.Context) interface{}) {
+	globalFuncsMu.Lock()
+	globalContextFuncs[funcName] = newFunc
+	globalFuncsMu.Unlock()
+}
+
+func addGlobalFuncs(e Engine) {
+	engineFuncer, ok := e.(EngineFuncer)
+	if !ok {
+		return
+	}
+
+	globalFuncsMu.RLock()
+	for funcName, funcBody := range globalFuncs {
+		engineFuncer.AddFunc(funcName, funcBody)
+	}
+	globalFuncsMu.RUnlock()
+}
+
+// contextFuncs returns the functions of `RegisterContextFunc` bound to the "w",
+// if it's a Context, otherwise nil.
+func contextFuncs(w io.Writer) map[string]interface{} {
+	ctx, ok := w.(context.Context)
+	if !ok {
+		return nil
+	}
+
+	globalFuncsMu.RLock()
+	defer globalFuncsMu.RUnlock()
+
+	if len(globalContextFuncs) == 0 {
+		return nil
+	}
+
+	funcs := make(map[string]interface{}, len(globalContextFuncs))
+	for funcName, newFunc := range globalContextFuncs {
+		funcs[funcName] = newFunc(ctx)
+	}
+
+	return funcs
+}
+
+// unboundContextFuncs returns the functions of `RegisterContextFunc`
+// which are not bound to a Context, they return an empty string.
+func unboundContextFuncs() map[string]interface{} {
+	globalFuncsMu.RLock()
+	defer globalFuncsMu.RUnlock()
+
+	funcs := make(map[string]interface{}, len(globalContextFuncs))
+	for funcName := range globalContextFuncs {
+		funcs[funcName] = func(...interface{}) string { return "" }
+	}
+
+	return funcs
+}
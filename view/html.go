@@ -32,6 +32,9 @@ type HTMLEngine struct {
 	//
 	middleware func(name string, contents []byte) (string, error)
 	Templates  *template.Template
+	// clones of the, never executed, loaded templates
+	// for the executions which bind the context functions, see `RegisterContextFunc`.
+	clones *sync.Pool
 	//
 }
 
@@ -217,7 +220,7 @@ func (s *HTMLEngine) Load() error {
 		// }
 
 		// embedded
-		return s.loadAssets()
+		return s.prepareClones(s.loadAssets())
 	}
 
 	// load from directory, make the dir absolute here too.
@@ -232,7 +235,35 @@ func (s *HTMLEngine) Load() error {
 
 	// change the directory field configuration, load happens after directory has been set, so we will not have any problems here.
 	s.directory = dir
-	return s.loadDirectory()
+	return s.prepareClones(s.loadDirectory())
+}
+
+// prepareClones prepares the clones of the loaded templates,
+// if there are context functions to bind, see `ExecuteWriter`.
+func (s *HTMLEngine) prepareClones(loadErr error) error {
+	s.clones = nil
+	if loadErr != nil || len(unboundContextFuncs()) == 0 {
+		return loadErr
+	}
+
+	// the templates which are executed are cloned,
+	// as a template cannot be cloned after its execution.
+	master, err := s.Templates.Clone()
+	if err != nil {
+		return err
+	}
+
+	s.clones = &sync.Pool{
+		New: func() interface{} {
+			clone, err := master.Clone()
+			if err != nil {
+				return nil
+			}
+			return clone
+		},
+	}
+
+	return nil
 }
 
 // loadDirectory builds the templates from directory.
@@ -242,6 +273,7 @@ func (s *HTMLEngine) loadDirectory() error {
 	var templateErr error
 	s.Templates = template.New(dir)
 	s.Templates.Delims(s.left, s.right)
+	contextFuncs := unboundContextFuncs()
 
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if info == nil || info.IsDir() {
@@ -275,7 +307,7 @@ func (s *HTMLEngine) loadDirectory() error {
 				}
 				// s.mu.Lock()
 				// Add our funcmaps.
-				_, err = tmpl.Funcs(emptyFuncs).Funcs(s.funcs).Parse(contents)
+				_, err = tmpl.Funcs(emptyFuncs).Funcs(contextFuncs).Funcs(s.funcs).Parse(contents)
 				// s.mu.Unlock()
 				if err != nil {
 					templateErr = err
@@ -302,6 +334,7 @@ func (s *HTMLEngine) loadAssets() error {
 	var templateErr error
 	s.Templates = template.New(virtualDirectory)
 	s.Templates.Delims(s.left, s.right)
+	contextFuncs := unboundContextFuncs()
 	names := namesFn()
 	if len(virtualDirectory) > 0 {
 		if virtualDirectory[0] == '.' { // first check for .wrong
@@ -377,7 +410,7 @@ func (s *HTMLEngine) loadAssets() error {
 			}
 
 			// Add our funcmaps.
-			if _, err = tmpl.Funcs(emptyFuncs).Funcs(s.funcs).Parse(contents); err != nil {
+			if _, err = tmpl.Funcs(emptyFuncs).Funcs(contextFuncs).Funcs(s.funcs).Parse(contents); err != nil {
 				templateErr = err
 				break
 			}
@@ -386,24 +419,24 @@ func (s *HTMLEngine) loadAssets() error {
 	return templateErr
 }
 
-func (s *HTMLEngine) executeTemplateBuf(name string, binding interface{}) (*bytes.Buffer, error) {
+func (s *HTMLEngine) executeTemplateBuf(t *template.Template, name string, binding interface{}) (*bytes.Buffer, error) {
 	buf := new(bytes.Buffer)
-	err := s.Templates.ExecuteTemplate(buf, name, binding)
+	err := t.ExecuteTemplate(buf, name, binding)
 
 	return buf, err
 }
 
-func (s *HTMLEngine) layoutFuncsFor(name string, binding interface{}) {
+func (s *HTMLEngine) layoutFuncsFor(t *template.Template, name string, binding interface{}) {
 	funcs := template.FuncMap{
 		"yield": func() (template.HTML, error) {
-			buf, err := s.executeTemplateBuf(name, binding)
+			buf, err := s.executeTemplateBuf(t, name, binding)
 			// Return safe HTML here since we are rendering our own template.
 			return template.HTML(buf.String()), err
 		},
 		"part": func(partName string) (template.HTML, error) {
 			nameTemp := strings.Replace(name, ".html", "", -1)
 			fullPartName := fmt.Sprintf("%s-%s", nameTemp, partName)
-			buf, err := s.executeTemplateBuf(fullPartName, binding)
+			buf, err := s.executeTemplateBuf(t, fullPartName, binding)
 			if err != nil {
 				return "", nil
 			}
@@ -414,8 +447,8 @@ func (s *HTMLEngine) layoutFuncsFor(name string, binding interface{}) {
 		},
 		"partial": func(partialName string) (template.HTML, error) {
 			fullPartialName := fmt.Sprintf("%s-%s", partialName, name)
-			if s.Templates.Lookup(fullPartialName) != nil {
-				buf, err := s.executeTemplateBuf(fullPartialName, binding)
+			if t.Lookup(fullPartialName) != nil {
+				buf, err := s.executeTemplateBuf(t, fullPartialName, binding)
 				return template.HTML(buf.String()), err
 			}
 			return "", nil
@@ -428,14 +461,14 @@ func (s *HTMLEngine) layoutFuncsFor(name string, binding interface{}) {
 			ext := filepath.Ext(name)
 			root := name[:len(name)-len(ext)]
 			fullPartialName := fmt.Sprintf("%s%s%s", root, partialName, ext)
-			if s.Templates.Lookup(fullPartialName) != nil {
-				buf, err := s.executeTemplateBuf(fullPartialName, binding)
+			if t.Lookup(fullPartialName) != nil {
+				buf, err := s.executeTemplateBuf(t, fullPartialName, binding)
 				return template.HTML(buf.String()), err
 			}
 			return "", nil
 		},
 		"render": func(fullPartialName string) (template.HTML, error) {
-			buf, err := s.executeTemplateBuf(fullPartialName, binding)
+			buf, err := s.executeTemplateBuf(t, fullPartialName, binding)
 			return template.HTML(buf.String()), err
 		},
 	}
@@ -443,20 +476,20 @@ func (s *HTMLEngine) layoutFuncsFor(name string, binding interface{}) {
 	for k, v := range s.layoutFuncs {
 		funcs[k] = v
 	}
-	if tpl := s.Templates.Lookup(name); tpl != nil {
+	if tpl := t.Lookup(name); tpl != nil {
 		tpl.Funcs(funcs)
 	}
 }
 
-func (s *HTMLEngine) runtimeFuncsFor(name string, binding interface{}) {
+func (s *HTMLEngine) runtimeFuncsFor(t *template.Template, name string, binding interface{}) {
 	funcs := template.FuncMap{
 		"render": func(fullPartialName string) (template.HTML, error) {
-			buf, err := s.executeTemplateBuf(fullPartialName, binding)
+			buf, err := s.executeTemplateBuf(t, fullPartialName, binding)
 			return template.HTML(buf.String()), err
 		},
 	}
 
-	if tpl := s.Templates.Lookup(name); tpl != nil {
+	if tpl := t.Lookup(name); tpl != nil {
 		tpl.Funcs(funcs)
 	}
 }
@@ -476,12 +509,23 @@ func (s *HTMLEngine) ExecuteWriter(w io.Writer, name string, layout string, bind
 
 	layout = getLayout(layout, s.layout)
 
+	t := s.Templates
+	// the context functions are bound to a clone which is used by this execution only.
+	if clones := s.clones; clones != nil {
+		if funcs := contextFuncs(w); funcs != nil {
+			if clone, ok := clones.Get().(*template.Template); ok {
+				defer clones.Put(clone)
+				t = clone.Funcs(funcs)
+			}
+		}
+	}
+
 	if layout != "" {
-		s.layoutFuncsFor(name, bindingData)
+		s.layoutFuncsFor(t, name, bindingData)
 		name = layout
 	} else {
-		s.runtimeFuncsFor(name, bindingData)
+		s.runtimeFuncsFor(t, name, bindingData)
 	}
 
-	return s.Templates.ExecuteTemplate(w, name, bindingData)
+	return t.ExecuteTemplate(w, name, bindingData)
 }
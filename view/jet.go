@@ -370,6 +370,18 @@ func (s *JetEngine) ExecuteWriter(w io.Writer, filename string, layout string, b
 		}
 	}
 
+	if funcs := contextFuncs(w); funcs != nil {
+		if vars == nil {
+			vars = make(JetRuntimeVars)
+		}
+
+		for k, v := range funcs {
+			if _, ok := vars[k]; !ok {
+				vars[k] = reflect.ValueOf(v)
+			}
+		}
+	}
+
 	if bindingData == nil {
 		return tmpl.Execute(w, vars, nil)
 	}
@@ -15,7 +15,9 @@ type View struct {
 }
 
 // Register registers a view engine.
+// The functions of `RegisterFunc` are added to the engine, if it's an `EngineFuncer`.
 func (v *View) Register(e Engine) {
+	addGlobalFuncs(e)
 	v.engines = append(v.engines, e)
 }
 